@@ -9,11 +9,14 @@ import (
 	"github.com/Anurag-Mishra22/taxi/shared/contracts"
 	"github.com/Anurag-Mishra22/taxi/shared/env"
 	"github.com/Anurag-Mishra22/taxi/shared/messaging"
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
 	"github.com/Anurag-Mishra22/taxi/shared/tracing"
 	"time"
 
 	"github.com/stripe/stripe-go/v81"
 	"github.com/stripe/stripe-go/v81/webhook"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var tracer = tracing.GetTracer("api-gateway")
@@ -49,7 +52,16 @@ func handleTripStart(w http.ResponseWriter, r *http.Request) {
 			appMetrics.GRPCRequestDuration.WithLabelValues("CreateTrip").Observe(time.Since(grpcStart).Seconds())
 			appMetrics.GRPCRequestsTotal.WithLabelValues("CreateTrip", "error").Inc()
 		}
-		http.Error(w, "Failed to start trip", http.StatusInternalServerError)
+		switch status.Code(err) {
+		case codes.FailedPrecondition:
+			http.Error(w, "Ride fare has expired, please preview the trip again", http.StatusGone)
+		case codes.AlreadyExists:
+			http.Error(w, "Ride fare has already been used", http.StatusConflict)
+		case codes.ResourceExhausted:
+			http.Error(w, "Promotion code has reached its usage limit, please preview the trip again", http.StatusConflict)
+		default:
+			http.Error(w, "Failed to start trip", httpStatusFromGRPC(err))
+		}
 		return
 	}
 	if appMetrics != nil {
@@ -99,6 +111,20 @@ func handleTripPreview(w http.ResponseWriter, r *http.Request) {
 			appMetrics.GRPCRequestDuration.WithLabelValues("PreviewTrip").Observe(time.Since(grpcStart).Seconds())
 			appMetrics.GRPCRequestsTotal.WithLabelValues("PreviewTrip", "error").Inc()
 		}
+		if status.Code(err) == codes.InvalidArgument {
+			// Unusable promotion code, the message tells the rider why
+			http.Error(w, status.Convert(err).Message(), http.StatusBadRequest)
+			return
+		}
+		if status.Code(err) == codes.FailedPrecondition {
+			writeJSON(w, http.StatusUnprocessableEntity, contracts.APIResponse{
+				Error: &contracts.APIError{
+					Code:    contracts.ErrCodeOutsideServiceArea,
+					Message: status.Convert(err).Message(),
+				},
+			})
+			return
+		}
 		http.Error(w, "Failed to preview trip", http.StatusInternalServerError)
 		return
 	}
@@ -112,6 +138,265 @@ func handleTripPreview(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusCreated, response)
 }
 
+func handleTripCancel(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handleTripCancel")
+	defer span.End()
+
+	var reqBody cancelTripRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		http.Error(w, "failed to parse JSON data", http.StatusBadRequest)
+		return
+	}
+
+	defer r.Body.Close()
+
+	// validation
+	if reqBody.UserID == "" {
+		http.Error(w, "user ID is required", http.StatusBadRequest)
+		return
+	}
+
+	tripService, err := grpc_clients.NewTripServiceClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer tripService.Close()
+
+	grpcStart := time.Now()
+	cancelled, err := tripService.Client.CancelTrip(ctx, reqBody.toProto(r.PathValue("id")))
+	if err != nil {
+		log.Printf("Failed to cancel a trip: %v", err)
+		if appMetrics != nil {
+			appMetrics.GRPCRequestDuration.WithLabelValues("CancelTrip").Observe(time.Since(grpcStart).Seconds())
+			appMetrics.GRPCRequestsTotal.WithLabelValues("CancelTrip", "error").Inc()
+		}
+		http.Error(w, "Failed to cancel trip", httpStatusFromGRPC(err))
+		return
+	}
+	if appMetrics != nil {
+		appMetrics.GRPCRequestDuration.WithLabelValues("CancelTrip").Observe(time.Since(grpcStart).Seconds())
+		appMetrics.GRPCRequestsTotal.WithLabelValues("CancelTrip", "success").Inc()
+	}
+
+	response := contracts.APIResponse{Data: cancelled}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func handleGetTrip(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handleGetTrip")
+	defer span.End()
+
+	if r.URL.Query().Get("userID") == "" && r.URL.Query().Get("driverID") == "" {
+		http.Error(w, "userID or driverID is required", http.StatusBadRequest)
+		return
+	}
+
+	tripService, err := grpc_clients.NewTripServiceClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer tripService.Close()
+
+	grpcStart := time.Now()
+	// The trip-service only shows the trip to its rider or its driver
+	query := r.URL.Query()
+	trip, err := tripService.Client.GetTrip(ctx, &pb.GetTripRequest{
+		TripID:   r.PathValue("id"),
+		UserID:   query.Get("userID"),
+		DriverID: query.Get("driverID"),
+	})
+	if err != nil {
+		log.Printf("Failed to get a trip: %v", err)
+		if appMetrics != nil {
+			appMetrics.GRPCRequestDuration.WithLabelValues("GetTrip").Observe(time.Since(grpcStart).Seconds())
+			appMetrics.GRPCRequestsTotal.WithLabelValues("GetTrip", "error").Inc()
+		}
+		http.Error(w, "Failed to get trip", httpStatusFromGRPC(err))
+		return
+	}
+	if appMetrics != nil {
+		appMetrics.GRPCRequestDuration.WithLabelValues("GetTrip").Observe(time.Since(grpcStart).Seconds())
+		appMetrics.GRPCRequestsTotal.WithLabelValues("GetTrip", "success").Inc()
+	}
+
+	response := contracts.APIResponse{Data: trip.Trip}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func handleGetTripTimeline(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handleGetTripTimeline")
+	defer span.End()
+
+	if r.URL.Query().Get("userID") == "" && r.URL.Query().Get("driverID") == "" {
+		http.Error(w, "userID or driverID is required", http.StatusBadRequest)
+		return
+	}
+
+	tripService, err := grpc_clients.NewTripServiceClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer tripService.Close()
+
+	grpcStart := time.Now()
+	// The trip-service only shows the timeline to the rider or the driver of the trip
+	query := r.URL.Query()
+	timeline, err := tripService.Client.GetTripTimeline(ctx, &pb.GetTripTimelineRequest{
+		TripID:   r.PathValue("id"),
+		UserID:   query.Get("userID"),
+		DriverID: query.Get("driverID"),
+	})
+	if err != nil {
+		log.Printf("Failed to get a trip timeline: %v", err)
+		if appMetrics != nil {
+			appMetrics.GRPCRequestDuration.WithLabelValues("GetTripTimeline").Observe(time.Since(grpcStart).Seconds())
+			appMetrics.GRPCRequestsTotal.WithLabelValues("GetTripTimeline", "error").Inc()
+		}
+		http.Error(w, "Failed to get trip timeline", httpStatusFromGRPC(err))
+		return
+	}
+	if appMetrics != nil {
+		appMetrics.GRPCRequestDuration.WithLabelValues("GetTripTimeline").Observe(time.Since(grpcStart).Seconds())
+		appMetrics.GRPCRequestsTotal.WithLabelValues("GetTripTimeline", "success").Inc()
+	}
+
+	response := contracts.APIResponse{Data: timeline.Entries}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func handleListPackages(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handleListPackages")
+	defer span.End()
+
+	tripService, err := grpc_clients.NewTripServiceClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer tripService.Close()
+
+	grpcStart := time.Now()
+	packages, err := tripService.Client.ListPackages(ctx, &pb.ListPackagesRequest{})
+	if err != nil {
+		log.Printf("Failed to list packages: %v", err)
+		if appMetrics != nil {
+			appMetrics.GRPCRequestDuration.WithLabelValues("ListPackages").Observe(time.Since(grpcStart).Seconds())
+			appMetrics.GRPCRequestsTotal.WithLabelValues("ListPackages", "error").Inc()
+		}
+		http.Error(w, "Failed to list packages", httpStatusFromGRPC(err))
+		return
+	}
+	if appMetrics != nil {
+		appMetrics.GRPCRequestDuration.WithLabelValues("ListPackages").Observe(time.Since(grpcStart).Seconds())
+		appMetrics.GRPCRequestsTotal.WithLabelValues("ListPackages", "success").Inc()
+	}
+
+	response := contracts.APIResponse{Data: packages.Packages}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func handleListTrips(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handleListTrips")
+	defer span.End()
+
+	req, err := listTripsRequestFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tripService, err := grpc_clients.NewTripServiceClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer tripService.Close()
+
+	grpcStart := time.Now()
+	trips, err := tripService.Client.ListTrips(ctx, req)
+	if err != nil {
+		log.Printf("Failed to list trips: %v", err)
+		if appMetrics != nil {
+			appMetrics.GRPCRequestDuration.WithLabelValues("ListTrips").Observe(time.Since(grpcStart).Seconds())
+			appMetrics.GRPCRequestsTotal.WithLabelValues("ListTrips", "error").Inc()
+		}
+		http.Error(w, "Failed to list trips", httpStatusFromGRPC(err))
+		return
+	}
+	if appMetrics != nil {
+		appMetrics.GRPCRequestDuration.WithLabelValues("ListTrips").Observe(time.Since(grpcStart).Seconds())
+		appMetrics.GRPCRequestsTotal.WithLabelValues("ListTrips", "success").Inc()
+	}
+
+	response := contracts.APIResponse{Data: trips}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleListScheduledTrips returns the upcoming bookings of a rider, soonest pickup first
+func handleListScheduledTrips(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handleListScheduledTrips")
+	defer span.End()
+
+	userID := r.URL.Query().Get("userID")
+	if userID == "" {
+		http.Error(w, "user ID is required", http.StatusBadRequest)
+		return
+	}
+
+	tripService, err := grpc_clients.NewTripServiceClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer tripService.Close()
+
+	grpcStart := time.Now()
+	trips, err := tripService.Client.ListScheduledTrips(ctx, &pb.ListScheduledTripsRequest{UserID: userID})
+	if err != nil {
+		log.Printf("Failed to list scheduled trips: %v", err)
+		if appMetrics != nil {
+			appMetrics.GRPCRequestDuration.WithLabelValues("ListScheduledTrips").Observe(time.Since(grpcStart).Seconds())
+			appMetrics.GRPCRequestsTotal.WithLabelValues("ListScheduledTrips", "error").Inc()
+		}
+		http.Error(w, "Failed to list scheduled trips", httpStatusFromGRPC(err))
+		return
+	}
+	if appMetrics != nil {
+		appMetrics.GRPCRequestDuration.WithLabelValues("ListScheduledTrips").Observe(time.Since(grpcStart).Seconds())
+		appMetrics.GRPCRequestsTotal.WithLabelValues("ListScheduledTrips", "success").Inc()
+	}
+
+	response := contracts.APIResponse{Data: trips}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// httpStatusFromGRPC maps the status of a failed gRPC call to the closest HTTP status code
+func httpStatusFromGRPC(err error) int {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.AlreadyExists, codes.FailedPrecondition:
+		return http.StatusConflict
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func handleStripeWebhook(w http.ResponseWriter, r *http.Request, rb *messaging.RabbitMQ) {
 	ctx, span := tracer.Start(r.Context(), "handleStripeWebhook")
 	defer span.End()
@@ -157,9 +442,10 @@ func handleStripeWebhook(w http.ResponseWriter, r *http.Request, rb *messaging.R
 		}
 
 		payload := messaging.PaymentStatusUpdateData{
-			TripID:   session.Metadata["trip_id"],
-			UserID:   session.Metadata["user_id"],
-			DriverID: session.Metadata["driver_id"],
+			TripID:     session.Metadata["trip_id"],
+			UserID:     session.Metadata["user_id"],
+			DriverID:   session.Metadata["driver_id"],
+			ChargeType: session.Metadata["charge_type"],
 		}
 
 		payloadBytes, err := json.Marshal(payload)
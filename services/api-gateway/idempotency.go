@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/cache"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// idempotencyKeyHeader lets clients retry a request without running it twice
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyCacheName labels the idempotency store in the cache metrics
+const idempotencyCacheName = "idempotency"
+
+// idempotentRequest is the record of a request made with an idempotency key.
+// It is stored before the request runs, and completed with the response afterwards.
+type idempotentRequest struct {
+	// Fingerprint is the hash of the request, a key can't be reused for another request
+	Fingerprint string `json:"fingerprint"`
+	Completed   bool   `json:"completed"`
+	StatusCode  int    `json:"statusCode,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+type idempotencyStore struct {
+	redis *cache.RedisClient
+	// ttl is how long the responses are replayed
+	ttl time.Duration
+	// lockTTL is how long a request is considered in progress, in case the gateway stops before completing it
+	lockTTL time.Duration
+}
+
+func newIdempotencyStore(redis *cache.RedisClient, ttl, lockTTL time.Duration) *idempotencyStore {
+	return &idempotencyStore{
+		redis:   redis,
+		ttl:     ttl,
+		lockTTL: lockTTL,
+	}
+}
+
+// withIdempotency replays the response of requests retried with the same Idempotency-Key.
+// Keys are scoped to the user of the request, two users can't see each other's responses.
+// Requests without the header, without a user, or without a store, are handled as usual.
+func withIdempotency(store *idempotencyStore, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if store == nil || key == "" {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID := requestUserID(body)
+		if userID == "" {
+			// The handler rejects requests without a user
+			next(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		redisKey := "idempotency:" + userID + ":" + r.URL.Path + ":" + key
+		fingerprint := requestFingerprint(r, body)
+
+		acquired, err := store.redis.SetNXJSON(ctx, redisKey, idempotentRequest{Fingerprint: fingerprint}, store.lockTTL)
+		if err != nil {
+			// The trip-service still refuses a second trip for a fare, so fail open
+			log.Printf("Failed to store idempotency key %s: %v", key, err)
+			next(w, r)
+			return
+		}
+
+		if !acquired {
+			store.replay(w, r, redisKey, fingerprint)
+			return
+		}
+		store.recordLookup(false)
+
+		recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next(recorder, r)
+
+		// The request ran, record its outcome even if the client went away
+		ctx = context.WithoutCancel(ctx)
+
+		// Server errors are not stored, the client can retry them with the same key
+		if recorder.statusCode >= http.StatusInternalServerError {
+			if err := store.redis.Del(ctx, redisKey); err != nil {
+				log.Printf("Failed to release idempotency key %s: %v", key, err)
+			}
+			return
+		}
+
+		completed := idempotentRequest{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			StatusCode:  recorder.statusCode,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}
+		if err := store.redis.SetJSON(ctx, redisKey, completed, store.ttl); err != nil {
+			log.Printf("Failed to store the response of idempotency key %s: %v", key, err)
+		}
+	}
+}
+
+func (s *idempotencyStore) replay(w http.ResponseWriter, r *http.Request, redisKey, fingerprint string) {
+	var stored idempotentRequest
+	err := s.redis.GetJSON(r.Context(), redisKey, &stored)
+	if errors.Is(err, redis.Nil) {
+		// Released or expired in the meantime
+		http.Error(w, "A request with this Idempotency-Key is in progress, please retry", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to read idempotency key %s: %v", redisKey, err)
+		http.Error(w, "Failed to process the request", http.StatusInternalServerError)
+		return
+	}
+
+	if stored.Fingerprint != fingerprint {
+		http.Error(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if !stored.Completed {
+		http.Error(w, "A request with this Idempotency-Key is in progress, please retry", http.StatusConflict)
+		return
+	}
+	s.recordLookup(true)
+
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.StatusCode)
+	w.Write(stored.Body)
+}
+
+func (s *idempotencyStore) recordLookup(hit bool) {
+	if appMetrics != nil {
+		appMetrics.RecordCacheLookup(idempotencyCacheName, hit)
+	}
+}
+
+// requestUserID is the userID of a JSON request body, the gateway has no other user identity yet
+func requestUserID(body []byte) string {
+	var request struct {
+		UserID string `json:"userID"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return ""
+	}
+	return request.UserID
+}
+
+// requestFingerprint identifies a request by its method, path and body
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response while writing it
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(code int) {
+	rr.statusCode = code
+	rr.ResponseWriter.WriteHeader(code)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/cache"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIdempotencyStore(t *testing.T) *idempotencyStore {
+	server := miniredis.RunT(t)
+	t.Setenv("REDIS_HOST", server.Host())
+	t.Setenv("REDIS_PORT", server.Port())
+
+	client, err := cache.NewRedisClient()
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return newIdempotencyStore(client, time.Hour, time.Minute)
+}
+
+// countingHandler answers with status and counts its calls
+func countingHandler(calls *atomic.Int32, status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"call":%d}`, n)
+	}
+}
+
+func idempotentRequestTo(handler http.HandlerFunc, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/trip/start", strings.NewReader(body))
+	r.Header.Set(idempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func TestIdempotencyReplaysTheResponse(t *testing.T) {
+	var calls atomic.Int32
+	handler := withIdempotency(newTestIdempotencyStore(t), countingHandler(&calls, http.StatusCreated))
+
+	first := idempotentRequestTo(handler, "key", `{"userID":"rider"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	replayed := idempotentRequestTo(handler, "key", `{"userID":"rider"}`)
+	assert.Equal(t, http.StatusCreated, replayed.Code)
+	assert.Equal(t, "true", replayed.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "application/json", replayed.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), replayed.Body.String())
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotencyKeysAreScopedToTheUser(t *testing.T) {
+	var calls atomic.Int32
+	handler := withIdempotency(newTestIdempotencyStore(t), countingHandler(&calls, http.StatusCreated))
+
+	idempotentRequestTo(handler, "key", `{"userID":"rider"}`)
+	other := idempotentRequestTo(handler, "key", `{"userID":"other-rider"}`)
+
+	assert.Equal(t, http.StatusCreated, other.Code)
+	assert.Empty(t, other.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotencyRejectsAConcurrentDuplicate(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls atomic.Int32
+	handler := withIdempotency(newTestIdempotencyStore(t), func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- idempotentRequestTo(handler, "key", `{"userID":"rider"}`) }()
+	<-started
+
+	duplicate := idempotentRequestTo(handler, "key", `{"userID":"rider"}`)
+	assert.Equal(t, http.StatusConflict, duplicate.Code)
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotencyRejectsAKeyReusedForAnotherBody(t *testing.T) {
+	var calls atomic.Int32
+	handler := withIdempotency(newTestIdempotencyStore(t), countingHandler(&calls, http.StatusCreated))
+
+	idempotentRequestTo(handler, "key", `{"userID":"rider","rideFareID":"a"}`)
+	mismatch := idempotentRequestTo(handler, "key", `{"userID":"rider","rideFareID":"b"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, mismatch.Code)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotencyLetsServerErrorsBeRetried(t *testing.T) {
+	var calls atomic.Int32
+	status := http.StatusBadGateway
+	handler := withIdempotency(newTestIdempotencyStore(t), func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(status)
+	})
+
+	failed := idempotentRequestTo(handler, "key", `{"userID":"rider"}`)
+	assert.Equal(t, http.StatusBadGateway, failed.Code)
+
+	status = http.StatusCreated
+	retried := idempotentRequestTo(handler, "key", `{"userID":"rider"}`)
+	assert.Equal(t, http.StatusCreated, retried.Code)
+	assert.Empty(t, retried.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -1,19 +1,33 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
 	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
 	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type previewTripRequest struct {
 	UserID      string           `json:"userID"`
 	Pickup      types.Coordinate `json:"pickup"`
 	Destination types.Coordinate `json:"destination"`
+	PromoCode   string           `json:"promoCode,omitempty"`
+	// ScheduledFor books the trip for a later pickup, RFC 3339
+	ScheduledFor *time.Time `json:"scheduledFor,omitempty"`
+	// Stops are visited in order between the pickup and the destination
+	Stops []types.Coordinate `json:"stops,omitempty"`
 }
 
 func (p *previewTripRequest) toProto() *pb.PreviewTripRequest {
-	return &pb.PreviewTripRequest{
-		UserID: p.UserID,
+	req := &pb.PreviewTripRequest{
+		UserID:    p.UserID,
+		PromoCode: p.PromoCode,
 		StartLocation: &pb.Coordinate{
 			Latitude:  p.Pickup.Latitude,
 			Longitude: p.Pickup.Longitude,
@@ -23,6 +37,16 @@ func (p *previewTripRequest) toProto() *pb.PreviewTripRequest {
 			Longitude: p.Destination.Longitude,
 		},
 	}
+	if p.ScheduledFor != nil {
+		req.ScheduledFor = timestamppb.New(*p.ScheduledFor)
+	}
+	for _, stop := range p.Stops {
+		req.Stops = append(req.Stops, &pb.Coordinate{
+			Latitude:  stop.Latitude,
+			Longitude: stop.Longitude,
+		})
+	}
+	return req
 }
 
 type startTripRequest struct {
@@ -35,4 +59,59 @@ func (c *startTripRequest) toProto() *pb.CreateTripRequest {
 		RideFareID: c.RideFareID,
 		UserID:     c.UserID,
 	}
-}
\ No newline at end of file
+}
+
+type cancelTripRequest struct {
+	UserID string `json:"userID"`
+	Reason string `json:"reason"`
+}
+
+func (c *cancelTripRequest) toProto(tripID string) *pb.CancelTripRequest {
+	return &pb.CancelTripRequest{
+		TripID: tripID,
+		UserID: c.UserID,
+		Reason: c.Reason,
+	}
+}
+
+// listTripsRequestFromQuery builds a ListTrips request from the query string of GET /trips.
+// Dates use the RFC 3339 format.
+func listTripsRequestFromQuery(query url.Values) (*pb.ListTripsRequest, error) {
+	req := &pb.ListTripsRequest{
+		UserID:    query.Get("userID"),
+		DriverID:  query.Get("driverID"),
+		Status:    query.Get("status"),
+		PageToken: query.Get("pageToken"),
+	}
+
+	// Listing the trips of every user is not exposed
+	if req.UserID == "" && req.DriverID == "" {
+		return nil, errors.New("userID or driverID is required")
+	}
+
+	if v := query.Get("pageSize"); v != "" {
+		pageSize, err := strconv.Atoi(v)
+		if err != nil || pageSize < 0 {
+			return nil, fmt.Errorf("invalid pageSize: %s", v)
+		}
+		req.PageSize = int32(pageSize)
+	}
+
+	if v := query.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from date: %s", v)
+		}
+		req.CreatedFrom = timestamppb.New(from)
+	}
+
+	if v := query.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to date: %s", v)
+		}
+		req.CreatedTo = timestamppb.New(to)
+	}
+
+	return req, nil
+}
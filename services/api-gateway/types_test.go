@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTripsRequestFromQuery(t *testing.T) {
+	req, err := listTripsRequestFromQuery(url.Values{
+		"userID":    {"rider"},
+		"status":    {"paid"},
+		"pageSize":  {"10"},
+		"pageToken": {"6ad2db17ee4211b71d622a95"},
+		"from":      {"2025-01-01T00:00:00Z"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "rider", req.UserID)
+	assert.Equal(t, "paid", req.Status)
+	assert.Equal(t, int32(10), req.PageSize)
+	assert.Equal(t, "6ad2db17ee4211b71d622a95", req.PageToken)
+	assert.NotNil(t, req.CreatedFrom)
+	assert.Nil(t, req.CreatedTo)
+
+	_, err = listTripsRequestFromQuery(url.Values{"driverID": {"driver"}})
+	assert.NoError(t, err)
+
+	invalid := []url.Values{
+		{"status": {"paid"}},
+		{"userID": {"rider"}, "pageSize": {"-1"}},
+		{"userID": {"rider"}, "to": {"yesterday"}},
+	}
+	for _, query := range invalid {
+		_, err := listTripsRequestFromQuery(query)
+		assert.Error(t, err, query.Encode())
+	}
+}
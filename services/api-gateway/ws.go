@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -8,7 +9,12 @@ import (
 	"github.com/Anurag-Mishra22/taxi/shared/contracts"
 	"github.com/Anurag-Mishra22/taxi/shared/messaging"
 	"github.com/Anurag-Mishra22/taxi/shared/proto/driver"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
 	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -47,6 +53,8 @@ func handleRidersWebSocket(w http.ResponseWriter, r *http.Request, rb *messaging
 		messaging.NotifyDriverNoDriversFoundQueue,
 		messaging.NotifyDriverAssignQueue,
 		messaging.NotifyPaymentSessionCreatedQueue,
+		messaging.NotifyStopReachedQueue,
+		messaging.NotifyTripCompletedQueue,
 	}
 
 	for _, q := range queues {
@@ -135,6 +143,14 @@ func handleDriversWebSocket(w http.ResponseWriter, r *http.Request, rb *messagin
 			appMetrics.GRPCRequestDuration.WithLabelValues("RegisterDriver").Observe(time.Since(grpcStart).Seconds())
 			appMetrics.GRPCRequestsTotal.WithLabelValues("RegisterDriver", "error").Inc()
 		}
+		if status.Code(err) == codes.InvalidArgument {
+			// Tell the driver app why the connection is refused instead of silently dropping it
+			conn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, status.Convert(err).Message()),
+				time.Now().Add(time.Second),
+			)
+		}
 		return
 	}
 	if appMetrics != nil {
@@ -153,6 +169,8 @@ func handleDriversWebSocket(w http.ResponseWriter, r *http.Request, rb *messagin
 	// Initialize queue consumers
 	queues := []string{
 		messaging.DriverCmdTripRequestQueue,
+		messaging.NotifyDriverTripCancelledQueue,
+		messaging.NotifyDriverOfferRevokedQueue,
 	}
 
 	for _, q := range queues {
@@ -163,6 +181,8 @@ func handleDriversWebSocket(w http.ResponseWriter, r *http.Request, rb *messagin
 		}
 	}
 
+	var lastLocationUpdate time.Time
+
 	for {
 		_, message, err := conn.ReadMessage()
 		if err != nil {
@@ -184,9 +204,17 @@ func handleDriversWebSocket(w http.ResponseWriter, r *http.Request, rb *messagin
 		// Handle the different message type
 		switch driverMsg.Type {
 		case contracts.DriverCmdLocation:
-			// Handle driver location update in the future
-			continue
-		case contracts.DriverCmdTripAccept, contracts.DriverCmdTripDecline:
+			// Drivers report their position every few seconds, drop the updates coming faster
+			if time.Since(lastLocationUpdate) < driverLocationMinInterval {
+				continue
+			}
+			lastLocationUpdate = time.Now()
+
+			updateDriverLocation(ctx, driverService.Client, userID, driverMsg.Data)
+		case contracts.DriverCmdPause, contracts.DriverCmdResume:
+			// The driver stays connected, only the trip offers stop
+			setDriverPaused(ctx, driverService.Client, userID, driverMsg.Type)
+		case contracts.DriverCmdTripAccept, contracts.DriverCmdTripDecline, contracts.DriverCmdStopReached, contracts.DriverCmdTripComplete:
 			// Forward the message to RabbitMQ
 			if err := rb.PublishMessage(ctx, driverMsg.Type, contracts.AmqpMessage{
 				OwnerID: userID,
@@ -205,4 +233,63 @@ func handleDriversWebSocket(w http.ResponseWriter, r *http.Request, rb *messagin
 			log.Printf("Unknown message type: %s", driverMsg.Type)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// updateDriverLocation forwards a location update of the driver to the driver service
+func updateDriverLocation(ctx context.Context, client driver.DriverServiceClient, driverID string, data json.RawMessage) {
+	var location types.Coordinate
+	if err := json.Unmarshal(data, &location); err != nil {
+		log.Printf("Invalid location from driver %s: %v", driverID, err)
+		return
+	}
+	if err := location.Validate(); err != nil {
+		log.Printf("Invalid location from driver %s: %v", driverID, err)
+		return
+	}
+
+	grpcStart := time.Now()
+	_, err := client.UpdateDriverLocation(ctx, &driver.UpdateDriverLocationRequest{
+		DriverID: driverID,
+		Location: &driver.Location{Latitude: location.Latitude, Longitude: location.Longitude},
+	})
+	if err != nil {
+		log.Printf("Error updating driver %s location: %v", driverID, err)
+		if appMetrics != nil {
+			appMetrics.GRPCRequestDuration.WithLabelValues("UpdateDriverLocation").Observe(time.Since(grpcStart).Seconds())
+			appMetrics.GRPCRequestsTotal.WithLabelValues("UpdateDriverLocation", "error").Inc()
+		}
+		return
+	}
+	if appMetrics != nil {
+		appMetrics.GRPCRequestDuration.WithLabelValues("UpdateDriverLocation").Observe(time.Since(grpcStart).Seconds())
+		appMetrics.GRPCRequestsTotal.WithLabelValues("UpdateDriverLocation", "success").Inc()
+	}
+}
+
+// setDriverPaused pauses or resumes the trip offers of the driver, the new status is sent back to the driver
+func setDriverPaused(ctx context.Context, client driver.DriverServiceClient, driverID, command string) {
+	grpcStart := time.Now()
+	resp, err := client.SetDriverPaused(ctx, &driver.SetDriverPausedRequest{
+		DriverID: driverID,
+		Paused:   command == contracts.DriverCmdPause,
+	})
+	if err != nil {
+		log.Printf("Error updating driver %s status: %v", driverID, err)
+		if appMetrics != nil {
+			appMetrics.GRPCRequestDuration.WithLabelValues("SetDriverPaused").Observe(time.Since(grpcStart).Seconds())
+			appMetrics.GRPCRequestsTotal.WithLabelValues("SetDriverPaused", "error").Inc()
+		}
+		return
+	}
+	if appMetrics != nil {
+		appMetrics.GRPCRequestDuration.WithLabelValues("SetDriverPaused").Observe(time.Since(grpcStart).Seconds())
+		appMetrics.GRPCRequestsTotal.WithLabelValues("SetDriverPaused", "success").Inc()
+	}
+
+	if err := connManager.SendMessage(driverID, contracts.WSMessage{
+		Type: command,
+		Data: resp,
+	}); err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
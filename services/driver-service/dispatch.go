@@ -0,0 +1,737 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/cache"
+	"github.com/Anurag-Mishra22/taxi/shared/contracts"
+	"github.com/Anurag-Mishra22/taxi/shared/env"
+	"github.com/Anurag-Mishra22/taxi/shared/messaging"
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
+	pbt "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	RedisDispatchStateKey    = "dispatch:trip:%s"          // Redis JSON of the dispatch of a trip
+	RedisDispatchOfferedKey  = "dispatch:trip:%s:offered"  // Redis SET of the drivers the trip was offered to
+	RedisDispatchPendingKey  = "dispatch:trip:%s:pending"  // Redis SET of the drivers with a pending offer of the trip
+	RedisDispatchAcceptedKey = "dispatch:trip:%s:accepted" // Redis SET of the drivers whose accept of the trip was claimed
+	RedisDispatchOffersKey   = "dispatch:offers"           // Redis ZSET of the pending offers, scored by expiry
+	RedisDispatchRetriesKey  = "dispatch:retries"          // Redis ZSET of the trips whose failed round is retried, scored by due time
+)
+
+// dispatchConfig bounds the time spent finding a driver for a trip
+type dispatchConfig struct {
+	// OfferTimeout is how long a driver has to answer before the trip is offered to the next one
+	OfferTimeout time.Duration
+	// MaxAttempts is the number of offer rounds before giving up
+	MaxAttempts int
+	// MaxDuration is how long the dispatch lasts before giving up
+	MaxDuration time.Duration
+	// BroadcastPackages and BroadcastZones select the trips offered to several drivers
+	// at once, the first driver accepting wins
+	BroadcastPackages []string
+	BroadcastZones    []string
+	// BroadcastSize is the number of nearest drivers a broadcast round is offered to
+	BroadcastSize int
+	// RetryDelay is how long a round of offers that failed waits before it is retried
+	RetryDelay time.Duration
+}
+
+func newDispatchConfig() dispatchConfig {
+	return dispatchConfig{
+		OfferTimeout:      time.Duration(env.GetInt("DISPATCH_OFFER_TIMEOUT_SECONDS", 20)) * time.Second,
+		MaxAttempts:       env.GetInt("DISPATCH_MAX_ATTEMPTS", 5),
+		MaxDuration:       time.Duration(env.GetInt("DISPATCH_MAX_DURATION_SECONDS", 180)) * time.Second,
+		BroadcastPackages: splitList(env.GetString("DISPATCH_BROADCAST_PACKAGES", "")),
+		BroadcastZones:    splitList(env.GetString("DISPATCH_BROADCAST_ZONES", "")),
+		BroadcastSize:     env.GetInt("DISPATCH_BROADCAST_SIZE", 3),
+		RetryDelay:        time.Duration(env.GetInt("DISPATCH_RETRY_DELAY_SECONDS", 5)) * time.Second,
+	}
+}
+
+// offersPerRound is the number of drivers a round of offers of the trip goes to:
+// one when dispatching sequentially, the broadcast size in broadcast mode
+func (c dispatchConfig) offersPerRound(trip *pbt.Trip) int {
+	broadcast := slices.Contains(c.BroadcastPackages, trip.GetSelectedFare().GetPackageSlug()) ||
+		(trip.GetZoneID() != "" && slices.Contains(c.BroadcastZones, trip.GetZoneID()))
+	if !broadcast {
+		return 1
+	}
+	return max(c.BroadcastSize, 1)
+}
+
+// dispatchState is the progress of the dispatch of a trip, shared by the pods in Redis
+type dispatchState struct {
+	Trip      *pbt.Trip `json:"trip"`
+	StartedAt time.Time `json:"startedAt"`
+	// Attempts is the number of offer rounds
+	Attempts int `json:"attempts"`
+	// Version is incremented by each write, a write only applies to the version it was read at
+	Version int `json:"version"`
+	// Finished is set once the dispatch is over. The state is kept until its TTL,
+	// so a late answer or a redelivered event can't start the dispatch again.
+	Finished bool `json:"finished,omitempty"`
+	// NoDriversFound is set when the dispatch ended without a driver
+	NoDriversFound bool `json:"noDriversFound,omitempty"`
+}
+
+// claimOfferScript removes the pending offer ARGV[1] of driver ARGV[2], KEYS[1] being the offers
+// of every trip, KEYS[2] the pending drivers and KEYS[3] the accepting drivers of the trip. It returns
+// -1 when the offer wasn't pending, else the number of offers of the trip still pending or accepted,
+// so a single caller sees the round end.
+var claimOfferScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return -1
+end
+redis.call('SREM', KEYS[2], ARGV[2])
+return redis.call('SCARD', KEYS[2]) + redis.call('SCARD', KEYS[3])
+`)
+
+// acceptOfferScript moves the pending offer ARGV[1] of driver ARGV[2] to the accepting drivers KEYS[3],
+// kept for ARGV[3] milliseconds, with the keys of claimOfferScript. It returns 1 when the offer was
+// pending or its accept was already claimed, so a redelivered accept goes through again, else 0.
+var acceptOfferScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return redis.call('SISMEMBER', KEYS[3], ARGV[2])
+end
+redis.call('SREM', KEYS[2], ARGV[2])
+redis.call('SADD', KEYS[3], ARGV[2])
+redis.call('PEXPIRE', KEYS[3], ARGV[3])
+return 1
+`)
+
+// saveStateScript writes the dispatch state ARGV[2] to KEYS[1] for ARGV[3] milliseconds, only when the
+// stored state is still at version ARGV[1] and isn't finished. The offers of trip ARGV[5] to the drivers
+// ARGV[6:], expiring at ARGV[4], are recorded with it in the offered KEYS[2], pending KEYS[3] and
+// offers KEYS[4] sets. It returns 0 when the state changed meanwhile.
+var saveStateScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current then
+	return 0
+end
+local state = cjson.decode(current)
+if state.finished or (state.version or 0) ~= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+for i = 6, #ARGV do
+	redis.call('SADD', KEYS[2], ARGV[i])
+	redis.call('SADD', KEYS[3], ARGV[i])
+	redis.call('ZADD', KEYS[4], ARGV[4], ARGV[5] .. ':' .. ARGV[i])
+end
+if #ARGV > 5 then
+	redis.call('PEXPIRE', KEYS[2], ARGV[3])
+	redis.call('PEXPIRE', KEYS[3], ARGV[3])
+end
+return 1
+`)
+
+// dispatchCoordinator offers a trip to the nearest drivers in rounds, of one driver, or of several
+// drivers in broadcast mode. Drivers who declined or didn't answer in time aren't offered the trip
+// again. The state lives in Redis, so any pod can handle the answer of a driver or the expiry of an offer.
+// The trip-service only assigns a driver to a pending trip, so the first driver accepting wins.
+// The state is written with a compare-and-set, a pod working from a stale state can't undo the
+// round or the end of the dispatch saved by another pod.
+type dispatchCoordinator struct {
+	publisher messagePublisher
+	service   *Service
+	redis     *cache.RedisClient
+	config    dispatchConfig
+}
+
+// messagePublisher is the part of RabbitMQ the coordinator publishes with
+type messagePublisher interface {
+	PublishMessage(ctx context.Context, routingKey string, message contracts.AmqpMessage) error
+}
+
+func NewDispatchCoordinator(publisher messagePublisher, service *Service, redisClient *cache.RedisClient) *dispatchCoordinator {
+	return &dispatchCoordinator{
+		publisher: publisher,
+		service:   service,
+		redis:     redisClient,
+		config:    newDispatchConfig(),
+	}
+}
+
+// Start offers a new trip to the nearest driver. A redelivered event doesn't restart the dispatch.
+func (c *dispatchCoordinator) Start(ctx context.Context, trip *pbt.Trip) error {
+	state := &dispatchState{Trip: trip, StartedAt: time.Now()}
+
+	started, err := c.redis.SetNXJSON(ctx, fmt.Sprintf(RedisDispatchStateKey, trip.Id), state, c.stateTTL())
+	if err != nil {
+		return fmt.Errorf("failed to start the dispatch of trip %s: %w", trip.Id, err)
+	}
+	if !started {
+		log.Printf("Dispatch of trip %s already started", trip.Id)
+		return nil
+	}
+
+	return c.advance(ctx, state)
+}
+
+// Declined offers the trip to the next driver after the driver with the pending offer declined
+func (c *dispatchCoordinator) Declined(ctx context.Context, trip *pbt.Trip, driverID string) error {
+	state, err := c.state(ctx, trip.Id)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		// The dispatch already ended, or in broadcast mode another driver was assigned meanwhile
+		if trip.GetStatus() != contracts.TripStatusPending {
+			log.Printf("Ignoring the decline of trip %s by driver %s, the trip is %s", trip.Id, driverID, trip.GetStatus())
+			return nil
+		}
+
+		// The trip was dispatched before the coordinator
+		log.Printf("No dispatch for declined trip %s, starting one", trip.Id)
+		if driverID != "" {
+			c.markOffered(ctx, trip.Id, driverID)
+		}
+		return c.Start(ctx, trip)
+	}
+
+	// Only the pod removing the pending offer moves on, the offer may have expired meanwhile
+	claimed, remaining, err := c.claimOffer(ctx, trip.Id, driverID)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		log.Printf("Ignoring the decline of trip %s by driver %s, the offer isn't pending", trip.Id, driverID)
+		return nil
+	}
+
+	log.Printf("Driver %s declined trip %s", driverID, trip.Id)
+	c.releaseDriver(ctx, driverID)
+
+	if remaining > 0 || state.Finished {
+		// The other drivers of the round can still accept, or the dispatch is over
+		return nil
+	}
+	return c.advance(ctx, state)
+}
+
+// Accepted hands the accept of a driver over to the trip-service, which assigns the trip to the first
+// driver accepting it. Only an accept claiming the pending offer of the driver goes through, the offer
+// may have expired, been revoked, or never been made to the driver.
+func (c *dispatchCoordinator) Accepted(ctx context.Context, tripID, driverID string) error {
+	accepted, err := c.acceptOffer(ctx, tripID, driverID)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		log.Printf("Ignoring the accept of trip %s by driver %s, the offer isn't pending", tripID, driverID)
+		return nil
+	}
+
+	// The trip-service copies the profile of the driver to the trip
+	driver, err := c.service.findDriver(ctx, driverID)
+	if err != nil {
+		log.Printf("Failed to get the profile of driver %s: %v", driverID, err)
+		driver = &pb.Driver{Id: driverID}
+	}
+
+	data, err := json.Marshal(messaging.DriverTripResponseData{Driver: driver, TripID: tripID})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Driver %s accepted trip %s", driverID, tripID)
+	return c.publisher.PublishMessage(ctx, contracts.TripEventOfferAccepted, contracts.AmqpMessage{
+		OwnerID: driverID,
+		Data:    data,
+	})
+}
+
+// Finish ends the dispatch of a trip, once a driver is assigned or the trip is cancelled.
+// The drivers with a pending offer, other than the assigned driver, are told the offer is revoked.
+func (c *dispatchCoordinator) Finish(ctx context.Context, tripID, assignedDriverID, reason string) error {
+	for {
+		state, err := c.state(ctx, tripID)
+		if err != nil || state == nil {
+			return err
+		}
+
+		if !state.Finished {
+			finished, err := c.finish(ctx, state, false)
+			if err != nil {
+				return err
+			}
+			if !finished {
+				// A round of offers was saved meanwhile, its offers are revoked too
+				continue
+			}
+			log.Printf("Dispatch of trip %s finished after %d rounds of offers", tripID, state.Attempts)
+		}
+
+		// A redelivered event revokes the offers a failed attempt left pending
+		return c.closeOffers(ctx, tripID, assignedDriverID, reason)
+	}
+}
+
+// closeOffers revokes the pending and the accepted offers of a finished dispatch,
+// except the offer of the assigned driver
+func (c *dispatchCoordinator) closeOffers(ctx context.Context, tripID, assignedDriverID, reason string) error {
+	pending, err := c.redis.SMembers(ctx, fmt.Sprintf(RedisDispatchPendingKey, tripID))
+	if err != nil {
+		return fmt.Errorf("failed to get the pending offers of trip %s: %w", tripID, err)
+	}
+
+	for _, driverID := range pending {
+		claimed, _, err := c.claimOffer(ctx, tripID, driverID)
+		if err != nil {
+			return err
+		}
+		if !claimed || driverID == assignedDriverID {
+			continue
+		}
+		c.releaseDriver(ctx, driverID)
+		c.revokeOffer(ctx, tripID, driverID, reason)
+	}
+
+	// No accept can be claimed once the pending offers are, the drivers who accepted
+	// after the assigned driver lost the trip
+	accepted, err := c.redis.SMembers(ctx, fmt.Sprintf(RedisDispatchAcceptedKey, tripID))
+	if err != nil {
+		return fmt.Errorf("failed to get the accepted offers of trip %s: %w", tripID, err)
+	}
+
+	for _, driverID := range accepted {
+		if driverID == assignedDriverID {
+			continue
+		}
+		c.releaseDriver(ctx, driverID)
+		c.revokeOffer(ctx, tripID, driverID, reason)
+	}
+
+	// The finished state is kept, it expires on its own
+	err = c.redis.Del(ctx,
+		fmt.Sprintf(RedisDispatchOfferedKey, tripID),
+		fmt.Sprintf(RedisDispatchPendingKey, tripID),
+		fmt.Sprintf(RedisDispatchAcceptedKey, tripID),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete the offers of trip %s: %w", tripID, err)
+	}
+	return nil
+}
+
+// Run expires the unanswered offers and retries the failed rounds until ctx is done. Every pod
+// runs it, each expired offer or retry is handled by the pod removing it from its set.
+func (c *dispatchCoordinator) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.expireOffers(ctx)
+			c.retryRounds(ctx)
+		}
+	}
+}
+
+func (c *dispatchCoordinator) expireOffers(ctx context.Context) {
+	expired, err := c.redis.ZRangeByScore(ctx, RedisDispatchOffersKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().UnixMilli(), 10),
+	})
+	if err != nil {
+		log.Printf("Failed to get the expired offers: %v", err)
+		return
+	}
+
+	for _, member := range expired {
+		tripID, driverID, _ := strings.Cut(member, ":")
+
+		claimed, remaining, err := c.claimOffer(ctx, tripID, driverID)
+		if err != nil {
+			log.Printf("Failed to claim the expired offer %s: %v", member, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		log.Printf("Offer of trip %s to driver %s expired", tripID, driverID)
+		c.releaseDriver(ctx, driverID)
+		c.revokeOffer(ctx, tripID, driverID, messaging.OfferRevokedExpired)
+
+		if remaining > 0 {
+			continue
+		}
+
+		state, err := c.state(ctx, tripID)
+		if err != nil {
+			log.Printf("Failed to get the dispatch of trip %s: %v", tripID, err)
+			continue
+		}
+		if state == nil || state.Finished {
+			continue
+		}
+
+		if err := c.advance(ctx, state); err != nil {
+			log.Printf("Failed to offer trip %s to the next drivers: %v", tripID, err)
+		}
+	}
+}
+
+// retryRounds runs the due retries of the rounds of offers that failed
+func (c *dispatchCoordinator) retryRounds(ctx context.Context) {
+	due, err := c.redis.ZRangeByScore(ctx, RedisDispatchRetriesKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().UnixMilli(), 10),
+	})
+	if err != nil {
+		log.Printf("Failed to get the due dispatch retries: %v", err)
+		return
+	}
+
+	for _, tripID := range due {
+		removed, err := c.redis.ZRem(ctx, RedisDispatchRetriesKey, tripID)
+		if err != nil {
+			log.Printf("Failed to claim the dispatch retry of trip %s: %v", tripID, err)
+			continue
+		}
+		if removed == 0 {
+			// Another pod is retrying it
+			continue
+		}
+
+		if err := c.retry(ctx, tripID); err != nil {
+			log.Printf("Failed to retry the dispatch of trip %s: %v", tripID, err)
+			if err := c.scheduleRetry(ctx, tripID); err != nil {
+				log.Printf("Failed to schedule the dispatch retry of trip %s: %v", tripID, err)
+			}
+		}
+	}
+}
+
+// retry runs the round of offers of a trip again, or publishes the end of a dispatch
+// without drivers again. Nothing is done while a round of offers is pending.
+func (c *dispatchCoordinator) retry(ctx context.Context, tripID string) error {
+	state, err := c.state(ctx, tripID)
+	if err != nil || state == nil {
+		return err
+	}
+	if state.Finished {
+		if state.NoDriversFound {
+			return c.publishNoDriversFound(ctx, state.Trip)
+		}
+		return nil
+	}
+
+	pending, err := c.redis.SCard(ctx, fmt.Sprintf(RedisDispatchPendingKey, tripID))
+	if err != nil {
+		return fmt.Errorf("failed to count the pending offers of trip %s: %w", tripID, err)
+	}
+	if pending > 0 {
+		return nil
+	}
+
+	return c.offerNext(ctx, state)
+}
+
+// advance starts the next round of offers. A round failing before its offers are recorded
+// is retried later, so a pending trip isn't left without a pending offer.
+func (c *dispatchCoordinator) advance(ctx context.Context, state *dispatchState) error {
+	err := c.offerNext(ctx, state)
+	if err == nil {
+		return nil
+	}
+
+	log.Printf("Failed to offer trip %s to the next drivers, retrying in %v: %v", state.Trip.Id, c.config.RetryDelay, err)
+	return c.scheduleRetry(ctx, state.Trip.Id)
+}
+
+func (c *dispatchCoordinator) scheduleRetry(ctx context.Context, tripID string) error {
+	err := c.redis.ZAdd(ctx, RedisDispatchRetriesKey, redis.Z{
+		Score:  float64(time.Now().Add(c.config.RetryDelay).UnixMilli()),
+		Member: tripID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to schedule the dispatch retry of trip %s: %w", tripID, err)
+	}
+	return nil
+}
+
+// offerNext offers the trip to the nearest drivers it wasn't offered to yet,
+// or gives up once the attempts or the time are exhausted
+func (c *dispatchCoordinator) offerNext(ctx context.Context, state *dispatchState) error {
+	trip := state.Trip
+
+	if state.Attempts >= c.config.MaxAttempts || time.Since(state.StartedAt) >= c.config.MaxDuration {
+		log.Printf("Giving up the dispatch of trip %s after %d rounds of offers", trip.Id, state.Attempts)
+		return c.noDriversFound(ctx, state)
+	}
+
+	offered, err := c.redis.SMembers(ctx, fmt.Sprintf(RedisDispatchOfferedKey, trip.Id))
+	if err != nil {
+		return fmt.Errorf("failed to get the drivers offered trip %s: %w", trip.Id, err)
+	}
+
+	var pickup *types.Coordinate
+	if p := trip.GetPickup(); p != nil {
+		pickup = &types.Coordinate{Latitude: p.Latitude, Longitude: p.Longitude}
+	}
+
+	// The search expands past the drivers who were already offered the trip, they declined or didn't answer
+	candidates := c.service.FindAvailableDrivers(ctx, trip.GetSelectedFare().GetPackageSlug(), pickup, offered)
+
+	// The drivers are reserved with the offered status, so another trip can't be offered to them meanwhile
+	size := c.config.offersPerRound(trip)
+	var reserved []driverCandidate
+	for _, candidate := range candidates {
+		if len(reserved) == size {
+			break
+		}
+
+		ok, err := c.service.transitionDriverStatus(ctx, candidate.DriverID, DriverStatusOffered, DriverStatusAvailable)
+		if err != nil {
+			c.releaseDrivers(ctx, reserved)
+			return err
+		}
+		if ok {
+			reserved = append(reserved, candidate)
+		}
+	}
+	if len(reserved) == 0 {
+		log.Printf("No more drivers for trip %s after %d rounds of offers", trip.Id, state.Attempts)
+		return c.noDriversFound(ctx, state)
+	}
+
+	marshalledEvent, err := json.Marshal(messaging.TripEventData{Trip: trip})
+	if err != nil {
+		c.releaseDrivers(ctx, reserved)
+		return err
+	}
+
+	// The offers are recorded with the round before they are sent, so they expire even if a driver
+	// never gets them. Nothing is recorded when another pod moved the dispatch on meanwhile.
+	next := *state
+	next.Attempts++
+	saved, err := c.saveState(ctx, state, &next, reserved)
+	if err != nil {
+		c.releaseDrivers(ctx, reserved)
+		return err
+	}
+	if !saved {
+		log.Printf("Dispatch of trip %s changed meanwhile, dropping the round of offers", trip.Id)
+		c.releaseDrivers(ctx, reserved)
+		return nil
+	}
+
+	for _, candidate := range reserved {
+		log.Printf("Offering trip %s to driver %s, %.2f km from the pickup (round %d)", trip.Id, candidate.DriverID, candidate.DistanceKm, next.Attempts)
+
+		// Notify the driver about a potential trip, an offer the driver never gets expires like the others
+		if err := c.publisher.PublishMessage(ctx, contracts.DriverCmdTripRequest, contracts.AmqpMessage{
+			OwnerID: candidate.DriverID,
+			Data:    marshalledEvent,
+		}); err != nil {
+			log.Printf("Failed to offer trip %s to driver %s: %v", trip.Id, candidate.DriverID, err)
+		}
+	}
+
+	return nil
+}
+
+// saveState writes next over state, with the offers of the round to candidates, unless the
+// dispatch changed or finished since state was read. It reports whether next was written.
+func (c *dispatchCoordinator) saveState(ctx context.Context, state, next *dispatchState, candidates []driverCandidate) (bool, error) {
+	tripID := state.Trip.Id
+	next.Version = state.Version + 1
+
+	data, err := json.Marshal(next)
+	if err != nil {
+		return false, err
+	}
+
+	keys := []string{
+		fmt.Sprintf(RedisDispatchStateKey, tripID),
+		fmt.Sprintf(RedisDispatchOfferedKey, tripID),
+		fmt.Sprintf(RedisDispatchPendingKey, tripID),
+		RedisDispatchOffersKey,
+	}
+	// The offers script builds the members of the offers like offerMember
+	args := []interface{}{
+		state.Version,
+		string(data),
+		c.stateTTL().Milliseconds(),
+		time.Now().Add(c.config.OfferTimeout).UnixMilli(),
+		tripID,
+	}
+	for _, candidate := range candidates {
+		args = append(args, candidate.DriverID)
+	}
+
+	saved, err := saveStateScript.Run(ctx, c.redis.GetClient(), keys, args...).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to save the dispatch of trip %s: %w", tripID, err)
+	}
+	return saved == 1, nil
+}
+
+// finish marks the dispatch over, unless it changed since state was read
+func (c *dispatchCoordinator) finish(ctx context.Context, state *dispatchState, noDriversFound bool) (bool, error) {
+	next := *state
+	next.Finished = true
+	next.NoDriversFound = noDriversFound
+	return c.saveState(ctx, state, &next, nil)
+}
+
+// claimOffer removes the pending offer of a driver. It reports whether the offer was
+// still pending, and how many offers of the trip are still pending.
+func (c *dispatchCoordinator) claimOffer(ctx context.Context, tripID, driverID string) (bool, int64, error) {
+	keys := c.offerKeys(tripID)
+
+	remaining, err := claimOfferScript.Run(ctx, c.redis.GetClient(), keys, offerMember(tripID, driverID), driverID).Int64()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to claim the offer of trip %s: %w", tripID, err)
+	}
+	if remaining < 0 {
+		return false, 0, nil
+	}
+	return true, remaining, nil
+}
+
+// acceptOffer claims the pending offer of a driver for their accept. It reports whether
+// the offer was pending, or already claimed by the same accept.
+func (c *dispatchCoordinator) acceptOffer(ctx context.Context, tripID, driverID string) (bool, error) {
+	accepted, err := acceptOfferScript.Run(ctx, c.redis.GetClient(), c.offerKeys(tripID), offerMember(tripID, driverID), driverID, c.stateTTL().Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to accept the offer of trip %s: %w", tripID, err)
+	}
+	return accepted == 1, nil
+}
+
+// offerKeys are the keys of the offers of a trip, used by the offer scripts
+func (c *dispatchCoordinator) offerKeys(tripID string) []string {
+	return []string{
+		RedisDispatchOffersKey,
+		fmt.Sprintf(RedisDispatchPendingKey, tripID),
+		fmt.Sprintf(RedisDispatchAcceptedKey, tripID),
+	}
+}
+
+// revokeOffer tells a driver their offer can no longer be accepted
+func (c *dispatchCoordinator) revokeOffer(ctx context.Context, tripID, driverID, reason string) {
+	data, err := json.Marshal(messaging.TripOfferRevokedData{TripID: tripID, Reason: reason})
+	if err != nil {
+		log.Printf("Failed to marshal the revoked offer of trip %s: %v", tripID, err)
+		return
+	}
+
+	if err := c.publisher.PublishMessage(ctx, contracts.TripEventOfferRevoked, contracts.AmqpMessage{
+		OwnerID: driverID,
+		Data:    data,
+	}); err != nil {
+		log.Printf("Failed to revoke the offer of trip %s to driver %s: %v", tripID, driverID, err)
+	}
+}
+
+// noDriversFound ends the dispatch without a driver, unless another pod moved it on meanwhile
+func (c *dispatchCoordinator) noDriversFound(ctx context.Context, state *dispatchState) error {
+	finished, err := c.finish(ctx, state, true)
+	if err != nil {
+		return err
+	}
+	if !finished {
+		log.Printf("Dispatch of trip %s changed meanwhile, not giving it up", state.Trip.Id)
+		return nil
+	}
+
+	if err := c.closeOffers(ctx, state.Trip.Id, "", messaging.OfferRevokedExpired); err != nil {
+		log.Printf("Failed to revoke the offers of trip %s: %v", state.Trip.Id, err)
+	}
+
+	// A failed publish is retried from the finished state
+	return c.publishNoDriversFound(ctx, state.Trip)
+}
+
+// publishNoDriversFound tells the rider no driver was found, the trip is included
+// so the trip-service can count the unfulfilled demand
+func (c *dispatchCoordinator) publishNoDriversFound(ctx context.Context, trip *pbt.Trip) error {
+	marshalledEvent, err := json.Marshal(messaging.TripEventData{Trip: trip})
+	if err != nil {
+		return err
+	}
+
+	if err := c.publisher.PublishMessage(ctx, contracts.TripEventNoDriversFound, contracts.AmqpMessage{
+		OwnerID: trip.UserID,
+		Data:    marshalledEvent,
+	}); err != nil {
+		log.Printf("Failed to publish message to exchange: %v", err)
+		return err
+	}
+	return nil
+}
+
+// releaseDriver makes a driver available again once their offer ended without an answer or with a decline
+func (c *dispatchCoordinator) releaseDriver(ctx context.Context, driverID string) {
+	if _, err := c.service.transitionDriverStatus(ctx, driverID, DriverStatusAvailable, DriverStatusOffered); err != nil {
+		log.Printf("Failed to release driver %s: %v", driverID, err)
+	}
+}
+
+func (c *dispatchCoordinator) releaseDrivers(ctx context.Context, candidates []driverCandidate) {
+	for _, candidate := range candidates {
+		c.releaseDriver(ctx, candidate.DriverID)
+	}
+}
+
+func (c *dispatchCoordinator) markOffered(ctx context.Context, tripID, driverID string) {
+	offeredKey := fmt.Sprintf(RedisDispatchOfferedKey, tripID)
+	if err := c.redis.SAdd(ctx, offeredKey, driverID); err != nil {
+		log.Printf("Failed to record the offer of trip %s to driver %s: %v", tripID, driverID, err)
+	}
+	c.redis.Expire(ctx, offeredKey, c.stateTTL())
+}
+
+// state returns the dispatch of a trip, nil when there is none
+func (c *dispatchCoordinator) state(ctx context.Context, tripID string) (*dispatchState, error) {
+	var state dispatchState
+	err := c.redis.GetJSON(ctx, fmt.Sprintf(RedisDispatchStateKey, tripID), &state)
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the dispatch of trip %s: %w", tripID, err)
+	}
+	return &state, nil
+}
+
+// stateTTL outlives the dispatch, so an abandoned state is eventually removed
+func (c *dispatchCoordinator) stateTTL() time.Duration {
+	return c.config.MaxDuration + c.config.OfferTimeout + time.Minute
+}
+
+// offerMember identifies an offer in the pending offers, so an answer only
+// matches the offer it was given to
+func offerMember(tripID, driverID string) string {
+	return tripID + ":" + driverID
+}
+
+// splitList parses a comma separated list, ignoring the empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/contracts"
+	"github.com/Anurag-Mishra22/taxi/shared/messaging"
+	"github.com/Anurag-Mishra22/taxi/shared/metrics"
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// dispatchConsumer ends the dispatch of the trips which no longer need a driver,
+// and keeps the status of their driver until the trip is over.
+// It hands the accepts of the pending offers over to the trip-service.
+type dispatchConsumer struct {
+	rabbitmq   *messaging.RabbitMQ
+	service    *Service
+	dispatcher *dispatchCoordinator
+	metrics    *metrics.Metrics
+}
+
+func NewDispatchConsumer(rabbitmq *messaging.RabbitMQ, service *Service, dispatcher *dispatchCoordinator, m *metrics.Metrics) *dispatchConsumer {
+	return &dispatchConsumer{
+		rabbitmq:   rabbitmq,
+		service:    service,
+		dispatcher: dispatcher,
+		metrics:    m,
+	}
+}
+
+func (c *dispatchConsumer) Listen() error {
+	return c.rabbitmq.ConsumeMessages(messaging.DriverDispatchQueue, func(ctx context.Context, msg amqp091.Delivery) error {
+		start := time.Now()
+
+		err := c.handle(ctx, msg)
+
+		status := "success"
+		if err != nil {
+			status = "error"
+		}
+		if c.metrics != nil {
+			c.metrics.RecordMessageConsumed(messaging.DriverDispatchQueue, status, time.Since(start), msg.RoutingKey)
+		}
+
+		return err
+	})
+}
+
+func (c *dispatchConsumer) handle(ctx context.Context, msg amqp091.Delivery) error {
+	var message contracts.AmqpMessage
+	if err := json.Unmarshal(msg.Body, &message); err != nil {
+		log.Printf("Failed to unmarshal message: %v", err)
+		return err
+	}
+
+	switch msg.RoutingKey {
+	case contracts.TripEventDriverAssigned:
+		// The assigned event carries the trip model of the trip-service
+		var trip struct {
+			ID     string         `json:"ID"`
+			Driver *pb.TripDriver `json:"Driver"`
+		}
+		if err := json.Unmarshal(message.Data, &trip); err != nil {
+			return err
+		}
+
+		// The driver is busy before the offer ends, so they can't be matched in between
+		if trip.Driver != nil {
+			if _, err := c.service.transitionDriverStatus(ctx, trip.Driver.Id, DriverStatusOnTrip); err != nil {
+				return err
+			}
+		}
+		var driverID string
+		if trip.Driver != nil {
+			driverID = trip.Driver.Id
+		}
+		return c.dispatcher.Finish(ctx, trip.ID, driverID, messaging.OfferRevokedTaken)
+	case contracts.TripEventCancelled:
+		var payload messaging.TripCancelledData
+		if err := json.Unmarshal(message.Data, &payload); err != nil {
+			return err
+		}
+
+		if err := c.dispatcher.Finish(ctx, payload.Trip.GetId(), "", messaging.OfferRevokedCancelled); err != nil {
+			return err
+		}
+		if driver := payload.Trip.GetDriver(); driver != nil {
+			return c.endTrip(ctx, driver.Id)
+		}
+		return nil
+	case contracts.DriverCmdTripAccept:
+		var payload messaging.DriverTripResponseData
+		if err := json.Unmarshal(message.Data, &payload); err != nil {
+			return err
+		}
+
+		// The gateway sets the owner of driver commands to the connected driver
+		if payload.Driver == nil || payload.Driver.Id != message.OwnerID {
+			log.Printf("Ignoring the accept of trip %s by driver %s for another driver", payload.TripID, message.OwnerID)
+			return nil
+		}
+		return c.dispatcher.Accepted(ctx, payload.TripID, message.OwnerID)
+	case contracts.TripEventCompleted:
+		// The driver dropped the rider off, whether the ride was paid or not
+		var payload messaging.TripEventData
+		if err := json.Unmarshal(message.Data, &payload); err != nil {
+			return err
+		}
+
+		if driver := payload.Trip.GetDriver(); driver != nil {
+			return c.endTrip(ctx, driver.Id)
+		}
+		return nil
+	default:
+		log.Printf("Unknown dispatch event: %s", msg.RoutingKey)
+		return nil
+	}
+}
+
+// endTrip makes the driver of a trip available again
+func (c *dispatchConsumer) endTrip(ctx context.Context, driverID string) error {
+	_, err := c.service.transitionDriverStatus(ctx, driverID, DriverStatusAvailable, DriverStatusOnTrip)
+	return err
+}
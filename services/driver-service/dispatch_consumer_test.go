@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Anurag-Mishra22/taxi/shared/contracts"
+	"github.com/Anurag-Mishra22/taxi/shared/messaging"
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
+	pbt "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dispatchDelivery(t *testing.T, routingKey, ownerID string, data any) amqp091.Delivery {
+	payload, err := json.Marshal(data)
+	require.NoError(t, err)
+	body, err := json.Marshal(contracts.AmqpMessage{OwnerID: ownerID, Data: payload})
+	require.NoError(t, err)
+	return amqp091.Delivery{RoutingKey: routingKey, Body: body}
+}
+
+func TestDispatchConsumerReleasesTheDriver(t *testing.T) {
+	trip := testTrip()
+	trip.Driver = &pbt.TripDriver{Id: "driver-1"}
+
+	tests := []struct {
+		name       string
+		routingKey string
+		data       any
+		expected   DriverStatus
+	}{
+		{"trip_completed", contracts.TripEventCompleted, messaging.TripEventData{Trip: trip}, DriverStatusAvailable},
+		{"trip_cancelled", contracts.TripEventCancelled, messaging.TripCancelledData{Trip: trip}, DriverStatusAvailable},
+		// The ride is paid when the driver is assigned, the driver is still carrying the rider
+		{"trip_paid", contracts.PaymentEventSuccess, messaging.TripEventData{Trip: trip}, DriverStatusOnTrip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c, _ := newTestCoordinator(t, testDispatchConfig(), "driver-1")
+			consumer := &dispatchConsumer{service: c.service, dispatcher: c}
+			require.NoError(t, c.redis.HSet(ctx, RedisDriverStatusKey, "driver-1", string(DriverStatusOnTrip)))
+
+			require.NoError(t, consumer.handle(ctx, dispatchDelivery(t, tt.routingKey, "rider", tt.data)))
+			assert.Equal(t, tt.expected, driverStatus(t, c, "driver-1"))
+		})
+	}
+}
+
+func TestDispatchConsumerChecksTheAcceptingDriver(t *testing.T) {
+	ctx := context.Background()
+	c, publisher := newTestCoordinator(t, testDispatchConfig(), "driver-1")
+	consumer := &dispatchConsumer{service: c.service, dispatcher: c}
+
+	require.NoError(t, c.Start(ctx, testTrip()))
+	publisher.take()
+
+	accept := messaging.DriverTripResponseData{TripID: "trip-1", Driver: &pb.Driver{Id: "driver-1"}}
+
+	// Another driver can't accept the offer of driver-1
+	require.NoError(t, consumer.handle(ctx, dispatchDelivery(t, contracts.DriverCmdTripAccept, "driver-2", accept)))
+	assert.Empty(t, publisher.take())
+
+	require.NoError(t, consumer.handle(ctx, dispatchDelivery(t, contracts.DriverCmdTripAccept, "driver-1", accept)))
+	assert.Equal(t, []string{"driver-1:" + contracts.TripEventOfferAccepted}, publisher.take())
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/contracts"
+	"github.com/Anurag-Mishra22/taxi/shared/messaging"
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
+	pbt "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePublisher struct {
+	mu        sync.Mutex
+	published []string
+	// failures makes the next publishes of a routing key fail
+	failures map[string]int
+}
+
+func (p *fakePublisher) PublishMessage(ctx context.Context, routingKey string, message contracts.AmqpMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failures[routingKey] > 0 {
+		p.failures[routingKey]--
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, message.OwnerID+":"+routingKey)
+	return nil
+}
+
+// take returns the messages published since the last call
+func (p *fakePublisher) take() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	published := p.published
+	p.published = nil
+	return published
+}
+
+// newTestCoordinator dispatches trips of the sedan package to the available drivers,
+// driver-1 being the nearest to the pickup of testTrip and the next ones farther away
+func newTestCoordinator(t *testing.T, config dispatchConfig, drivers ...string) (*dispatchCoordinator, *fakePublisher) {
+	client := newTestRedisClient(t)
+	svc := &Service{
+		redis:    client,
+		matching: matchingConfig{InitialRadiusKm: 2, MaxPickupDistanceKm: 20, MaxCandidates: 10},
+	}
+
+	for i, id := range drivers {
+		driver := &pb.Driver{Id: id, PackageSlug: "sedan", Location: &pb.Location{Latitude: 38.72 + float64(i+1)*0.001, Longitude: -9.14}}
+		svc.drivers = append(svc.drivers, &driverInMap{Driver: driver, Status: DriverStatusAvailable})
+		require.NoError(t, svc.addDriverLocation(context.Background(), driver))
+		require.NoError(t, client.HSet(context.Background(), RedisDriverStatusKey, id, string(DriverStatusAvailable)))
+	}
+
+	publisher := &fakePublisher{failures: map[string]int{}}
+	return &dispatchCoordinator{publisher: publisher, service: svc, redis: client, config: config}, publisher
+}
+
+// testDispatchConfig retries the failed rounds right away
+func testDispatchConfig() dispatchConfig {
+	return dispatchConfig{
+		OfferTimeout: 20 * time.Second,
+		MaxAttempts:  5,
+		MaxDuration:  time.Minute,
+	}
+}
+
+func testTrip() *pbt.Trip {
+	return &pbt.Trip{
+		Id:           "trip-1",
+		UserID:       "rider",
+		Status:       contracts.TripStatusPending,
+		SelectedFare: &pbt.RideFare{PackageSlug: "sedan"},
+		Pickup:       &pbt.Coordinate{Latitude: 38.72, Longitude: -9.14},
+	}
+}
+
+func driverStatus(t *testing.T, c *dispatchCoordinator, driverID string) DriverStatus {
+	status, err := c.service.GetDriverStatus(context.Background(), driverID)
+	require.NoError(t, err)
+	return status
+}
+
+func TestDispatchKeepsTheFinishedState(t *testing.T) {
+	ctx := context.Background()
+	c, publisher := newTestCoordinator(t, testDispatchConfig(), "driver-1", "driver-2")
+
+	require.NoError(t, c.Start(ctx, testTrip()))
+	assert.Equal(t, []string{"driver-1:" + contracts.DriverCmdTripRequest}, publisher.take())
+
+	require.NoError(t, c.Finish(ctx, "trip-1", "driver-1", messaging.OfferRevokedTaken))
+	state, err := c.state(ctx, "trip-1")
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.True(t, state.Finished)
+
+	// A redelivered created event or a late decline can't start the dispatch again
+	require.NoError(t, c.Start(ctx, testTrip()))
+	require.NoError(t, c.Declined(ctx, testTrip(), "driver-2"))
+	assert.Empty(t, publisher.take())
+	assert.Equal(t, DriverStatusAvailable, driverStatus(t, c, "driver-2"))
+}
+
+func TestDispatchIgnoresAStaleState(t *testing.T) {
+	ctx := context.Background()
+	c, publisher := newTestCoordinator(t, testDispatchConfig(), "driver-1", "driver-2")
+
+	require.NoError(t, c.Start(ctx, testTrip()))
+	publisher.take()
+
+	// The state is read by a pod expiring the offer, just before the driver is assigned
+	stale, err := c.state(ctx, "trip-1")
+	require.NoError(t, err)
+	require.NoError(t, c.Finish(ctx, "trip-1", "driver-1", messaging.OfferRevokedTaken))
+
+	require.NoError(t, c.offerNext(ctx, stale))
+	assert.Empty(t, publisher.take())
+	assert.Equal(t, DriverStatusAvailable, driverStatus(t, c, "driver-2"))
+
+	stale.Attempts = c.config.MaxAttempts
+	require.NoError(t, c.offerNext(ctx, stale))
+	assert.Empty(t, publisher.take(), "no_drivers_found isn't published for an assigned trip")
+
+	state, err := c.state(ctx, "trip-1")
+	require.NoError(t, err)
+	assert.True(t, state.Finished)
+	assert.False(t, state.NoDriversFound)
+}
+
+func TestDispatchRetriesAFailedRound(t *testing.T) {
+	ctx := context.Background()
+	c, publisher := newTestCoordinator(t, testDispatchConfig(), "driver-1")
+
+	// The round failed before its offers were recorded
+	state := &dispatchState{Trip: testTrip(), StartedAt: time.Now()}
+	_, err := c.redis.SetNXJSON(ctx, fmt.Sprintf(RedisDispatchStateKey, "trip-1"), state, c.stateTTL())
+	require.NoError(t, err)
+	require.NoError(t, c.scheduleRetry(ctx, "trip-1"))
+
+	c.retryRounds(ctx)
+	assert.Equal(t, []string{"driver-1:" + contracts.DriverCmdTripRequest}, publisher.take())
+
+	// A round is pending, a retry doesn't start another one
+	require.NoError(t, c.scheduleRetry(ctx, "trip-1"))
+	c.retryRounds(ctx)
+	assert.Empty(t, publisher.take())
+}
+
+func TestDispatchRetriesTheEndWithoutDrivers(t *testing.T) {
+	ctx := context.Background()
+	c, publisher := newTestCoordinator(t, testDispatchConfig())
+	publisher.failures[contracts.TripEventNoDriversFound] = 1
+
+	require.NoError(t, c.Start(ctx, testTrip()))
+	assert.Empty(t, publisher.take())
+
+	c.retryRounds(ctx)
+	assert.Equal(t, []string{"rider:" + contracts.TripEventNoDriversFound}, publisher.take())
+
+	// The retry is done
+	c.retryRounds(ctx)
+	assert.Empty(t, publisher.take())
+}
+
+// testBroadcastConfig offers the sedan trips to two drivers at once
+func testBroadcastConfig() dispatchConfig {
+	config := testDispatchConfig()
+	config.BroadcastPackages = []string{"sedan"}
+	config.BroadcastSize = 2
+	return config
+}
+
+func TestDispatchClaimOffer(t *testing.T) {
+	ctx := context.Background()
+	c, publisher := newTestCoordinator(t, testBroadcastConfig(), "driver-1", "driver-2")
+
+	require.NoError(t, c.Start(ctx, testTrip()))
+	publisher.take()
+
+	claimed, remaining, err := c.claimOffer(ctx, "trip-1", "driver-1")
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Equal(t, int64(1), remaining)
+
+	// An offer is claimed once, by the answer of the driver or by its expiry
+	claimed, _, err = c.claimOffer(ctx, "trip-1", "driver-1")
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	claimed, remaining, err = c.claimOffer(ctx, "trip-1", "driver-2")
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Equal(t, int64(0), remaining)
+
+	claimed, _, err = c.claimOffer(ctx, "trip-2", "driver-1")
+	require.NoError(t, err)
+	assert.False(t, claimed)
+}
+
+func TestDispatchEndsTheRoundOnTheLastDecline(t *testing.T) {
+	ctx := context.Background()
+	c, publisher := newTestCoordinator(t, testBroadcastConfig(), "driver-1", "driver-2", "driver-3")
+
+	require.NoError(t, c.Start(ctx, testTrip()))
+	assert.ElementsMatch(t, []string{
+		"driver-1:" + contracts.DriverCmdTripRequest,
+		"driver-2:" + contracts.DriverCmdTripRequest,
+	}, publisher.take())
+
+	// driver-2 can still accept the round
+	require.NoError(t, c.Declined(ctx, testTrip(), "driver-1"))
+	assert.Empty(t, publisher.take())
+	assert.Equal(t, DriverStatusAvailable, driverStatus(t, c, "driver-1"))
+
+	// A repeated decline doesn't end the round either
+	require.NoError(t, c.Declined(ctx, testTrip(), "driver-1"))
+	assert.Empty(t, publisher.take())
+
+	// The round is over, the next one skips the drivers who were offered the trip
+	require.NoError(t, c.Declined(ctx, testTrip(), "driver-2"))
+	assert.Equal(t, []string{"driver-3:" + contracts.DriverCmdTripRequest}, publisher.take())
+	assert.Equal(t, DriverStatusOffered, driverStatus(t, c, "driver-3"))
+
+	state, err := c.state(ctx, "trip-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, state.Attempts)
+}
+
+func TestDispatchFinishRevokesTheOtherOffers(t *testing.T) {
+	ctx := context.Background()
+	c, publisher := newTestCoordinator(t, testBroadcastConfig(), "driver-1", "driver-2")
+
+	require.NoError(t, c.Start(ctx, testTrip()))
+	publisher.take()
+
+	require.NoError(t, c.Finish(ctx, "trip-1", "driver-1", messaging.OfferRevokedTaken))
+	assert.Equal(t, []string{"driver-2:" + contracts.TripEventOfferRevoked}, publisher.take())
+	assert.Equal(t, DriverStatusAvailable, driverStatus(t, c, "driver-2"))
+	// The dispatch consumer moves the assigned driver on a trip
+	assert.Equal(t, DriverStatusOffered, driverStatus(t, c, "driver-1"))
+
+	pending, err := c.redis.SCard(ctx, fmt.Sprintf(RedisDispatchPendingKey, "trip-1"))
+	require.NoError(t, err)
+	assert.Zero(t, pending)
+	offers, err := c.redis.GetClient().ZCard(ctx, RedisDispatchOffersKey).Result()
+	require.NoError(t, err)
+	assert.Zero(t, offers)
+
+	// A redelivered event revokes nothing more
+	require.NoError(t, c.Finish(ctx, "trip-1", "driver-1", messaging.OfferRevokedTaken))
+	assert.Empty(t, publisher.take())
+}
+
+func TestDispatchAccepted(t *testing.T) {
+	ctx := context.Background()
+	config := testBroadcastConfig()
+	config.BroadcastSize = 3
+	c, publisher := newTestCoordinator(t, config, "driver-1", "driver-2", "driver-3", "driver-4")
+
+	require.NoError(t, c.Start(ctx, testTrip()))
+	publisher.take()
+
+	// driver-4 wasn't offered the trip
+	require.NoError(t, c.Accepted(ctx, "trip-1", "driver-4"))
+	assert.Empty(t, publisher.take())
+
+	require.NoError(t, c.Accepted(ctx, "trip-1", "driver-1"))
+	assert.Equal(t, []string{"driver-1:" + contracts.TripEventOfferAccepted}, publisher.take())
+
+	// A redelivered accept goes through again, the trip-service assigns the trip once
+	require.NoError(t, c.Accepted(ctx, "trip-1", "driver-1"))
+	assert.Equal(t, []string{"driver-1:" + contracts.TripEventOfferAccepted}, publisher.take())
+
+	require.NoError(t, c.Accepted(ctx, "trip-1", "driver-2"))
+	assert.Equal(t, []string{"driver-2:" + contracts.TripEventOfferAccepted}, publisher.take())
+
+	// The round isn't over while the accepts are handled
+	require.NoError(t, c.Declined(ctx, testTrip(), "driver-3"))
+	assert.Empty(t, publisher.take())
+
+	// driver-1 was assigned first, driver-2 lost the trip
+	require.NoError(t, c.Finish(ctx, "trip-1", "driver-1", messaging.OfferRevokedTaken))
+	assert.Equal(t, []string{"driver-2:" + contracts.TripEventOfferRevoked}, publisher.take())
+	assert.Equal(t, DriverStatusAvailable, driverStatus(t, c, "driver-2"))
+	assert.Equal(t, DriverStatusOffered, driverStatus(t, c, "driver-1"))
+
+	// The offers are closed
+	require.NoError(t, c.Accepted(ctx, "trip-1", "driver-2"))
+	assert.Empty(t, publisher.take())
+}
+
+func TestDispatchRevokesTheExpiredOffers(t *testing.T) {
+	ctx := context.Background()
+	config := testDispatchConfig()
+	config.OfferTimeout = -time.Second
+	c, publisher := newTestCoordinator(t, config, "driver-1", "driver-2")
+
+	require.NoError(t, c.Start(ctx, testTrip()))
+	publisher.take()
+
+	// The trip goes to the next driver, whose offer expires in turn
+	c.expireOffers(ctx)
+	assert.Equal(t, []string{
+		"driver-1:" + contracts.TripEventOfferRevoked,
+		"driver-2:" + contracts.DriverCmdTripRequest,
+	}, publisher.take())
+	assert.Equal(t, DriverStatusAvailable, driverStatus(t, c, "driver-1"))
+	assert.Equal(t, DriverStatusOffered, driverStatus(t, c, "driver-2"))
+
+	// A driver answering after the expiry doesn't move the dispatch on
+	require.NoError(t, c.Declined(ctx, testTrip(), "driver-1"))
+	assert.Empty(t, publisher.take())
+
+	// No driver is left to offer the trip to
+	c.expireOffers(ctx)
+	assert.Equal(t, []string{
+		"driver-2:" + contracts.TripEventOfferRevoked,
+		"rider:" + contracts.TripEventNoDriversFound,
+	}, publisher.take())
+	assert.Equal(t, DriverStatusAvailable, driverStatus(t, c, "driver-2"))
+}
+
+func TestOffersPerRound(t *testing.T) {
+	config := dispatchConfig{
+		BroadcastPackages: []string{"van"},
+		BroadcastZones:    []string{"airport"},
+		BroadcastSize:     3,
+	}
+
+	tests := []struct {
+		name     string
+		trip     *pbt.Trip
+		expected int
+	}{
+		{"sequential", &pbt.Trip{SelectedFare: &pbt.RideFare{PackageSlug: "sedan"}, ZoneID: "downtown"}, 1},
+		{"broadcast_package", &pbt.Trip{SelectedFare: &pbt.RideFare{PackageSlug: "van"}}, 3},
+		{"broadcast_zone", &pbt.Trip{SelectedFare: &pbt.RideFare{PackageSlug: "sedan"}, ZoneID: "airport"}, 3},
+		{"no_zone", &pbt.Trip{SelectedFare: &pbt.RideFare{PackageSlug: "sedan"}}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, config.offersPerRound(tt.trip))
+		})
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Equal(t, []string{"van", "luxury"}, splitList(" van, ,luxury,"))
+	assert.Empty(t, splitList(""))
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	pb "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
 	"github.com/Anurag-Mishra22/taxi/shared/metrics"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -13,20 +15,29 @@ import (
 type driverGrpcHandler struct {
 	pb.UnimplementedDriverServiceServer
 
-	service *Service
-	metrics *metrics.Metrics
+	service  *Service
+	packages *packageCatalog
+	metrics  *metrics.Metrics
 }
 
-func NewGrpcHandler(s *grpc.Server, service *Service, m *metrics.Metrics) {
+func NewGrpcHandler(s *grpc.Server, service *Service, packages *packageCatalog, m *metrics.Metrics) {
 	handler := &driverGrpcHandler{
-		service: service,
-		metrics: m,
+		service:  service,
+		packages: packages,
+		metrics:  m,
 	}
 
 	pb.RegisterDriverServiceServer(s, handler)
 }
 
 func (h *driverGrpcHandler) RegisterDriver(ctx context.Context, req *pb.RegisterDriverRequest) (*pb.RegisterDriverResponse, error) {
+	if err := h.packages.Validate(ctx, req.GetPackageSlug()); err != nil {
+		if errors.Is(err, ErrUnknownPackage) || errors.Is(err, ErrPackageDisabled) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid package: %v", err)
+		}
+		return nil, status.Errorf(codes.Unavailable, "failed to validate the package: %v", err)
+	}
+
 	driver, err := h.service.RegisterDriver(req.GetDriverID(), req.GetPackageSlug())
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to register driver")
@@ -46,3 +57,43 @@ func (h *driverGrpcHandler) UnregisterDriver(ctx context.Context, req *pb.Regist
 		},
 	}, nil
 }
+
+func (h *driverGrpcHandler) UpdateDriverLocation(ctx context.Context, req *pb.UpdateDriverLocationRequest) (*pb.UpdateDriverLocationResponse, error) {
+	if req.GetLocation() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "location is required")
+	}
+
+	driver, err := h.service.UpdateDriverLocation(ctx, req.GetDriverID(), req.GetLocation())
+	if err != nil {
+		switch {
+		case errors.Is(err, types.ErrInvalidCoordinate):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid location: %v", err)
+		case errors.Is(err, ErrDriverNotFound):
+			return nil, status.Errorf(codes.NotFound, "driver %s is not online", req.GetDriverID())
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to update the driver location: %v", err)
+		}
+	}
+
+	return &pb.UpdateDriverLocationResponse{
+		Driver: driver,
+	}, nil
+}
+
+func (h *driverGrpcHandler) SetDriverPaused(ctx context.Context, req *pb.SetDriverPausedRequest) (*pb.SetDriverPausedResponse, error) {
+	driverStatus, err := h.service.SetDriverPaused(ctx, req.GetDriverID(), req.GetPaused())
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDriverNotFound):
+			return nil, status.Errorf(codes.NotFound, "driver %s is not online", req.GetDriverID())
+		case errors.Is(err, ErrDriverOnTrip):
+			return nil, status.Errorf(codes.FailedPrecondition, "driver %s can't pause during a trip", req.GetDriverID())
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to update the driver status: %v", err)
+		}
+	}
+
+	return &pb.SetDriverPausedResponse{
+		Status: string(driverStatus),
+	}, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"github.com/mmcloughlin/geohash"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/proto"
+)
+
+// RedisDriverLocationsKey is a Redis GEO index of the online drivers by package type
+const RedisDriverLocationsKey = "drivers:locations:%s"
+
+// ErrDriverNotFound is returned when updating a driver that isn't online
+var ErrDriverNotFound = errors.New("driver not found")
+
+// saveLocationScript writes the driver data ARGV[1] to KEYS[1] and moves the driver ARGV[2] to
+// the longitude ARGV[3] and latitude ARGV[4] in the GEO index KEYS[2], then extends the TTL of
+// KEYS[1] to ARGV[5] seconds and of KEYS[2] and the package set KEYS[3] to ARGV[6] seconds.
+// Nothing is written once KEYS[1] is deleted, it returns 0 for a driver who disconnected.
+var saveLocationScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'data', ARGV[1])
+redis.call('EXPIRE', KEYS[1], ARGV[5])
+redis.call('GEOADD', KEYS[2], ARGV[3], ARGV[4], ARGV[2])
+redis.call('EXPIRE', KEYS[2], ARGV[6])
+redis.call('EXPIRE', KEYS[3], ARGV[6])
+return 1
+`)
+
+// UpdateDriverLocation moves an online driver to location. The driver profile and the
+// GEO index in Redis are updated, so every pod sees the latest position.
+func (s *Service) UpdateDriverLocation(ctx context.Context, driverID string, location *pb.Location) (*pb.Driver, error) {
+	coordinate := &types.Coordinate{Latitude: location.GetLatitude(), Longitude: location.GetLongitude()}
+	if err := coordinate.Validate(); err != nil {
+		return nil, err
+	}
+
+	driver, err := s.findDriver(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	driver.Location = &pb.Location{Latitude: coordinate.Latitude, Longitude: coordinate.Longitude}
+	driver.Geohash = geohash.Encode(coordinate.Latitude, coordinate.Longitude)
+
+	// Redis is written without holding the lock, the drivers of the pod aren't blocked meanwhile
+	if s.redis != nil {
+		if err := s.saveDriverLocation(ctx, driver); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	for _, d := range s.drivers {
+		if d.Driver.Id == driverID {
+			d.Driver = driver
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	return driver, nil
+}
+
+// findDriver reads the driver from Redis, as it may have registered on another pod,
+// or from memory when Redis is unavailable. The driver is a copy the caller can update.
+func (s *Service) findDriver(ctx context.Context, driverID string) (*pb.Driver, error) {
+	if s.redis != nil {
+		var driver pb.Driver
+		err := s.redis.HGetJSON(ctx, RedisDriverDataPrefix+driverID, "data", &driver)
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrDriverNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get driver %s data: %w", driverID, err)
+		}
+		return &driver, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, d := range s.drivers {
+		if d.Driver.Id == driverID {
+			return proto.Clone(d.Driver).(*pb.Driver), nil
+		}
+	}
+	return nil, ErrDriverNotFound
+}
+
+// saveDriverLocation stores the driver data and its position, unless the driver disconnected
+// since it was read. An active driver stays online, the TTLs of their keys are extended.
+func (s *Service) saveDriverLocation(ctx context.Context, driver *pb.Driver) error {
+	data, err := json.Marshal(driver)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{
+		RedisDriverDataPrefix + driver.Id,
+		fmt.Sprintf(RedisDriverLocationsKey, driver.PackageSlug),
+		fmt.Sprintf(RedisDriversByPackageKey, driver.PackageSlug),
+	}
+	saved, err := saveLocationScript.Run(ctx, s.redis.GetClient(), keys,
+		string(data),
+		driver.Id,
+		driver.Location.GetLongitude(),
+		driver.Location.GetLatitude(),
+		int((30 * time.Minute).Seconds()),
+		int((31 * time.Minute).Seconds()),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to store driver %s location: %w", driver.Id, err)
+	}
+	if saved == 0 {
+		return ErrDriverNotFound
+	}
+	return nil
+}
+
+// addDriverLocation adds or moves the driver in the GEO index of its package
+func (s *Service) addDriverLocation(ctx context.Context, driver *pb.Driver) error {
+	locationsKey := fmt.Sprintf(RedisDriverLocationsKey, driver.PackageSlug)
+
+	err := s.redis.GeoAdd(ctx, locationsKey, &redis.GeoLocation{
+		Name:      driver.Id,
+		Latitude:  driver.Location.GetLatitude(),
+		Longitude: driver.Location.GetLongitude(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index driver %s location: %w", driver.Id, err)
+	}
+	s.redis.Expire(ctx, locationsKey, 31*time.Minute)
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Anurag-Mishra22/taxi/shared/cache"
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+	"github.com/mmcloughlin/geohash"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedisClient(t *testing.T) *cache.RedisClient {
+	server := miniredis.RunT(t)
+	t.Setenv("REDIS_HOST", server.Host())
+	t.Setenv("REDIS_PORT", server.Port())
+
+	client, err := cache.NewRedisClient()
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	registerGeoSearch(t, server, client)
+	return client
+}
+
+// registerGeoSearch answers the GEOSEARCH queries of the matching with GEORADIUS,
+// miniredis doesn't implement GEOSEARCH
+func registerGeoSearch(t *testing.T, m *miniredis.Miniredis, client *cache.RedisClient) {
+	err := m.Server().Register("GEOSEARCH", func(c *server.Peer, cmd string, args []string) {
+		var lon, lat float64
+		query := &redis.GeoRadiusQuery{}
+		for i := 1; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "FROMLONLAT":
+				lon, _ = strconv.ParseFloat(args[i+1], 64)
+				lat, _ = strconv.ParseFloat(args[i+2], 64)
+				i += 2
+			case "BYRADIUS":
+				query.Radius, _ = strconv.ParseFloat(args[i+1], 64)
+				query.Unit = args[i+2]
+				i += 2
+			case "ASC", "DESC":
+				query.Sort = strings.ToUpper(args[i])
+			case "COUNT":
+				query.Count, _ = strconv.Atoi(args[i+1])
+				i++
+			case "WITHDIST":
+				query.WithDist = true
+			}
+		}
+
+		locations, err := client.GetClient().GeoRadius(context.Background(), args[0], lon, lat, query).Result()
+		if err != nil {
+			c.WriteError(err.Error())
+			return
+		}
+		c.WriteLen(len(locations))
+		for _, l := range locations {
+			if !query.WithDist {
+				c.WriteBulk(l.Name)
+				continue
+			}
+			c.WriteLen(2)
+			c.WriteBulk(l.Name)
+			c.WriteBulk(strconv.FormatFloat(l.Dist, 'f', 4, 64))
+		}
+	})
+	require.NoError(t, err)
+}
+
+func TestUpdateDriverLocationInMemory(t *testing.T) {
+	driver := &driverInMap{Driver: &pb.Driver{Id: "driver-1", PackageSlug: "sedan"}, Status: DriverStatusAvailable}
+	svc := &Service{drivers: []*driverInMap{driver}}
+	location := &pb.Location{Latitude: 38.72, Longitude: -9.14}
+
+	updated, err := svc.UpdateDriverLocation(context.Background(), "driver-1", location)
+	require.NoError(t, err)
+	assert.Equal(t, location.Latitude, updated.Location.Latitude)
+	assert.Equal(t, location.Longitude, updated.Location.Longitude)
+	assert.Equal(t, geohash.Encode(38.72, -9.14), updated.Geohash)
+	assert.Equal(t, updated, driver.Driver)
+	assert.Equal(t, DriverStatusAvailable, driver.Status)
+}
+
+func TestUpdateDriverLocationInRedis(t *testing.T) {
+	ctx := context.Background()
+	client := newTestRedisClient(t)
+	svc := &Service{redis: client}
+
+	require.NoError(t, client.HSetJSON(ctx, RedisDriverDataPrefix+"driver-1", "data", &pb.Driver{Id: "driver-1", PackageSlug: "sedan"}))
+
+	_, err := svc.UpdateDriverLocation(ctx, "driver-1", &pb.Location{Latitude: 38.72, Longitude: -9.14})
+	require.NoError(t, err)
+
+	var stored pb.Driver
+	require.NoError(t, client.HGetJSON(ctx, RedisDriverDataPrefix+"driver-1", "data", &stored))
+	assert.Equal(t, 38.72, stored.Location.GetLatitude())
+	assert.Equal(t, geohash.Encode(38.72, -9.14), stored.Geohash)
+
+	positions, err := client.GetClient().GeoPos(ctx, fmt.Sprintf(RedisDriverLocationsKey, "sedan"), "driver-1").Result()
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	require.NotNil(t, positions[0])
+	assert.InDelta(t, 38.72, positions[0].Latitude, 0.0001)
+	assert.InDelta(t, -9.14, positions[0].Longitude, 0.0001)
+}
+
+func TestUpdateDriverLocationRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		driverID string
+		location *pb.Location
+		err      error
+	}{
+		{"unknown_driver", "driver-2", &pb.Location{Latitude: 38.72, Longitude: -9.14}, ErrDriverNotFound},
+		{"beyond_the_geo_index_limit", "driver-1", &pb.Location{Latitude: 86, Longitude: -9.14}, types.ErrInvalidCoordinate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := newTestRedisClient(t)
+			svc := &Service{redis: client}
+			require.NoError(t, client.HSetJSON(ctx, RedisDriverDataPrefix+"driver-1", "data", &pb.Driver{Id: "driver-1", PackageSlug: "sedan"}))
+
+			_, err := svc.UpdateDriverLocation(ctx, tt.driverID, tt.location)
+			assert.True(t, errors.Is(err, tt.err), "got %v", err)
+		})
+	}
+}
+
+func TestUpdateDriverLocationOfADisconnectedDriver(t *testing.T) {
+	ctx := context.Background()
+	client := newTestRedisClient(t)
+	svc := &Service{redis: client}
+
+	// The driver was read just before they disconnected
+	driver := &pb.Driver{Id: "driver-1", PackageSlug: "sedan", Location: &pb.Location{Latitude: 38.72, Longitude: -9.14}}
+	err := svc.saveDriverLocation(ctx, driver)
+	assert.True(t, errors.Is(err, ErrDriverNotFound), "got %v", err)
+
+	exists, err := client.GetClient().Exists(ctx, RedisDriverDataPrefix+"driver-1", fmt.Sprintf(RedisDriverLocationsKey, "sedan")).Result()
+	require.NoError(t, err)
+	assert.Zero(t, exists)
+}
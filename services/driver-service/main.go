@@ -62,6 +62,12 @@ func main() {
 
 	svc := NewService(appMetrics)
 
+	packages, err := NewPackageCatalog()
+	if err != nil {
+		log.Fatalf("Failed to create the trip service client: %v", err)
+	}
+	defer packages.Close()
+
 	// RabbitMQ connection
 	rabbitmq, err := messaging.NewRabbitMQ(rabbitMqURI)
 	if err != nil {
@@ -79,15 +85,25 @@ func main() {
 	}
 	grpcOpts = append(grpcOpts, tracing.WithTracingInterceptors()...)
 	grpcServer := grpcserver.NewServer(grpcOpts...)
-	NewGrpcHandler(grpcServer, svc, appMetrics)
+	NewGrpcHandler(grpcServer, svc, packages, appMetrics)
 
-	consumer := NewTripConsumer(rabbitmq, svc, appMetrics)
+	dispatcher := NewDispatchCoordinator(rabbitmq, svc, svc.redis)
+	go dispatcher.Run(ctx)
+
+	consumer := NewTripConsumer(rabbitmq, dispatcher, appMetrics)
 	go func() {
 		if err := consumer.Listen(); err != nil {
 			log.Fatalf("Failed to listen to the message: %v", err)
 		}
 	}()
 
+	dispatchConsumer := NewDispatchConsumer(rabbitmq, svc, dispatcher, appMetrics)
+	go func() {
+		if err := dispatchConsumer.Listen(); err != nil {
+			log.Fatalf("Failed to listen to the message: %v", err)
+		}
+	}()
+
 	log.Printf("Starting gRPC server Driver service on port %s", lis.Addr().String())
 
 	go func() {
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/env"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// driverCandidate is an online driver that can be offered a trip
+type driverCandidate struct {
+	DriverID string
+	// DistanceKm is the straight distance to the pickup, 0 when the pickup is unknown
+	DistanceKm float64
+}
+
+// matchingConfig bounds the search for drivers around a pickup
+type matchingConfig struct {
+	// InitialRadiusKm is the radius of the first search, it doubles while no driver is found
+	InitialRadiusKm float64
+	// MaxPickupDistanceKm is the farthest a driver is sent to pick up a rider
+	MaxPickupDistanceKm float64
+	// MaxCandidates is the number of nearest drivers returned by a search
+	MaxCandidates int
+}
+
+// defaultMaxPickupDistanceKm replaces a maximum pickup distance that isn't positive
+const defaultMaxPickupDistanceKm = 10
+
+// defaultMaxCandidates replaces a number of candidates that isn't positive
+const defaultMaxCandidates = 10
+
+func newMatchingConfig() matchingConfig {
+	config := matchingConfig{
+		InitialRadiusKm:     env.GetFloat("DRIVER_SEARCH_RADIUS_KM", 2),
+		MaxPickupDistanceKm: env.GetFloat("DRIVER_MAX_PICKUP_DISTANCE_KM", defaultMaxPickupDistanceKm),
+		MaxCandidates:       env.GetInt("DRIVER_MAX_CANDIDATES", defaultMaxCandidates),
+	}
+	if config.MaxPickupDistanceKm <= 0 {
+		log.Printf("DRIVER_MAX_PICKUP_DISTANCE_KM must be positive, using %v km", float64(defaultMaxPickupDistanceKm))
+		config.MaxPickupDistanceKm = defaultMaxPickupDistanceKm
+	}
+	return config
+}
+
+// maxPickupDistanceKm is the maximum pickup distance, the default one when it isn't positive
+func (c matchingConfig) maxPickupDistanceKm() float64 {
+	if c.MaxPickupDistanceKm <= 0 {
+		return defaultMaxPickupDistanceKm
+	}
+	return c.MaxPickupDistanceKm
+}
+
+// maxCandidates is the number of candidates of a search, the default one when it isn't positive
+func (c matchingConfig) maxCandidates() int {
+	if c.MaxCandidates <= 0 {
+		return defaultMaxCandidates
+	}
+	return c.MaxCandidates
+}
+
+// radii are the search radii, from the initial radius doubling up to the maximum pickup distance
+func (c matchingConfig) radii() []float64 {
+	radius := math.Max(c.InitialRadiusKm, 0.1)
+	maxDistance := c.maxPickupDistanceKm()
+
+	var radii []float64
+	for radius < maxDistance {
+		radii = append(radii, radius)
+		radius *= 2
+	}
+	return append(radii, maxDistance)
+}
+
+// FindAvailableDrivers returns the available drivers of the package around the pickup, nearest first,
+// except the excluded drivers. The search radius expands in steps until a driver is found or the
+// maximum pickup distance is reached. Uses the Redis GEO index for cluster-wide matching, falls back
+// to in-memory search if Redis is unavailable.
+func (s *Service) FindAvailableDrivers(ctx context.Context, packageType string, pickup *types.Coordinate, excluded []string) []driverCandidate {
+	if pickup == nil {
+		// Trips published before the pickup was part of the event
+		log.Printf("No pickup to match drivers for package %s, falling back to the package set", packageType)
+		return s.findPackageDrivers(ctx, packageType, excluded)
+	}
+
+	if s.redis != nil {
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+
+		candidates, err := s.findNearbyDrivers(ctx, packageType, pickup, excluded)
+		if err == nil {
+			log.Printf("Found %d drivers for package %s from Redis (cluster-wide)", len(candidates), packageType)
+			return candidates
+		}
+		log.Printf("Failed to search drivers in Redis for package %s: %v, falling back to memory", packageType, err)
+	}
+
+	// Fallback: search in-memory (single-pod only)
+	return s.findNearbyDriversInMemory(packageType, pickup, excluded)
+}
+
+func (s *Service) findNearbyDrivers(ctx context.Context, packageType string, pickup *types.Coordinate, excluded []string) ([]driverCandidate, error) {
+	locationsKey := fmt.Sprintf(RedisDriverLocationsKey, packageType)
+
+	for _, radius := range s.matching.radii() {
+		candidates, err := s.searchAvailableDrivers(ctx, locationsKey, pickup, radius, excluded)
+		if err != nil {
+			return nil, err
+		}
+		// The search expands while no driver is available
+		if len(candidates) > 0 {
+			return candidates, nil
+		}
+	}
+
+	return []driverCandidate{}, nil
+}
+
+// searchAvailableDrivers returns the nearest available drivers within the radius, except the excluded ones.
+// The GEOSEARCH count includes the excluded and the busy drivers, so it doubles while they fill the results.
+func (s *Service) searchAvailableDrivers(ctx context.Context, locationsKey string, pickup *types.Coordinate, radius float64, excluded []string) ([]driverCandidate, error) {
+	maxCandidates := s.matching.maxCandidates()
+
+	for count := maxCandidates; ; count *= 2 {
+		locations, err := s.redis.GeoSearchLocation(ctx, locationsKey, &redis.GeoSearchLocationQuery{
+			GeoSearchQuery: redis.GeoSearchQuery{
+				Longitude:  pickup.Longitude,
+				Latitude:   pickup.Latitude,
+				Radius:     radius,
+				RadiusUnit: "km",
+				Sort:       "ASC",
+				Count:      count,
+			},
+			WithDist: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var candidates []driverCandidate
+		for _, l := range locations {
+			if !slices.Contains(excluded, l.Name) {
+				candidates = append(candidates, driverCandidate{DriverID: l.Name, DistanceKm: l.Dist})
+			}
+		}
+
+		candidates, err = s.filterAvailableDrivers(ctx, candidates)
+		if err != nil {
+			return nil, err
+		}
+		if len(candidates) >= maxCandidates {
+			return candidates[:maxCandidates], nil
+		}
+		// Fewer results than the count, every driver within the radius was seen
+		if len(locations) < count {
+			return candidates, nil
+		}
+	}
+}
+
+// findNearbyDriversInMemory searches only this pod's in-memory driver list.
+// Used as fallback when Redis is unavailable.
+func (s *Service) findNearbyDriversInMemory(packageType string, pickup *types.Coordinate, excluded []string) []driverCandidate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var candidates []driverCandidate
+	for _, driver := range s.drivers {
+		if driver.Driver.PackageSlug != packageType || driver.Status != DriverStatusAvailable || driver.Driver.Location == nil {
+			continue
+		}
+		if slices.Contains(excluded, driver.Driver.Id) {
+			continue
+		}
+
+		location := &types.Coordinate{Latitude: driver.Driver.Location.Latitude, Longitude: driver.Driver.Location.Longitude}
+		distance := pickup.DistanceKm(location)
+		if distance <= s.matching.maxPickupDistanceKm() {
+			candidates = append(candidates, driverCandidate{DriverID: driver.Driver.Id, DistanceKm: distance})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].DistanceKm < candidates[j].DistanceKm })
+	if len(candidates) > s.matching.maxCandidates() {
+		candidates = candidates[:s.matching.maxCandidates()]
+	}
+
+	log.Printf("Found %d drivers for package %s from memory (single-pod)", len(candidates), packageType)
+	return candidates
+}
+
+// findPackageDrivers returns every available driver of the package but the excluded ones, in no particular order
+func (s *Service) findPackageDrivers(ctx context.Context, packageType string, excluded []string) []driverCandidate {
+	if s.redis != nil {
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+
+		candidates, err := s.findPackageDriversInRedis(ctx, packageType, excluded)
+		if err == nil {
+			return candidates
+		}
+		log.Printf("Failed to get drivers from Redis for package %s: %v, falling back to memory", packageType, err)
+	}
+
+	var candidates []driverCandidate
+	for _, id := range s.findAvailableDriversInMemory(packageType) {
+		if !slices.Contains(excluded, id) {
+			candidates = append(candidates, driverCandidate{DriverID: id})
+		}
+	}
+	return candidates
+}
+
+func (s *Service) findPackageDriversInRedis(ctx context.Context, packageType string, excluded []string) ([]driverCandidate, error) {
+	driverIDs, err := s.redis.SMembers(ctx, fmt.Sprintf(RedisDriversByPackageKey, packageType))
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []driverCandidate
+	for _, id := range driverIDs {
+		if !slices.Contains(excluded, id) {
+			candidates = append(candidates, driverCandidate{DriverID: id})
+		}
+	}
+	return s.filterAvailableDrivers(ctx, candidates)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchingRadii(t *testing.T) {
+	config := matchingConfig{InitialRadiusKm: 2, MaxPickupDistanceKm: 10}
+	assert.Equal(t, []float64{2, 4, 8, 10}, config.radii())
+
+	config = matchingConfig{InitialRadiusKm: 5, MaxPickupDistanceKm: 3}
+	assert.Equal(t, []float64{3}, config.radii())
+
+	// A maximum pickup distance that isn't positive is replaced by the default one
+	config = matchingConfig{InitialRadiusKm: 4, MaxPickupDistanceKm: 0}
+	assert.Equal(t, []float64{4, 8, 10}, config.radii())
+
+	config = matchingConfig{InitialRadiusKm: 4, MaxPickupDistanceKm: -5}
+	assert.Equal(t, []float64{4, 8, 10}, config.radii())
+}
+
+func TestNewMatchingConfigRejectsANonPositiveMaxPickupDistance(t *testing.T) {
+	t.Setenv("DRIVER_MAX_PICKUP_DISTANCE_KM", "-1")
+	assert.Equal(t, float64(defaultMaxPickupDistanceKm), newMatchingConfig().MaxPickupDistanceKm)
+}
+
+func TestFindAvailableDriversInMemoryRanksByDistance(t *testing.T) {
+	driver := func(id, packageSlug string, lat, lon float64) *driverInMap {
+		return &driverInMap{
+			Driver: &pb.Driver{Id: id, PackageSlug: packageSlug, Location: &pb.Location{Latitude: lat, Longitude: lon}},
+			Status: DriverStatusAvailable,
+		}
+	}
+
+	busy := driver("on_trip", "sedan", 38.72, -9.14)
+	busy.Status = DriverStatusOnTrip
+
+	svc := &Service{
+		drivers: []*driverInMap{
+			driver("far", "sedan", 38.80, -9.14),
+			driver("near", "sedan", 38.721, -9.14),
+			driver("too_far", "sedan", 39.50, -9.14),
+			driver("other_package", "van", 38.72, -9.14),
+			busy,
+		},
+		matching: matchingConfig{InitialRadiusKm: 2, MaxPickupDistanceKm: 20, MaxCandidates: 10},
+	}
+
+	candidates := svc.FindAvailableDrivers(context.Background(), "sedan", &types.Coordinate{Latitude: 38.72, Longitude: -9.14}, nil)
+
+	var ids []string
+	for _, c := range candidates {
+		ids = append(ids, c.DriverID)
+	}
+	assert.Equal(t, []string{"near", "far"}, ids)
+	assert.Less(t, candidates[0].DistanceKm, 1.0)
+}
+
+func TestFindAvailableDriversExpandsPastTheExcludedDrivers(t *testing.T) {
+	ctx := context.Background()
+	client := newTestRedisClient(t)
+	svc := &Service{
+		redis:    client,
+		matching: matchingConfig{InitialRadiusKm: 2, MaxPickupDistanceKm: 20, MaxCandidates: 10},
+	}
+
+	// Only the declined driver is within the initial radius
+	for _, driver := range []*pb.Driver{
+		{Id: "declined", PackageSlug: "sedan", Location: &pb.Location{Latitude: 38.721, Longitude: -9.14}},
+		{Id: "farther", PackageSlug: "sedan", Location: &pb.Location{Latitude: 38.75, Longitude: -9.14}},
+	} {
+		require.NoError(t, svc.addDriverLocation(ctx, driver))
+		require.NoError(t, client.HSet(ctx, RedisDriverStatusKey, driver.Id, string(DriverStatusAvailable)))
+	}
+
+	candidates := svc.FindAvailableDrivers(ctx, "sedan", &types.Coordinate{Latitude: 38.72, Longitude: -9.14}, []string{"declined"})
+
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "farther", candidates[0].DriverID)
+	assert.Greater(t, candidates[0].DistanceKm, 2.0)
+}
+
+func TestFindAvailableDriversSkipsTheBusyNearestDrivers(t *testing.T) {
+	ctx := context.Background()
+	client := newTestRedisClient(t)
+	svc := &Service{
+		redis:    client,
+		matching: matchingConfig{InitialRadiusKm: 2, MaxPickupDistanceKm: 20, MaxCandidates: 2},
+	}
+
+	// The busy drivers are nearer than the available ones and fill the first results
+	drivers := []struct {
+		id       string
+		latitude float64
+		status   DriverStatus
+	}{
+		{"busy_1", 38.7201, DriverStatusOnTrip},
+		{"busy_2", 38.7202, DriverStatusOffered},
+		{"busy_3", 38.7203, DriverStatusOnTrip},
+		{"available_1", 38.721, DriverStatusAvailable},
+		{"available_2", 38.722, DriverStatusAvailable},
+		{"available_3", 38.723, DriverStatusAvailable},
+	}
+	for _, d := range drivers {
+		require.NoError(t, svc.addDriverLocation(ctx, &pb.Driver{Id: d.id, PackageSlug: "sedan", Location: &pb.Location{Latitude: d.latitude, Longitude: -9.14}}))
+		require.NoError(t, client.HSet(ctx, RedisDriverStatusKey, d.id, string(d.status)))
+	}
+
+	candidates := svc.FindAvailableDrivers(ctx, "sedan", &types.Coordinate{Latitude: 38.72, Longitude: -9.14}, nil)
+
+	var ids []string
+	for _, c := range candidates {
+		ids = append(ids, c.DriverID)
+	}
+	assert.Equal(t, []string{"available_1", "available_2"}, ids)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/env"
+	pbt "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+	"github.com/Anurag-Mishra22/taxi/shared/tracing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var (
+	ErrUnknownPackage  = errors.New("unknown package")
+	ErrPackageDisabled = errors.New("package is disabled")
+)
+
+// packageCatalogTTL is how long the catalog fetched from the trip-service is reused
+const packageCatalogTTL = time.Minute
+
+// packageCatalog validates driver packages against the trip-service catalog
+type packageCatalog struct {
+	client pbt.TripServiceClient
+	conn   *grpc.ClientConn
+
+	mu        sync.Mutex
+	packages  map[string]*pbt.VehiclePackage
+	fetchedAt time.Time
+}
+
+func NewPackageCatalog() (*packageCatalog, error) {
+	tripServiceURL := env.GetString("TRIP_SERVICE_URL", "trip-service:9093")
+
+	dialOptions := append(
+		tracing.DialOptionsWithTracing(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+
+	conn, err := grpc.NewClient(tripServiceURL, dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &packageCatalog{
+		client: pbt.NewTripServiceClient(conn),
+		conn:   conn,
+	}, nil
+}
+
+// Validate returns ErrUnknownPackage or ErrPackageDisabled when drivers can't register for the package
+func (c *packageCatalog) Validate(ctx context.Context, slug string) error {
+	packages, err := c.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	p, ok := packages[slug]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPackage, slug)
+	}
+
+	if !p.GetEnabled() {
+		return fmt.Errorf("%w: %s", ErrPackageDisabled, slug)
+	}
+
+	return nil
+}
+
+func (c *packageCatalog) list(ctx context.Context) (map[string]*pbt.VehiclePackage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.packages != nil && time.Since(c.fetchedAt) < packageCatalogTTL {
+		return c.packages, nil
+	}
+
+	resp, err := c.client.ListPackages(ctx, &pbt.ListPackagesRequest{IncludeDisabled: true})
+	if err != nil {
+		if c.packages != nil {
+			// A stale catalog is better than refusing every driver while the trip-service is down
+			log.Printf("Failed to refresh the package catalog, using the cached one: %v", err)
+			return c.packages, nil
+		}
+		return nil, fmt.Errorf("failed to fetch the package catalog: %w", err)
+	}
+
+	packages := make(map[string]*pbt.VehiclePackage, len(resp.GetPackages()))
+	for _, p := range resp.GetPackages() {
+		packages[p.GetSlug()] = p
+	}
+
+	c.packages = packages
+	c.fetchedAt = time.Now()
+
+	return packages, nil
+}
+
+func (c *packageCatalog) Close() {
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
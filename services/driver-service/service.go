@@ -9,6 +9,7 @@ import (
 	"github.com/Anurag-Mishra22/taxi/shared/metrics"
 	pb "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
 	"github.com/Anurag-Mishra22/taxi/shared/util"
+	"slices"
 	"sync"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 
 type driverInMap struct {
 	Driver *pb.Driver
+	Status DriverStatus
 	// Index int
 	// TODO: route
 }
@@ -26,6 +28,8 @@ type Service struct {
 	mu      sync.RWMutex
 	metrics *metrics.Metrics
 	redis   *cache.RedisClient
+	// matching bounds the search for drivers around a pickup
+	matching matchingConfig
 }
 
 const (
@@ -44,9 +48,10 @@ func NewService(m *metrics.Metrics) *Service {
 	}
 
 	svc := &Service{
-		drivers: make([]*driverInMap, 0),
-		metrics: m,
-		redis:   redisClient,
+		drivers:  make([]*driverInMap, 0),
+		metrics:  m,
+		redis:    redisClient,
+		matching: newMatchingConfig(),
 	}
 
 	// Initial sync with Redis to set correct metric value
@@ -58,38 +63,13 @@ func NewService(m *metrics.Metrics) *Service {
 	return svc
 }
 
-// FindAvailableDrivers returns IDs of online drivers matching the package type.
-// Uses Redis per-package sets for cluster-wide matching across all pods.
-// Falls back to in-memory search if Redis is unavailable.
-func (s *Service) FindAvailableDrivers(packageType string) []string {
-	// Try Redis first (cluster-wide matching)
-	if s.redis != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-
-		// Direct lookup from per-package set (O(1) operation)
-		packageKey := fmt.Sprintf(RedisDriversByPackageKey, packageType)
-		driverIDs, err := s.redis.SMembers(ctx, packageKey)
-		if err != nil {
-			log.Printf("Failed to get drivers from Redis for package %s: %v, falling back to memory", packageType, err)
-			// Fall through to memory-based search
-		} else {
-			log.Printf("Found %d drivers for package %s from Redis (cluster-wide)", len(driverIDs), packageType)
-			return driverIDs
-		}
-	}
-
-	// Fallback: search in-memory (single-pod only)
-	return s.findAvailableDriversInMemory(packageType)
-}
-
 // findAvailableDriversInMemory searches only this pod's in-memory driver list.
 // Used as fallback when Redis is unavailable.
 func (s *Service) findAvailableDriversInMemory(packageType string) []string {
 	var matchingDrivers []string
 
 	for _, driver := range s.drivers {
-		if driver.Driver.PackageSlug == packageType {
+		if driver.Driver.PackageSlug == packageType && driver.Status == DriverStatusAvailable {
 			matchingDrivers = append(matchingDrivers, driver.Driver.Id)
 		}
 	}
@@ -129,9 +109,18 @@ func (s *Service) RegisterDriver(driverId string, packageSlug string) (*pb.Drive
 		CarPlate:       randomPlate,
 	}
 
-	s.drivers = append(s.drivers, &driverInMap{
-		Driver: driver,
-	})
+	// A driver registering again keeps the status of their previous connection, see keptStatuses
+	registered := &driverInMap{Driver: driver, Status: DriverStatusAvailable}
+	for i, d := range s.drivers {
+		if d.Driver.Id == driverId {
+			if slices.Contains(keptStatuses, d.Status) {
+				registered.Status = d.Status
+			}
+			s.drivers = append(s.drivers[:i], s.drivers[i+1:]...)
+			break
+		}
+	}
+	s.drivers = append(s.drivers, registered)
 
 	// Store driver in Redis
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -159,6 +148,19 @@ func (s *Service) RegisterDriver(driverId string, packageSlug string) (*pb.Drive
 		}
 		s.redis.Expire(ctx, driverKey, 30*time.Minute)
 
+		// 4. Index the initial position for the proximity searches
+		if err := s.addDriverLocation(ctx, driver); err != nil {
+			log.Printf("%v", err)
+		}
+
+		// 5. A new connection starts available, unless the driver is on a trip or paused
+		status, err := s.registerDriverStatus(ctx, driverId)
+		if err != nil {
+			log.Printf("%v", err)
+		} else {
+			registered.Status = status
+		}
+
 		log.Printf("Driver %s registered in Redis (global + package:%s)", driverId, packageSlug)
 	}
 
@@ -174,12 +176,15 @@ func (s *Service) UnregisterDriver(driverId string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Remove from in-memory list and track package type for Redis cleanup
+	// Remove from in-memory list and track package type for Redis cleanup.
+	// A driver on a trip or paused stays, to keep their status until they reconnect.
 	var driverPackage string
 	for i, driver := range s.drivers {
 		if driver.Driver.Id == driverId {
 			driverPackage = driver.Driver.PackageSlug
-			s.drivers = append(s.drivers[:i], s.drivers[i+1:]...)
+			if !slices.Contains(keptStatuses, driver.Status) {
+				s.drivers = append(s.drivers[:i], s.drivers[i+1:]...)
+			}
 			break
 		}
 	}
@@ -189,33 +194,41 @@ func (s *Service) UnregisterDriver(driverId string) {
 	defer cancel()
 
 	if s.redis != nil {
-		// 1. Remove from global online drivers set
+		driverKey := RedisDriverDataPrefix + driverId
+
+		// If package unknown, try to get it from Redis driver data before deleting
+		if driverPackage == "" {
+			var driver pb.Driver
+			if err := s.redis.HGetJSON(ctx, driverKey, "data", &driver); err == nil {
+				driverPackage = driver.PackageSlug
+			}
+		}
+
+		// 1. Delete driver profile data first, a concurrent location update
+		// only indexes the position of a driver whose data still exists
+		if err := s.redis.Del(ctx, driverKey); err != nil {
+			log.Printf("Failed to delete driver %s data from Redis: %v", driverId, err)
+		}
+
+		// 2. Remove from global online drivers set
 		if err := s.redis.SRem(ctx, RedisDriversOnlineKey, driverId); err != nil {
 			log.Printf("Failed to remove driver %s from Redis SET: %v", driverId, err)
 		} else {
 			log.Printf("Removed driver %s from Redis online set", driverId)
 		}
 
-		// 2. Remove from per-package set (if we know the package)
+		// 3. Remove from per-package set and location index (if we know the package)
 		if driverPackage != "" {
 			packageKey := fmt.Sprintf(RedisDriversByPackageKey, driverPackage)
 			if err := s.redis.SRem(ctx, packageKey, driverId); err != nil {
 				log.Printf("Failed to remove driver %s from package set %s: %v", driverId, packageKey, err)
 			}
-		} else {
-			// If package unknown, try to get it from Redis driver data before deleting
-			driverKey := RedisDriverDataPrefix + driverId
-			var driver pb.Driver
-			if err := s.redis.HGetJSON(ctx, driverKey, "data", &driver); err == nil {
-				packageKey := fmt.Sprintf(RedisDriversByPackageKey, driver.PackageSlug)
-				s.redis.SRem(ctx, packageKey, driverId)
-			}
+			s.redis.ZRem(ctx, fmt.Sprintf(RedisDriverLocationsKey, driverPackage), driverId)
 		}
 
-		// 3. Delete driver profile data
-		driverKey := RedisDriverDataPrefix + driverId
-		if err := s.redis.Del(ctx, driverKey); err != nil {
-			log.Printf("Failed to delete driver %s data from Redis: %v", driverId, err)
+		// 4. Offline drivers have no status, unless they are on a trip or paused
+		if err := s.unregisterDriverStatus(ctx, driverId); err != nil {
+			log.Printf("%v", err)
 		}
 
 		log.Printf("Driver %s fully unregistered from Redis", driverId)
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DriverStatus tells whether a driver can be offered a trip
+type DriverStatus string
+
+const (
+	// DriverStatusAvailable drivers are the only ones matched with trips
+	DriverStatusAvailable DriverStatus = "available"
+	// DriverStatusOffered drivers have a pending trip offer
+	DriverStatusOffered DriverStatus = "offered"
+	// DriverStatusOnTrip drivers have been assigned a trip, until they complete it or it is cancelled
+	DriverStatusOnTrip DriverStatus = "on_trip"
+	// DriverStatusPaused drivers are connected but don't want new trips
+	DriverStatusPaused DriverStatus = "paused"
+	// DriverStatusOffline drivers aren't connected, they have no stored status
+	DriverStatusOffline DriverStatus = "offline"
+)
+
+// RedisDriverStatusKey is a Redis HASH of the status of the online drivers,
+// and of the disconnected drivers with one of the keptStatuses
+const RedisDriverStatusKey = "drivers:status"
+
+// keptStatuses outlive the connection of the driver: a driver reconnecting in the middle
+// of a trip is still carrying the rider, and a paused driver still doesn't want offers
+var keptStatuses = []DriverStatus{DriverStatusOnTrip, DriverStatusPaused}
+
+// ErrDriverOnTrip is returned when pausing a driver who is driving a rider
+var ErrDriverOnTrip = errors.New("driver is on a trip")
+
+// transitionStatusScript sets the status field ARGV[1] to ARGV[2] when its current value
+// is one of ARGV[3:], or any value when there are none. Missing fields aren't set.
+var transitionStatusScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], ARGV[1])
+if not current then
+	return 0
+end
+if #ARGV == 2 then
+	redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+	return 1
+end
+for i = 3, #ARGV do
+	if ARGV[i] == current then
+		redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+		return 1
+	end
+end
+return 0
+`)
+
+// clearStatusScript removes the status field ARGV[1], unless its value is one of ARGV[2:]
+var clearStatusScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], ARGV[1])
+for i = 2, #ARGV do
+	if ARGV[i] == current then
+		return 0
+	end
+end
+redis.call('HDEL', KEYS[1], ARGV[1])
+return 1
+`)
+
+// transitionDriverStatus atomically moves an online driver to status to, when the current status
+// is one of from or when from is empty. It reports whether the status changed.
+func (s *Service) transitionDriverStatus(ctx context.Context, driverID string, to DriverStatus, from ...DriverStatus) (bool, error) {
+	if s.redis == nil {
+		return s.transitionDriverStatusInMemory(driverID, to, from...), nil
+	}
+
+	args := []interface{}{driverID, string(to)}
+	for _, status := range from {
+		args = append(args, string(status))
+	}
+
+	changed, err := transitionStatusScript.Run(ctx, s.redis.GetClient(), []string{RedisDriverStatusKey}, args...).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to update driver %s status: %w", driverID, err)
+	}
+	if changed == 0 {
+		return false, nil
+	}
+
+	s.transitionDriverStatusInMemory(driverID, to)
+	log.Printf("Driver %s is now %s", driverID, to)
+	return true, nil
+}
+
+func (s *Service) transitionDriverStatusInMemory(driverID string, to DriverStatus, from ...DriverStatus) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, d := range s.drivers {
+		if d.Driver.Id == driverID {
+			if len(from) > 0 && !slices.Contains(from, d.Status) {
+				return false
+			}
+			d.Status = to
+			return true
+		}
+	}
+	return false
+}
+
+// GetDriverStatus returns the status of a driver, offline when the driver isn't connected
+// and didn't disconnect with one of the keptStatuses
+func (s *Service) GetDriverStatus(ctx context.Context, driverID string) (DriverStatus, error) {
+	if s.redis == nil {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		for _, d := range s.drivers {
+			if d.Driver.Id == driverID {
+				return d.Status, nil
+			}
+		}
+		return DriverStatusOffline, nil
+	}
+
+	status, err := s.redis.HGet(ctx, RedisDriverStatusKey, driverID)
+	if errors.Is(err, redis.Nil) {
+		return DriverStatusOffline, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get driver %s status: %w", driverID, err)
+	}
+	return DriverStatus(status), nil
+}
+
+// SetDriverPaused stops or resumes the trip offers of a connected driver.
+// A driver with a pending offer can pause, the offer is left to expire or be declined.
+func (s *Service) SetDriverPaused(ctx context.Context, driverID string, paused bool) (DriverStatus, error) {
+	to, from := DriverStatusAvailable, []DriverStatus{DriverStatusPaused}
+	if paused {
+		to, from = DriverStatusPaused, []DriverStatus{DriverStatusAvailable, DriverStatusOffered}
+	}
+
+	changed, err := s.transitionDriverStatus(ctx, driverID, to, from...)
+	if err != nil {
+		return "", err
+	}
+	if changed {
+		return to, nil
+	}
+
+	status, err := s.GetDriverStatus(ctx, driverID)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case status == DriverStatusOffline:
+		return "", ErrDriverNotFound
+	case paused && status == DriverStatusOnTrip:
+		return "", ErrDriverOnTrip
+	}
+	// Pausing a paused driver, or resuming a driver who wasn't paused
+	return status, nil
+}
+
+// registerDriverStatus makes a connecting driver available, unless they kept
+// a status from their previous connection. It returns the status of the driver.
+func (s *Service) registerDriverStatus(ctx context.Context, driverID string) (DriverStatus, error) {
+	if err := s.redis.GetClient().HSetNX(ctx, RedisDriverStatusKey, driverID, string(DriverStatusAvailable)).Err(); err != nil {
+		return "", fmt.Errorf("failed to set driver %s status: %w", driverID, err)
+	}
+	return s.GetDriverStatus(ctx, driverID)
+}
+
+// unregisterDriverStatus makes a disconnecting driver offline, unless they have one of the keptStatuses
+func (s *Service) unregisterDriverStatus(ctx context.Context, driverID string) error {
+	args := []interface{}{driverID}
+	for _, status := range keptStatuses {
+		args = append(args, string(status))
+	}
+
+	if err := clearStatusScript.Run(ctx, s.redis.GetClient(), []string{RedisDriverStatusKey}, args...).Err(); err != nil {
+		return fmt.Errorf("failed to delete driver %s status: %w", driverID, err)
+	}
+	return nil
+}
+
+// filterAvailableDrivers keeps the candidates who can be offered a trip
+func (s *Service) filterAvailableDrivers(ctx context.Context, candidates []driverCandidate) ([]driverCandidate, error) {
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	ids := make([]string, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.DriverID
+	}
+
+	statuses, err := s.redis.HMGet(ctx, RedisDriverStatusKey, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the drivers status: %w", err)
+	}
+
+	available := make([]driverCandidate, 0, len(candidates))
+	for i, c := range candidates {
+		if status, ok := statuses[i].(string); ok && DriverStatus(status) == DriverStatusAvailable {
+			available = append(available, c)
+		}
+	}
+	return available, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDriverPaused(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   DriverStatus
+		paused   bool
+		expected DriverStatus
+		err      error
+	}{
+		{"pause_available", DriverStatusAvailable, true, DriverStatusPaused, nil},
+		{"pause_offered", DriverStatusOffered, true, DriverStatusPaused, nil},
+		{"pause_paused", DriverStatusPaused, true, DriverStatusPaused, nil},
+		{"pause_on_trip", DriverStatusOnTrip, true, DriverStatusOnTrip, ErrDriverOnTrip},
+		{"resume_paused", DriverStatusPaused, false, DriverStatusAvailable, nil},
+		{"resume_on_trip", DriverStatusOnTrip, false, DriverStatusOnTrip, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := &driverInMap{Driver: &pb.Driver{Id: "driver-1"}, Status: tt.status}
+			svc := &Service{drivers: []*driverInMap{driver}}
+
+			status, err := svc.SetDriverPaused(context.Background(), "driver-1", tt.paused)
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), "got %v", err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, status)
+			}
+			assert.Equal(t, tt.expected, driver.Status)
+		})
+	}
+}
+
+func TestSetDriverPausedOffline(t *testing.T) {
+	_, err := (&Service{}).SetDriverPaused(context.Background(), "driver-1", true)
+	assert.True(t, errors.Is(err, ErrDriverNotFound))
+}
+
+func TestRegisterDriverKeepsTheStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   DriverStatus
+		expected DriverStatus
+	}{
+		{"new_driver", DriverStatusOffline, DriverStatusAvailable},
+		{"offered", DriverStatusOffered, DriverStatusAvailable},
+		{"on_trip", DriverStatusOnTrip, DriverStatusOnTrip},
+		{"paused", DriverStatusPaused, DriverStatusPaused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := &Service{redis: newTestRedisClient(t)}
+
+			_, err := svc.RegisterDriver("driver-1", "sedan")
+			require.NoError(t, err)
+			if tt.status != DriverStatusOffline {
+				require.NoError(t, svc.redis.HSet(ctx, RedisDriverStatusKey, "driver-1", string(tt.status)))
+				svc.drivers[0].Status = tt.status
+			}
+
+			// The connection drops and the driver reconnects
+			svc.UnregisterDriver("driver-1")
+			_, err = svc.RegisterDriver("driver-1", "sedan")
+			require.NoError(t, err)
+
+			status, err := svc.GetDriverStatus(ctx, "driver-1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, status)
+			require.Len(t, svc.drivers, 1)
+			assert.Equal(t, tt.expected, svc.drivers[0].Status)
+		})
+	}
+}
+
+func TestRegisterDriverKeepsTheStatusInMemory(t *testing.T) {
+	svc := &Service{}
+	_, err := svc.RegisterDriver("driver-1", "sedan")
+	require.NoError(t, err)
+	svc.drivers[0].Status = DriverStatusOnTrip
+
+	svc.UnregisterDriver("driver-1")
+	_, err = svc.RegisterDriver("driver-1", "sedan")
+	require.NoError(t, err)
+
+	require.Len(t, svc.drivers, 1)
+	assert.Equal(t, DriverStatusOnTrip, svc.drivers[0].Status)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"log"
-	"math/rand"
 	"github.com/Anurag-Mishra22/taxi/shared/contracts"
 	"github.com/Anurag-Mishra22/taxi/shared/messaging"
 	"github.com/Anurag-Mishra22/taxi/shared/metrics"
@@ -14,16 +13,16 @@ import (
 )
 
 type tripConsumer struct {
-	rabbitmq *messaging.RabbitMQ
-	service  *Service
-	metrics  *metrics.Metrics
+	rabbitmq   *messaging.RabbitMQ
+	dispatcher *dispatchCoordinator
+	metrics    *metrics.Metrics
 }
 
-func NewTripConsumer(rabbitmq *messaging.RabbitMQ, service *Service, m *metrics.Metrics) *tripConsumer {
+func NewTripConsumer(rabbitmq *messaging.RabbitMQ, dispatcher *dispatchCoordinator, m *metrics.Metrics) *tripConsumer {
 	return &tripConsumer{
-		rabbitmq: rabbitmq,
-		service:  service,
-		metrics:  m,
+		rabbitmq:   rabbitmq,
+		dispatcher: dispatcher,
+		metrics:    m,
 	}
 }
 
@@ -60,7 +59,12 @@ func (c *tripConsumer) Listen() error {
 			matchStart := time.Now()
 			
 			// Call handler (this does the actual driver matching)
-			err := c.handleFindAndNotifyDrivers(ctx, payload)
+			var err error
+			if msg.RoutingKey == contracts.TripEventDriverNotInterested {
+				err = c.handleDriverNotInterested(ctx, tripEvent.Data)
+			} else {
+				err = c.dispatcher.Start(ctx, payload.Trip)
+			}
 			
 			// Record matching duration (pure business logic time)
 			if c.metrics != nil {
@@ -89,41 +93,11 @@ func (c *tripConsumer) Listen() error {
 	})
 }
 
-func (c *tripConsumer) handleFindAndNotifyDrivers(ctx context.Context, payload messaging.TripEventData) error {
-	suitableIDs := c.service.FindAvailableDrivers(payload.Trip.SelectedFare.PackageSlug)
-
-	log.Printf("Found %d suitable drivers for package '%s'", len(suitableIDs), payload.Trip.SelectedFare.PackageSlug)
-
-	if len(suitableIDs) == 0 {
-		// Notify the driver that no drivers are available
-		if err := c.rabbitmq.PublishMessage(ctx, contracts.TripEventNoDriversFound, contracts.AmqpMessage{
-			OwnerID: payload.Trip.UserID,
-		}); err != nil {
-			log.Printf("Failed to publish message to exchange: %v", err)
-			return err
-		}
-
-		return nil
-	}
-
-	// Get a random index from the matching drivers
-	randomIndex := rand.Intn(len(suitableIDs))
-
-	suitableDriverID := suitableIDs[randomIndex]
-
-	marshalledEvent, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-
-	// Notify the driver about a potential trip
-	if err := c.rabbitmq.PublishMessage(ctx, contracts.DriverCmdTripRequest, contracts.AmqpMessage{
-		OwnerID: suitableDriverID,
-		Data:    marshalledEvent,
-	}); err != nil {
-		log.Printf("Failed to publish message to exchange: %v", err)
+func (c *tripConsumer) handleDriverNotInterested(ctx context.Context, data []byte) error {
+	var payload messaging.TripDriverNotInterestedData
+	if err := json.Unmarshal(data, &payload); err != nil {
 		return err
 	}
 
-	return nil
+	return c.dispatcher.Declined(ctx, payload.Trip, payload.DriverID)
 }
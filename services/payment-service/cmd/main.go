@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/Anurag-Mishra22/taxi/services/payment-service/internal/events"
 	"github.com/Anurag-Mishra22/taxi/services/payment-service/internal/infrastructure/stripe"
@@ -14,6 +15,7 @@ import (
 	"github.com/Anurag-Mishra22/taxi/shared/env"
 	"github.com/Anurag-Mishra22/taxi/shared/messaging"
 	"github.com/Anurag-Mishra22/taxi/shared/metrics"
+	"github.com/Anurag-Mishra22/taxi/shared/resilience"
 	"github.com/Anurag-Mishra22/taxi/shared/tracing"
 )
 
@@ -73,7 +75,11 @@ func main() {
 	}
 
 	// Stripe processor
-	paymentProcessor := stripe.NewStripeClient(stripeCfg, appMetrics)
+	stripePolicy, err := resilience.NewPolicy("stripe", stripePolicyConfig(), appMetrics)
+	if err != nil {
+		log.Fatalf("Failed to create the Stripe policy: %v", err)
+	}
+	paymentProcessor := stripe.NewStripeClient(stripeCfg, stripePolicy, appMetrics)
 
 	// Service
 	svc := service.NewPaymentService(paymentProcessor, appMetrics)
@@ -95,3 +101,14 @@ func main() {
 	<-ctx.Done()
 	log.Println("Shutting down payment service...")
 }
+
+// stripePolicyConfig protects the calls to Stripe
+func stripePolicyConfig() resilience.PolicyConfig {
+	config := resilience.DefaultPolicyConfig()
+	config.MaxConcurrent = env.GetInt("STRIPE_MAX_CONCURRENT", 20)
+	config.Timeout = time.Duration(env.GetInt("STRIPE_TIMEOUT_MS", 10000)) * time.Millisecond
+	config.Breaker.FailureRate = env.GetFloat("STRIPE_BREAKER_FAILURE_RATE", 0.5)
+	config.Breaker.OpenTimeout = time.Duration(env.GetInt("STRIPE_BREAKER_OPEN_SECONDS", 30)) * time.Second
+	config.Breaker.IsFailure = stripe.IsStripeFailure
+	return config
+}
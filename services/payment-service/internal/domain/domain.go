@@ -4,12 +4,14 @@ import (
 	"context"
 
 	"github.com/Anurag-Mishra22/taxi/services/payment-service/pkg/types"
+	sharedTypes "github.com/Anurag-Mishra22/taxi/shared/types"
 )
 
 type Service interface {
-	CreatePaymentSession(ctx context.Context, tripID, userID, driverID string, amount int64, currency string) (*types.PaymentIntent, error)
+	CreatePaymentSession(ctx context.Context, tripID, userID, driverID string, amount sharedTypes.Money) (*types.PaymentIntent, error)
+	CreateCancellationFeeSession(ctx context.Context, tripID, userID, driverID string, amount sharedTypes.Money) (*types.PaymentIntent, error)
 }
 
 type PaymentProcessor interface {
-	CreatePaymentSession(ctx context.Context, amount int64, currency string, metadata map[string]string) (string, error)
+	CreatePaymentSession(ctx context.Context, amount sharedTypes.Money, metadata map[string]string) (string, error)
 }
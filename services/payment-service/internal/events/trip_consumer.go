@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Anurag-Mishra22/taxi/services/payment-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/services/payment-service/pkg/types"
 	"github.com/Anurag-Mishra22/taxi/shared/contracts"
 	"github.com/Anurag-Mishra22/taxi/shared/messaging"
 	"github.com/Anurag-Mishra22/taxi/shared/metrics"
@@ -49,6 +50,11 @@ func (c *TripConsumer) Listen() error {
 				log.Printf("Failed to handle trip accepted: %v", err)
 				return err
 			}
+		case contracts.PaymentCmdChargeCancellationFee:
+			if err := c.handleCancellationFee(ctx, payload); err != nil {
+				log.Printf("Failed to handle cancellation fee: %v", err)
+				return err
+			}
 		}
 
 		if c.metrics != nil {
@@ -67,8 +73,7 @@ func (c *TripConsumer) handleTripAccepted(ctx context.Context, payload messaging
 		payload.TripID,
 		payload.UserID,
 		payload.DriverID,
-		int64(payload.Amount),
-		payload.Currency,
+		payload.Amount,
 	)
 	if err != nil {
 		log.Printf("Failed to create payment session: %v", err)
@@ -77,12 +82,35 @@ func (c *TripConsumer) handleTripAccepted(ctx context.Context, payload messaging
 
 	log.Printf("Payment session created: %s", paymentSession.StripeSessionID)
 
+	return c.publishSessionCreated(ctx, payload, paymentSession)
+}
+
+func (c *TripConsumer) handleCancellationFee(ctx context.Context, payload messaging.PaymentTripResponseData) error {
+	log.Printf("Charging cancellation fee for trip: %s", payload.TripID)
+
+	paymentSession, err := c.service.CreateCancellationFeeSession(
+		ctx,
+		payload.TripID,
+		payload.UserID,
+		payload.DriverID,
+		payload.Amount,
+	)
+	if err != nil {
+		log.Printf("Failed to create cancellation fee session: %v", err)
+		return err
+	}
+
+	log.Printf("Cancellation fee session created: %s", paymentSession.StripeSessionID)
+
+	return c.publishSessionCreated(ctx, payload, paymentSession)
+}
+
+func (c *TripConsumer) publishSessionCreated(ctx context.Context, payload messaging.PaymentTripResponseData, paymentSession *types.PaymentIntent) error {
 	// Publish payment session created event
 	paymentPayload := messaging.PaymentEventSessionCreatedData{
 		TripID:    payload.TripID,
 		SessionID: paymentSession.StripeSessionID,
-		Amount:    float64(paymentSession.Amount) / 100.0, // Convert from cents to dollars
-		Currency:  paymentSession.Currency,
+		Amount:    paymentSession.Amount,
 	}
 
 	payloadBytes, err := json.Marshal(paymentPayload)
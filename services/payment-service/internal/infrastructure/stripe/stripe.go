@@ -2,10 +2,15 @@ package stripe
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/Anurag-Mishra22/taxi/services/payment-service/internal/domain"
 	"github.com/Anurag-Mishra22/taxi/services/payment-service/pkg/types"
+	"github.com/Anurag-Mishra22/taxi/shared/messaging"
 	"github.com/Anurag-Mishra22/taxi/shared/metrics"
+	"github.com/Anurag-Mishra22/taxi/shared/resilience"
+	sharedTypes "github.com/Anurag-Mishra22/taxi/shared/types"
+	"strings"
 	"time"
 
 	"github.com/stripe/stripe-go/v81"
@@ -14,19 +19,38 @@ import (
 
 type stripeClient struct {
 	config  *types.PaymentConfig
+	policy  *resilience.Policy
 	metrics *metrics.Metrics
 }
 
-func NewStripeClient(config *types.PaymentConfig, m *metrics.Metrics) domain.PaymentProcessor {
+// NewStripeClient creates the Stripe processor, every call to Stripe goes through policy
+func NewStripeClient(config *types.PaymentConfig, policy *resilience.Policy, m *metrics.Metrics) domain.PaymentProcessor {
 	stripe.Key = config.StripeSecretKey
 
 	return &stripeClient{
 		config:  config,
+		policy:  policy,
 		metrics: m,
 	}
 }
 
-func (s *stripeClient) CreatePaymentSession(ctx context.Context, amount int64, currency string, metadata map[string]string) (string, error) {
+// IsStripeFailure is the breaker failure check of the Stripe calls. Stripe rejecting
+// a request (4xx) means the request was wrong, not that Stripe is unhealthy,
+// but rate limiting means it should be left alone for a while.
+func IsStripeFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var stripeErr *stripe.Error
+	if errors.As(err, &stripeErr) && stripeErr.HTTPStatusCode >= 400 && stripeErr.HTTPStatusCode < 500 {
+		return stripeErr.HTTPStatusCode == 429
+	}
+
+	return true
+}
+
+func (s *stripeClient) CreatePaymentSession(ctx context.Context, amount sharedTypes.Money, metadata map[string]string) (string, error) {
 	start := time.Now()
 	defer func() {
 		if s.metrics != nil {
@@ -40,11 +64,12 @@ func (s *stripeClient) CreatePaymentSession(ctx context.Context, amount int64, c
 		LineItems: []*stripe.CheckoutSessionLineItemParams{
 			{
 				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-					Currency: stripe.String(currency),
+					// Stripe expects lowercase ISO codes and amounts in the minor unit
+					Currency: stripe.String(strings.ToLower(amount.Currency)),
 					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-						Name: stripe.String("Ride Payment"),
+						Name: stripe.String(productName(metadata["charge_type"])),
 					},
-					UnitAmount: stripe.Int64(amount),
+					UnitAmount: stripe.Int64(amount.AmountMinor),
 				},
 				Quantity: stripe.Int64(1),
 			},
@@ -52,7 +77,10 @@ func (s *stripeClient) CreatePaymentSession(ctx context.Context, amount int64, c
 		Mode: stripe.String(string(stripe.CheckoutSessionModePayment)),
 	}
 
-	result, err := session.New(params)
+	result, err := resilience.Call(ctx, s.policy, func(ctx context.Context) (*stripe.CheckoutSession, error) {
+		params.Context = ctx
+		return session.New(params)
+	})
 	if err != nil {
 		if s.metrics != nil {
 			s.metrics.ExternalAPICallsTotal.WithLabelValues("stripe", "error").Inc()
@@ -66,3 +94,11 @@ func (s *stripeClient) CreatePaymentSession(ctx context.Context, amount int64, c
 
 	return result.ID, nil
 }
+
+// productName returns the name shown to the rider on the Stripe checkout page
+func productName(chargeType string) string {
+	if chargeType == messaging.PaymentChargeTypeCancellationFee {
+		return "Cancellation Fee"
+	}
+	return "Ride Payment"
+}
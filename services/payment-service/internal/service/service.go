@@ -7,7 +7,9 @@ import (
 
 	"github.com/Anurag-Mishra22/taxi/services/payment-service/internal/domain"
 	"github.com/Anurag-Mishra22/taxi/services/payment-service/pkg/types"
+	"github.com/Anurag-Mishra22/taxi/shared/messaging"
 	"github.com/Anurag-Mishra22/taxi/shared/metrics"
+	sharedTypes "github.com/Anurag-Mishra22/taxi/shared/types"
 
 	"github.com/google/uuid"
 )
@@ -31,16 +33,38 @@ func (s *paymentService) CreatePaymentSession(
 	tripID string,
 	userID string,
 	driverID string,
-	amount int64,
-	currency string,
+	amount sharedTypes.Money,
+) (*types.PaymentIntent, error) {
+	return s.createSession(ctx, messaging.PaymentChargeTypeRide, tripID, userID, driverID, amount)
+}
+
+// CreateCancellationFeeSession creates a payment session charging the rider for a cancelled trip
+func (s *paymentService) CreateCancellationFeeSession(
+	ctx context.Context,
+	tripID string,
+	userID string,
+	driverID string,
+	amount sharedTypes.Money,
+) (*types.PaymentIntent, error) {
+	return s.createSession(ctx, messaging.PaymentChargeTypeCancellationFee, tripID, userID, driverID, amount)
+}
+
+func (s *paymentService) createSession(
+	ctx context.Context,
+	chargeType string,
+	tripID string,
+	userID string,
+	driverID string,
+	amount sharedTypes.Money,
 ) (*types.PaymentIntent, error) {
 	metadata := map[string]string{
-		"trip_id":   tripID,
-		"user_id":   userID,
-		"driver_id": driverID,
+		"trip_id":     tripID,
+		"user_id":     userID,
+		"driver_id":   driverID,
+		"charge_type": chargeType,
 	}
 
-	sessionID, err := s.paymentProcessor.CreatePaymentSession(ctx, amount, currency, metadata)
+	sessionID, err := s.paymentProcessor.CreatePaymentSession(ctx, amount, metadata)
 	if err != nil {
 		if s.metrics != nil {
 			s.metrics.PaymentErrors.WithLabelValues("session_creation_failed").Inc()
@@ -49,7 +73,7 @@ func (s *paymentService) CreatePaymentSession(
 	}
 
 	if s.metrics != nil {
-		s.metrics.RecordPayment("created", "stripe", currency, float64(amount))
+		s.metrics.RecordPayment("created", "stripe", amount.Currency, amount.Major())
 	}
 
 	paymentIntent := &types.PaymentIntent{
@@ -58,7 +82,6 @@ func (s *paymentService) CreatePaymentSession(
 		UserID:          userID,
 		DriverID:        driverID,
 		Amount:          amount,
-		Currency:        currency,
 		StripeSessionID: sessionID,
 		CreatedAt:       time.Now(),
 	}
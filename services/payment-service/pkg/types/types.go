@@ -1,6 +1,10 @@
 package types
 
-import "time"
+import (
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+)
 
 // PaymentStatus represents the current status of a payment
 type PaymentStatus string
@@ -17,8 +21,7 @@ type Payment struct {
 	ID              string        `json:"id"`
 	TripID          string        `json:"trip_id"`
 	UserID          string        `json:"user_id"`
-	Amount          int64         `json:"amount"`   // Amount in cents
-	Currency        string        `json:"currency"` // e.g., "usd"
+	Amount          types.Money   `json:"amount"`
 	Status          PaymentStatus `json:"status"`
 	StripeSessionID string        `json:"stripe_session_id"`
 	CreatedAt       time.Time     `json:"created_at"`
@@ -27,21 +30,20 @@ type Payment struct {
 
 // PaymentIntent represents the intent to collect a payment
 type PaymentIntent struct {
-	ID              string    `json:"id"`
-	TripID          string    `json:"trip_id"`
-	UserID          string    `json:"user_id"`
-	DriverID        string    `json:"driver_id"`
-	Amount          int64     `json:"amount"`
-	Currency        string    `json:"currency"`
-	StripeSessionID string    `json:"stripe_session_id"`
-	CreatedAt       time.Time `json:"created_at"`
+	ID              string      `json:"id"`
+	TripID          string      `json:"trip_id"`
+	UserID          string      `json:"user_id"`
+	DriverID        string      `json:"driver_id"`
+	Amount          types.Money `json:"amount"`
+	StripeSessionID string      `json:"stripe_session_id"`
+	CreatedAt       time.Time   `json:"created_at"`
 }
 
 // PaymentConfig holds the configuration for the payment service
 type PaymentConfig struct {
-	StripeSecretKey      string `json:"stripeSecretKey"`
-	StripeWebhookSecret  string `json:"stripeWebhookSecret"`
-	Currency             string `json:"currency"`
-	SuccessURL           string `json:"successURL"`
-	CancelURL            string `json:"cancelURL"`
+	StripeSecretKey     string `json:"stripeSecretKey"`
+	StripeWebhookSecret string `json:"stripeWebhookSecret"`
+	Currency            string `json:"currency"`
+	SuccessURL          string `json:"successURL"`
+	CancelURL           string `json:"cancelURL"`
 }
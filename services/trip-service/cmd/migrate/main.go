@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/infrastructure/repository"
+	"github.com/Anurag-Mishra22/taxi/shared/db"
+	"github.com/Anurag-Mishra22/taxi/shared/db/migrate"
+	"github.com/Anurag-Mishra22/taxi/shared/env"
+)
+
+const usage = `Usage: go run ./services/trip-service/cmd/migrate [flags] <command>
+
+Commands:
+  status          list the migrations and when they were applied
+  up [version]    apply the pending migrations, up to version (default: all)
+  down <version>  revert the migrations above version (0 reverts everything)
+
+Flags:
+`
+
+func main() {
+	uri := flag.String("uri", env.GetString("MONGODB_URI", ""), "MongoDB URI")
+	database := flag.String("database", db.NewMongoDefaultConfig().Database, "MongoDB database")
+	currency := flag.String("currency", env.GetString("CURRENCY", "USD"), "Currency of the legacy amounts")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	cfg := &db.MongoConfig{URI: *uri, Database: *database}
+
+	client, err := db.NewMongoClient(ctx, cfg)
+	if err != nil {
+		fmt.Printf("Error connecting to MongoDB: %v\n", err)
+		os.Exit(1)
+	}
+	defer client.Disconnect(ctx)
+
+	migrator, err := migrate.NewMigrator(db.GetDatabase(client, cfg), repository.Migrations(*currency))
+	if err != nil {
+		fmt.Printf("Error loading the migrations: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := run(ctx, migrator, flag.Args()); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, migrator *migrate.Migrator, args []string) error {
+	switch command := args[0]; command {
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%4d  %-19s  %s\n", s.Version, applied, s.Description)
+		}
+		return nil
+	case "up":
+		target := migrator.Latest()
+		if len(args) > 1 {
+			version, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid version: %s", args[1])
+			}
+			target = version
+		}
+		return migrator.Up(ctx, target)
+	case "down":
+		// The target is required, so a typo can't revert every migration
+		if len(args) < 2 {
+			return fmt.Errorf("down needs the version to revert to")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version: %s", args[1])
+		}
+		return migrator.Down(ctx, version)
+	default:
+		return fmt.Errorf("unknown command: %s", command)
+	}
+}
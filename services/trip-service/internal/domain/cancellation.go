@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+)
+
+// TripCancellation records why and when a trip was cancelled and what the rider was charged
+type TripCancellation struct {
+	Reason      string      `bson:"reason"`
+	Fee         types.Money `bson:"fee"`
+	CancelledAt time.Time   `bson:"cancelledAt"`
+}
+
+// CancellationPolicy decides the fee a rider pays for cancelling a trip
+type CancellationPolicy struct {
+	// GracePeriod is how long after a driver is assigned the rider can still cancel for free
+	GracePeriod time.Duration
+	Fee         types.Money
+}
+
+// FeeFor returns the cancellation fee for the trip if it was cancelled at the given time.
+// Trips without an assigned driver are always free to cancel.
+func (p CancellationPolicy) FeeFor(trip *TripModel, at time.Time) types.Money {
+	free := types.NewMoney(0, p.Fee.Currency)
+
+	if trip.DriverAssignedAt == nil {
+		return free
+	}
+
+	if at.Sub(*trip.DriverAssignedAt) <= p.GracePeriod {
+		return free
+	}
+
+	return p.Fee
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancellationPolicyFeeFor(t *testing.T) {
+	policy := CancellationPolicy{
+		GracePeriod: 2 * time.Minute,
+		Fee:         types.NewMoney(500, "USD"),
+	}
+	assignedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		assignedAt *time.Time
+		at         time.Time
+		expected   int64
+	}{
+		{"no_driver_assigned", nil, assignedAt.Add(time.Hour), 0},
+		{"inside_the_grace_period", &assignedAt, assignedAt.Add(time.Minute), 0},
+		{"at_the_end_of_the_grace_period", &assignedAt, assignedAt.Add(2 * time.Minute), 0},
+		{"after_the_grace_period", &assignedAt, assignedAt.Add(2*time.Minute + time.Second), 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee := policy.FeeFor(&TripModel{DriverAssignedAt: tt.assignedAt}, tt.at)
+			assert.Equal(t, tt.expected, fee.AmountMinor)
+			assert.Equal(t, "USD", fee.Currency)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxMessage is a message written in the same transaction as the trip change it announces.
+// The outbox relay publishes it to RabbitMQ afterwards, so a change is never left unannounced.
+type OutboxMessage struct {
+	ID primitive.ObjectID `bson:"_id,omitempty"`
+	// TripID and Sequence order the messages, the messages of a trip are published in the order they were written
+	TripID string `bson:"tripID"`
+	// Sequence numbers the messages of a trip, in the order their transactions committed
+	Sequence   int64     `bson:"sequence"`
+	RoutingKey string    `bson:"routingKey"`
+	OwnerID    string    `bson:"ownerID"`
+	Data       []byte    `bson:"data"`
+	CreatedAt  time.Time `bson:"createdAt"`
+	// LeaseUntil is set while a relay is publishing the message
+	LeaseUntil *time.Time `bson:"leaseUntil,omitempty"`
+	// PublishedAt is set once RabbitMQ confirmed the message, published messages are deleted after a while
+	PublishedAt *time.Time `bson:"publishedAt,omitempty"`
+	Attempts    int        `bson:"attempts"`
+	LastError   string     `bson:"lastError,omitempty"`
+	// DeadLetteredAt is set when the relay gave up on the message, it is kept with its last error
+	// and no longer holds back the next messages of the trip
+	DeadLetteredAt *time.Time `bson:"deadLetteredAt,omitempty"`
+}
+
+// Transactor runs fn in a transaction: the repository calls made with the ctx
+// passed to fn are committed together, or not at all when fn fails
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type OutboxRepository interface {
+	// AddOutboxMessage writes a message of an existing trip and sets its Sequence, from a counter of the trip
+	// incremented in the same transaction. It returns ErrTripNotFound when the trip doesn't exist.
+	AddOutboxMessage(ctx context.Context, message *OutboxMessage) error
+	// ListPendingOutboxMessages returns the oldest unpublished message of up to limit trips, oldest first.
+	// Later messages of a trip are only returned once the previous ones are published.
+	ListPendingOutboxMessages(ctx context.Context, limit int) ([]*OutboxMessage, error)
+	// ClaimOutboxMessage leases an unpublished message until leaseUntil,
+	// it returns false when another relay holds the lease
+	ClaimOutboxMessage(ctx context.Context, id primitive.ObjectID, now, leaseUntil time.Time) (bool, error)
+	MarkOutboxMessagePublished(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	// RecordOutboxFailure keeps the last publish error, the message is retried once its lease expires
+	RecordOutboxFailure(ctx context.Context, id primitive.ObjectID, reason string) error
+	// DeadLetterOutboxMessage stops retrying a message that failed too many times
+	DeadLetterOutboxMessage(ctx context.Context, id primitive.ObjectID, at time.Time, reason string) error
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+)
+
+var ErrPackageNotFound = errors.New("package does not exist")
+
+// PackageModel is a vehicle package riders can book and drivers can register for.
+// The fares are in the minor unit of the pricing currency.
+type PackageModel struct {
+	Slug             string  `bson:"slug" json:"slug"` // ex: van, luxury, sedan
+	DisplayName      string  `bson:"displayName" json:"displayName"`
+	SeatCapacity     int     `bson:"seatCapacity" json:"seatCapacity"`
+	BaseFareMinor    float64 `bson:"baseFareMinor" json:"baseFareMinor"`
+	PerKmMinor       float64 `bson:"perKmMinor" json:"perKmMinor"`
+	PerMinuteMinor   float64 `bson:"perMinuteMinor" json:"perMinuteMinor"`
+	MinimumFareMinor float64 `bson:"minimumFareMinor" json:"minimumFareMinor"`
+	Enabled          bool    `bson:"enabled" json:"enabled"`
+}
+
+// PackageCatalog is the source of the bookable vehicle packages
+type PackageCatalog interface {
+	// ListPackages returns every package, enabled or not
+	ListPackages(ctx context.Context) ([]*PackageModel, error)
+}
+
+// DefaultPackages is the catalog used when no catalog file or collection is configured
+func DefaultPackages() []*PackageModel {
+	return []*PackageModel{
+		{
+			Slug:             "suv",
+			DisplayName:      "SUV",
+			SeatCapacity:     6,
+			BaseFareMinor:    200,
+			PerKmMinor:       1500,
+			PerMinuteMinor:   15,
+			MinimumFareMinor: 700,
+			Enabled:          true,
+		},
+		{
+			Slug:             "sedan",
+			DisplayName:      "Sedan",
+			SeatCapacity:     4,
+			BaseFareMinor:    350,
+			PerKmMinor:       1500,
+			PerMinuteMinor:   15,
+			MinimumFareMinor: 700,
+			Enabled:          true,
+		},
+		{
+			Slug:             "van",
+			DisplayName:      "Van",
+			SeatCapacity:     8,
+			BaseFareMinor:    400,
+			PerKmMinor:       1500,
+			PerMinuteMinor:   15,
+			MinimumFareMinor: 900,
+			Enabled:          true,
+		},
+		{
+			Slug:             "luxury",
+			DisplayName:      "Luxury",
+			SeatCapacity:     4,
+			BaseFareMinor:    1000,
+			PerKmMinor:       1500,
+			PerMinuteMinor:   15,
+			MinimumFareMinor: 1500,
+			Enabled:          true,
+		},
+	}
+}
+
+func (p *PackageModel) ToProto() *pb.VehiclePackage {
+	return &pb.VehiclePackage{
+		Slug:             p.Slug,
+		DisplayName:      p.DisplayName,
+		SeatCapacity:     int32(p.SeatCapacity),
+		BaseFareMinor:    p.BaseFareMinor,
+		PerKmMinor:       p.PerKmMinor,
+		PerMinuteMinor:   p.PerMinuteMinor,
+		MinimumFareMinor: p.MinimumFareMinor,
+		Enabled:          p.Enabled,
+	}
+}
+
+func ToPackagesProto(packages []*PackageModel) []*pb.VehiclePackage {
+	protoPackages := make([]*pb.VehiclePackage, len(packages))
+	for i, p := range packages {
+		protoPackages[i] = p.ToProto()
+	}
+	return protoPackages
+}
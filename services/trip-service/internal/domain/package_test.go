@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The catalog replaced the hard-coded base fares, which were priced per meter and per second
+func TestDefaultPackagesKeepTheLegacyRates(t *testing.T) {
+	legacyBaseFares := map[string]int64{"suv": 200, "sedan": 350, "van": 400, "luxury": 1000}
+	const (
+		legacyPerMeter  = 1.5
+		legacyPerSecond = 0.25
+	)
+
+	// A 5 km ride of 10 minutes, as returned by OSRM
+	distanceMeters, durationSeconds := 5000.0, 600.0
+	engine := NewPricingEngine("USD", BaseFareRule(), DistanceRule(), TimeRule(), MinimumFareRule())
+
+	for _, p := range DefaultPackages() {
+		base, ok := legacyBaseFares[p.Slug]
+		if !ok {
+			continue
+		}
+		delete(legacyBaseFares, p.Slug)
+
+		total, _ := engine.Price(PricingInput{
+			Package:         p,
+			DistanceKm:      distanceMeters / 1000,
+			DurationMinutes: durationSeconds / 60,
+			PickupAt:        time.Now(),
+		})
+
+		legacy := base + int64(distanceMeters*legacyPerMeter+durationSeconds*legacyPerSecond)
+		assert.Equal(t, usd(legacy), total, p.Slug)
+	}
+
+	assert.Empty(t, legacyBaseFares, "legacy packages missing from the catalog")
+}
@@ -0,0 +1,289 @@
+package domain
+
+import (
+	"time"
+
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+)
+
+// FareLineItem is one named component of a fare, ex: "base fare", "night surcharge"
+type FareLineItem struct {
+	Name   string      `bson:"name" json:"name"`
+	Amount types.Money `bson:"amount" json:"amount"`
+}
+
+func (i FareLineItem) ToProto() *pb.FareLineItem {
+	return &pb.FareLineItem{
+		Name:   i.Name,
+		Amount: i.Amount.ToProto(),
+	}
+}
+
+// PriceComponent is a line item as computed by a pricing rule, in minor units and not rounded yet
+type PriceComponent struct {
+	Name        string
+	AmountMinor float64
+}
+
+// PricingInput is everything the pricing rules can look at
+type PricingInput struct {
+	Package         *PackageModel
+	DistanceKm      float64
+	DurationMinutes float64
+	Pickup          *types.Coordinate
+	// Path is the route geometry, used to detect toll roads
+	Path            []*types.Coordinate
+	PickupAt        time.Time
+	SurgeMultiplier float64
+	// Stops is the number of intermediate stops
+	Stops int
+}
+
+// PricingRule adds at most one line item to a fare.
+// subtotal is the sum, in minor units, of the line items added by the previous rules.
+type PricingRule interface {
+	Apply(in PricingInput, subtotal float64) (PriceComponent, bool)
+}
+
+// PricingRuleFunc adapts a function to a PricingRule
+type PricingRuleFunc func(in PricingInput, subtotal float64) (PriceComponent, bool)
+
+func (f PricingRuleFunc) Apply(in PricingInput, subtotal float64) (PriceComponent, bool) {
+	return f(in, subtotal)
+}
+
+// PricingEngine builds a fare by applying its rules in order
+type PricingEngine struct {
+	currency string
+	rules    []PricingRule
+}
+
+// NewPricingEngine creates an engine pricing fares in currency, the rule amounts
+// are expressed in the minor unit of that currency
+func NewPricingEngine(currency string, rules ...PricingRule) *PricingEngine {
+	return &PricingEngine{currency: currency, rules: rules}
+}
+
+// Price returns the total fare and the line items it is made of.
+// Every line item is rounded here, so the total is always the sum of the line items.
+func (e *PricingEngine) Price(in PricingInput) (types.Money, []FareLineItem) {
+	total := types.NewMoney(0, e.currency)
+	items := make([]FareLineItem, 0, len(e.rules))
+
+	for _, rule := range e.rules {
+		component, ok := rule.Apply(in, float64(total.AmountMinor))
+		if !ok {
+			continue
+		}
+
+		amount := types.RoundMoney(component.AmountMinor, e.currency)
+		if amount.IsZero() {
+			continue
+		}
+
+		items = append(items, FareLineItem{Name: component.Name, Amount: amount})
+		total = total.Add(amount)
+	}
+
+	return total, items
+}
+
+// HourWindow is a daily time range, it may wrap around midnight (ex: 22 to 6)
+type HourWindow struct {
+	StartHour int `json:"startHour"`
+	EndHour   int `json:"endHour"`
+}
+
+func (w HourWindow) Contains(t time.Time) bool {
+	hour := t.Hour()
+	if w.StartHour <= w.EndHour {
+		return hour >= w.StartHour && hour < w.EndHour
+	}
+	return hour >= w.StartHour || hour < w.EndHour
+}
+
+// GeoZone is a circular area, ex: an airport or a toll bridge
+type GeoZone struct {
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	RadiusKm  float64 `json:"radiusKm"`
+	// FeeMinor is charged when the zone applies
+	FeeMinor float64 `json:"feeMinor"`
+}
+
+func (z GeoZone) Contains(c *types.Coordinate) bool {
+	if c == nil {
+		return false
+	}
+	return c.DistanceKm(&types.Coordinate{Latitude: z.Latitude, Longitude: z.Longitude}) <= z.RadiusKm
+}
+
+// PricingConfig holds the tunable values of the default pricing rules,
+// amounts are in the minor unit of Currency
+type PricingConfig struct {
+	Currency string `json:"currency"`
+	// TimeZone is used for the night and peak hours, ex: "Europe/Lisbon"
+	TimeZone              string       `json:"timeZone"`
+	BookingFeeMinor       float64      `json:"bookingFeeMinor"`
+	NightHours            HourWindow   `json:"nightHours"`
+	NightSurchargePercent float64      `json:"nightSurchargePercent"`
+	PeakHours             []HourWindow `json:"peakHours"`
+	PeakSurchargePercent  float64      `json:"peakSurchargePercent"`
+	PeakOnWeekdaysOnly    bool         `json:"peakOnWeekdaysOnly"`
+	Airports              []GeoZone    `json:"airports"`
+	TollZones             []GeoZone    `json:"tollZones"`
+	// StopWaitMinutes is the expected wait at every stop, charged at the package per minute rate
+	StopWaitMinutes float64 `json:"stopWaitMinutes"`
+	StopFeeMinor    float64 `json:"stopFeeMinor"`
+}
+
+func DefaultPricingConfig() PricingConfig {
+	return PricingConfig{
+		Currency:              "USD",
+		TimeZone:              "UTC",
+		BookingFeeMinor:       150,
+		NightHours:            HourWindow{StartHour: 22, EndHour: 6},
+		NightSurchargePercent: 20,
+		PeakHours: []HourWindow{
+			{StartHour: 7, EndHour: 10},
+			{StartHour: 16, EndHour: 19},
+		},
+		PeakSurchargePercent: 15,
+		PeakOnWeekdaysOnly:   true,
+		StopWaitMinutes:      3,
+		StopFeeMinor:         100,
+	}
+}
+
+// NewDefaultPricingEngine builds the standard rule chain:
+// the ride itself, the time-of-day surcharges and surge on the ride,
+// then the flat fees and finally the minimum fare top-up
+func NewDefaultPricingEngine(cfg PricingConfig) (*PricingEngine, error) {
+	location, err := time.LoadLocation(cfg.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPricingEngine(
+		cfg.Currency,
+		BaseFareRule(),
+		DistanceRule(),
+		TimeRule(),
+		StopsRule(cfg.StopWaitMinutes, cfg.StopFeeMinor),
+		NightSurchargeRule(cfg.NightHours, cfg.NightSurchargePercent, location),
+		PeakSurchargeRule(cfg.PeakHours, cfg.PeakSurchargePercent, cfg.PeakOnWeekdaysOnly, location),
+		SurgeRule(),
+		TollsRule(cfg.TollZones),
+		AirportPickupRule(cfg.Airports),
+		BookingFeeRule(cfg.BookingFeeMinor),
+		MinimumFareRule(),
+	), nil
+}
+
+func BaseFareRule() PricingRule {
+	return PricingRuleFunc(func(in PricingInput, subtotal float64) (PriceComponent, bool) {
+		return PriceComponent{Name: "base fare", AmountMinor: in.Package.BaseFareMinor}, true
+	})
+}
+
+func DistanceRule() PricingRule {
+	return PricingRuleFunc(func(in PricingInput, subtotal float64) (PriceComponent, bool) {
+		return PriceComponent{Name: "distance", AmountMinor: in.DistanceKm * in.Package.PerKmMinor}, true
+	})
+}
+
+func TimeRule() PricingRule {
+	return PricingRuleFunc(func(in PricingInput, subtotal float64) (PriceComponent, bool) {
+		return PriceComponent{Name: "time", AmountMinor: in.DurationMinutes * in.Package.PerMinuteMinor}, true
+	})
+}
+
+// StopsRule charges the expected wait and a flat fee for every intermediate stop
+func StopsRule(waitMinutes, feeMinor float64) PricingRule {
+	return PricingRuleFunc(func(in PricingInput, subtotal float64) (PriceComponent, bool) {
+		if in.Stops == 0 {
+			return PriceComponent{}, false
+		}
+		perStop := waitMinutes*in.Package.PerMinuteMinor + feeMinor
+		return PriceComponent{Name: "stops", AmountMinor: float64(in.Stops) * perStop}, true
+	})
+}
+
+func NightSurchargeRule(hours HourWindow, percent float64, location *time.Location) PricingRule {
+	return PricingRuleFunc(func(in PricingInput, subtotal float64) (PriceComponent, bool) {
+		if !hours.Contains(in.PickupAt.In(location)) {
+			return PriceComponent{}, false
+		}
+		return PriceComponent{Name: "night surcharge", AmountMinor: subtotal * percent / 100}, true
+	})
+}
+
+func PeakSurchargeRule(hours []HourWindow, percent float64, weekdaysOnly bool, location *time.Location) PricingRule {
+	return PricingRuleFunc(func(in PricingInput, subtotal float64) (PriceComponent, bool) {
+		pickupAt := in.PickupAt.In(location)
+		if weekdaysOnly && (pickupAt.Weekday() == time.Saturday || pickupAt.Weekday() == time.Sunday) {
+			return PriceComponent{}, false
+		}
+
+		for _, w := range hours {
+			if w.Contains(pickupAt) {
+				return PriceComponent{Name: "peak surcharge", AmountMinor: subtotal * percent / 100}, true
+			}
+		}
+		return PriceComponent{}, false
+	})
+}
+
+func SurgeRule() PricingRule {
+	return PricingRuleFunc(func(in PricingInput, subtotal float64) (PriceComponent, bool) {
+		if in.SurgeMultiplier <= 1 {
+			return PriceComponent{}, false
+		}
+		return PriceComponent{Name: "surge", AmountMinor: subtotal * (in.SurgeMultiplier - 1)}, true
+	})
+}
+
+// TollsRule charges every toll zone the route goes through, once
+func TollsRule(zones []GeoZone) PricingRule {
+	return PricingRuleFunc(func(in PricingInput, subtotal float64) (PriceComponent, bool) {
+		var tolls float64
+		for _, zone := range zones {
+			for _, c := range in.Path {
+				if zone.Contains(c) {
+					tolls += zone.FeeMinor
+					break
+				}
+			}
+		}
+		return PriceComponent{Name: "tolls", AmountMinor: tolls}, tolls > 0
+	})
+}
+
+func AirportPickupRule(airports []GeoZone) PricingRule {
+	return PricingRuleFunc(func(in PricingInput, subtotal float64) (PriceComponent, bool) {
+		for _, airport := range airports {
+			if airport.Contains(in.Pickup) {
+				return PriceComponent{Name: "airport pickup", AmountMinor: airport.FeeMinor}, true
+			}
+		}
+		return PriceComponent{}, false
+	})
+}
+
+func BookingFeeRule(feeMinor float64) PricingRule {
+	return PricingRuleFunc(func(in PricingInput, subtotal float64) (PriceComponent, bool) {
+		return PriceComponent{Name: "booking fee", AmountMinor: feeMinor}, true
+	})
+}
+
+// MinimumFareRule tops the fare up to the package minimum fare
+func MinimumFareRule() PricingRule {
+	return PricingRuleFunc(func(in PricingInput, subtotal float64) (PriceComponent, bool) {
+		if subtotal >= in.Package.MinimumFareMinor {
+			return PriceComponent{}, false
+		}
+		return PriceComponent{Name: "minimum fare", AmountMinor: in.Package.MinimumFareMinor - subtotal}, true
+	})
+}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultPricingEngine(t *testing.T) {
+	cfg := DefaultPricingConfig()
+	cfg.Airports = []GeoZone{
+		{Name: "LIS", Latitude: 38.7742, Longitude: -9.1342, RadiusKm: 2, FeeMinor: 300},
+	}
+
+	engine, err := NewDefaultPricingEngine(cfg)
+	require.NoError(t, err)
+
+	sedan := &PackageModel{
+		Slug:             "sedan",
+		BaseFareMinor:    350,
+		PerKmMinor:       150,
+		PerMinuteMinor:   25,
+		MinimumFareMinor: 700,
+	}
+	// A Sunday, outside of the peak and night hours
+	noon := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	downtown := &types.Coordinate{Latitude: 38.7223, Longitude: -9.1393}
+
+	t.Run("regular_ride", func(t *testing.T) {
+		total, items := engine.Price(PricingInput{
+			Package:         sedan,
+			DistanceKm:      10,
+			DurationMinutes: 20,
+			Pickup:          downtown,
+			PickupAt:        noon,
+		})
+
+		assert.Equal(t, []FareLineItem{
+			{Name: "base fare", Amount: usd(350)},
+			{Name: "distance", Amount: usd(1500)},
+			{Name: "time", Amount: usd(500)},
+			{Name: "booking fee", Amount: usd(150)},
+		}, items)
+		assert.Equal(t, usd(2500), total)
+	})
+
+	t.Run("night_airport_surge", func(t *testing.T) {
+		total, items := engine.Price(PricingInput{
+			Package:         sedan,
+			DistanceKm:      10,
+			DurationMinutes: 20,
+			Pickup:          &types.Coordinate{Latitude: 38.7750, Longitude: -9.1350},
+			PickupAt:        time.Date(2025, 6, 1, 23, 0, 0, 0, time.UTC),
+			SurgeMultiplier: 1.5,
+		})
+
+		assert.Equal(t, []FareLineItem{
+			{Name: "base fare", Amount: usd(350)},
+			{Name: "distance", Amount: usd(1500)},
+			{Name: "time", Amount: usd(500)},
+			{Name: "night surcharge", Amount: usd(470)},
+			{Name: "surge", Amount: usd(1410)},
+			{Name: "airport pickup", Amount: usd(300)},
+			{Name: "booking fee", Amount: usd(150)},
+		}, items)
+		assert.Equal(t, usd(4680), total)
+	})
+
+	t.Run("stops", func(t *testing.T) {
+		total, items := engine.Price(PricingInput{
+			Package:         sedan,
+			DistanceKm:      10,
+			DurationMinutes: 20,
+			Pickup:          downtown,
+			PickupAt:        noon,
+			Stops:           2,
+		})
+
+		// 2 stops * (3 minutes * 25 + 100)
+		assert.Contains(t, items, FareLineItem{Name: "stops", Amount: usd(350)})
+		assert.Equal(t, usd(2850), total)
+	})
+
+	t.Run("minimum_fare", func(t *testing.T) {
+		total, items := engine.Price(PricingInput{
+			Package:         sedan,
+			DistanceKm:      0.5,
+			DurationMinutes: 1,
+			Pickup:          downtown,
+			PickupAt:        noon,
+		})
+
+		assert.Equal(t, FareLineItem{Name: "minimum fare", Amount: usd(100)}, items[len(items)-1])
+		assert.Equal(t, usd(700), total)
+	})
+}
+
+func usd(amountMinor int64) types.Money {
+	return types.NewMoney(amountMinor, "USD")
+}
+
+func TestHourWindowContains(t *testing.T) {
+	night := HourWindow{StartHour: 22, EndHour: 6}
+	assert.True(t, night.Contains(time.Date(2025, 1, 1, 23, 0, 0, 0, time.UTC)))
+	assert.True(t, night.Contains(time.Date(2025, 1, 1, 5, 59, 0, 0, time.UTC)))
+	assert.False(t, night.Contains(time.Date(2025, 1, 1, 6, 0, 0, 0, time.UTC)))
+	assert.False(t, night.Contains(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)))
+}
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrPromotionNotFound      = fmt.Errorf("promotion code %w", ErrNotFound)
+	ErrPromotionExpired       = errors.New("promotion code has expired")
+	ErrPromotionNotApplicable = errors.New("promotion code does not apply to this trip")
+	// ErrPromotionExhausted is returned once the global or the per-user usage limit is reached
+	ErrPromotionExhausted = errors.New("promotion code has reached its usage limit")
+)
+
+type DiscountType string
+
+const (
+	DiscountTypePercent DiscountType = "percent"
+	DiscountTypeFixed   DiscountType = "fixed"
+)
+
+// PromotionModel is a discount code. Codes without UserID are global,
+// the others can only be used by that user.
+type PromotionModel struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	Code         string             `bson:"code"`
+	UserID       string             `bson:"userID"`
+	DiscountType DiscountType       `bson:"discountType"`
+	PercentOff   float64            `bson:"percentOff"`  // for percent discounts, ex: 20 for 20%
+	AmountOff    types.Money        `bson:"amountOff"`   // for fixed discounts
+	MaxDiscount  types.Money        `bson:"maxDiscount"` // caps percent discounts, zero means no cap
+	// UsageLimit is the total number of redemptions, zero means unlimited
+	UsageLimit int64 `bson:"usageLimit"`
+	// PerUserLimit is the number of redemptions per user, zero means unlimited
+	PerUserLimit int64 `bson:"perUserLimit"`
+	// PackageSlugs restricts the code to some packages, empty means every package
+	PackageSlugs []string  `bson:"packageSlugs"`
+	StartsAt     time.Time `bson:"startsAt"`
+	ExpiresAt    time.Time `bson:"expiresAt"`
+	Redemptions  int64     `bson:"redemptions"`
+	// UserRedemptions is the number of redemptions of the user the code was looked up for,
+	// the repositories keep it apart from the promotion
+	UserRedemptions int64 `bson:"-"`
+}
+
+// NormalizePromoCode makes codes case and whitespace insensitive
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate checks that the user can redeem the code at the given time.
+// Usage limits are only a hint here, they are enforced atomically by RedeemPromotion.
+func (p *PromotionModel) Validate(userID string, at time.Time) error {
+	if p.UserID != "" && p.UserID != userID {
+		return ErrPromotionNotFound
+	}
+
+	if at.Before(p.StartsAt) {
+		return ErrPromotionNotApplicable
+	}
+
+	if !p.ExpiresAt.IsZero() && at.After(p.ExpiresAt) {
+		return ErrPromotionExpired
+	}
+
+	if p.UsageLimit > 0 && p.Redemptions >= p.UsageLimit {
+		return ErrPromotionExhausted
+	}
+
+	if p.PerUserLimit > 0 && p.UserRedemptions >= p.PerUserLimit {
+		return ErrPromotionExhausted
+	}
+
+	return nil
+}
+
+// AppliesTo reports whether the code can be used for a package
+func (p *PromotionModel) AppliesTo(packageSlug string) bool {
+	if len(p.PackageSlugs) == 0 {
+		return true
+	}
+	for _, slug := range p.PackageSlugs {
+		if slug == packageSlug {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscountFor returns the discount on a fare, it never exceeds the fare itself.
+// Fixed amounts and caps only apply to fares of their own currency.
+func (p *PromotionModel) DiscountFor(fare types.Money) types.Money {
+	var discount types.Money
+
+	switch p.DiscountType {
+	case DiscountTypePercent:
+		discount = types.RoundMoney(float64(fare.AmountMinor)*p.PercentOff/100, fare.Currency)
+		if !p.MaxDiscount.IsZero() {
+			// A cap in another currency can't be honored
+			if !p.MaxDiscount.SameCurrency(fare) {
+				return types.NewMoney(0, fare.Currency)
+			}
+			if discount.AmountMinor > p.MaxDiscount.AmountMinor {
+				discount.AmountMinor = p.MaxDiscount.AmountMinor
+			}
+		}
+	case DiscountTypeFixed:
+		if !p.AmountOff.SameCurrency(fare) {
+			return types.NewMoney(0, fare.Currency)
+		}
+		discount = types.NewMoney(p.AmountOff.AmountMinor, fare.Currency)
+	default:
+		return types.NewMoney(0, fare.Currency)
+	}
+
+	if discount.AmountMinor > fare.AmountMinor {
+		discount.AmountMinor = fare.AmountMinor
+	}
+	if discount.AmountMinor < 0 {
+		discount.AmountMinor = 0
+	}
+
+	return discount
+}
+
+type PromotionRepository interface {
+	// GetPromotionByCode returns the user's own code first, then the global one
+	GetPromotionByCode(ctx context.Context, code, userID string) (*PromotionModel, error)
+	// RedeemPromotion atomically counts one redemption, it returns ErrPromotionExhausted
+	// instead when the usage limits are reached
+	RedeemPromotion(ctx context.Context, id primitive.ObjectID, userID string) error
+	// ReleasePromotion gives back a redemption when the trip could not be created
+	ReleasePromotion(ctx context.Context, id primitive.ObjectID, userID string) error
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromotionDiscountFor(t *testing.T) {
+	fare := types.NewMoney(2000, "USD")
+
+	tests := []struct {
+		name      string
+		promotion PromotionModel
+		expected  int64
+	}{
+		{"percent", PromotionModel{DiscountType: DiscountTypePercent, PercentOff: 15}, 300},
+		{"percent_capped", PromotionModel{DiscountType: DiscountTypePercent, PercentOff: 50, MaxDiscount: types.NewMoney(500, "USD")}, 500},
+		{"fixed", PromotionModel{DiscountType: DiscountTypeFixed, AmountOff: types.NewMoney(750, "USD")}, 750},
+		{"fixed_above_fare", PromotionModel{DiscountType: DiscountTypeFixed, AmountOff: types.NewMoney(5000, "USD")}, 2000},
+		{"fixed_other_currency", PromotionModel{DiscountType: DiscountTypeFixed, AmountOff: types.NewMoney(750, "EUR")}, 0},
+		{"cap_other_currency", PromotionModel{DiscountType: DiscountTypePercent, PercentOff: 50, MaxDiscount: types.NewMoney(500, "EUR")}, 0},
+		{"unknown_type", PromotionModel{DiscountType: "bogus", PercentOff: 15}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discount := tt.promotion.DiscountFor(fare)
+			assert.Equal(t, tt.expected, discount.AmountMinor)
+			assert.Equal(t, "USD", discount.Currency)
+		})
+	}
+}
+
+func TestPromotionValidate(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		promotion PromotionModel
+		userID    string
+		expected  error
+	}{
+		{"valid", PromotionModel{StartsAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)}, "u1", nil},
+		{"other_user", PromotionModel{UserID: "u2"}, "u1", ErrPromotionNotFound},
+		{"not_started", PromotionModel{StartsAt: now.Add(time.Hour)}, "u1", ErrPromotionNotApplicable},
+		{"expired", PromotionModel{ExpiresAt: now.Add(-time.Hour)}, "u1", ErrPromotionExpired},
+		{"usage_limit", PromotionModel{UsageLimit: 10, Redemptions: 10}, "u1", ErrPromotionExhausted},
+		{"per_user_limit", PromotionModel{PerUserLimit: 1, UserRedemptions: 1}, "u1", ErrPromotionExhausted},
+		{"per_user_limit_not_reached", PromotionModel{PerUserLimit: 2, UserRedemptions: 1}, "u1", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.promotion.Validate(tt.userID, now))
+		})
+	}
+}
+
+func TestRideFareApplyDiscount(t *testing.T) {
+	fare := &RideFareModel{
+		TotalPrice: types.NewMoney(2000, "USD"),
+		LineItems:  []FareLineItem{{Name: "base fare", Amount: types.NewMoney(2000, "USD")}},
+	}
+
+	fare.ApplyDiscount(&PromotionModel{Code: "SAVE5", DiscountType: DiscountTypeFixed, AmountOff: types.NewMoney(500, "USD")})
+
+	assert.Equal(t, int64(1500), fare.TotalPrice.AmountMinor)
+	assert.Equal(t, int64(500), fare.Discount.AmountMinor)
+	assert.Equal(t, "SAVE5", fare.PromoCode)
+
+	// The total stays the sum of the line items
+	var sum int64
+	for _, item := range fare.LineItems {
+		sum += item.Amount.AmountMinor
+	}
+	assert.Equal(t, fare.TotalPrice.AmountMinor, sum)
+}
@@ -1,27 +1,101 @@
 package domain
 
 import (
-	"github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
+	"errors"
+	"fmt"
+	tripTypes "github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
 	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	ErrFareNotFound = fmt.Errorf("fare %w", ErrNotFound)
+	ErrFareNotOwned = errors.New("fare does not belong to the user")
+	ErrFareExpired  = errors.New("fare has expired")
+	ErrFareConsumed = errors.New("fare has already been used")
 )
 
 type RideFareModel struct {
-	ID                primitive.ObjectID     `bson:"_id,omitempty"`
-	UserID            string                 `bson:"userID"`
-	PackageSlug       string                 `bson:"packageSlug"` // ex: van, luxury, sedan
-	TotalPriceInCents float64                `bson:"totalPriceInCents"`
-	Route             *types.OsrmApiResponse `bson:"route"`
+	ID              primitive.ObjectID         `bson:"_id,omitempty"`
+	UserID          string                     `bson:"userID"`
+	PackageSlug     string                     `bson:"packageSlug"` // ex: van, luxury, sedan
+	TotalPrice      types.Money                `bson:"totalPrice"`
+	SurgeMultiplier float64                    `bson:"surgeMultiplier"` // already applied to TotalPrice
+	SurgeCell       string                     `bson:"surgeCell"`       // geohash cell of the pickup
+	LineItems       []FareLineItem             `bson:"lineItems"`
+	Route           *tripTypes.OsrmApiResponse `bson:"route"`
+	CreatedAt       time.Time                  `bson:"createdAt"`
+	ExpiresAt       time.Time                  `bson:"expiresAt"`
+	// ConsumedAt is set once a trip has been created from this fare
+	ConsumedAt *time.Time `bson:"consumedAt,omitempty"`
+	// Discount is already deducted from TotalPrice, it is zero without a promotion
+	Discount    types.Money         `bson:"discount"`
+	PromotionID *primitive.ObjectID `bson:"promotionID,omitempty"`
+	PromoCode   string              `bson:"promoCode,omitempty"`
+	// ScheduledFor is the pickup time the fare was quoted for, nil for an immediate ride
+	ScheduledFor *time.Time `bson:"scheduledFor,omitempty"`
+	// Stops are the intermediate stops the fare was quoted for
+	Stops []*types.Coordinate `bson:"stops,omitempty"`
+	// ZoneID is the service zone the fare was priced in
+	ZoneID string `bson:"zoneID,omitempty"`
+	// Pickup is where the rider asked to be picked up, the route starts
+	// on the road nearest to it
+	Pickup *types.Coordinate `bson:"pickup,omitempty"`
+}
+
+// ApplyDiscount deducts a promotion from the fare, as a negative line item
+// so the total stays the sum of the line items
+func (r *RideFareModel) ApplyDiscount(promotion *PromotionModel) {
+	discount := promotion.DiscountFor(r.TotalPrice)
+
+	r.Discount = discount
+	r.PromotionID = &promotion.ID
+	r.PromoCode = promotion.Code
+
+	if discount.IsZero() {
+		return
+	}
+
+	r.LineItems = append(r.LineItems, FareLineItem{
+		Name:   "promotion",
+		Amount: types.NewMoney(-discount.AmountMinor, discount.Currency),
+	})
+	r.TotalPrice = r.TotalPrice.Sub(discount)
+}
+
+// IsExpired reports whether the quote can no longer be used to start a trip
+func (r *RideFareModel) IsExpired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && now.After(r.ExpiresAt)
 }
 
 func (r *RideFareModel) ToProto() *pb.RideFare {
-	return &pb.RideFare{
-		Id:                r.ID.Hex(),
-		UserID:            r.UserID,
-		PackageSlug:       r.PackageSlug,
-		TotalPriceInCents: r.TotalPriceInCents,
+	lineItems := make([]*pb.FareLineItem, len(r.LineItems))
+	for i, item := range r.LineItems {
+		lineItems[i] = item.ToProto()
+	}
+
+	fare := &pb.RideFare{
+		Id:              r.ID.Hex(),
+		UserID:          r.UserID,
+		PackageSlug:     r.PackageSlug,
+		TotalPrice:      r.TotalPrice.ToProto(),
+		SurgeMultiplier: r.SurgeMultiplier,
+		LineItems:       lineItems,
+		ExpiresAt:       timestamppb.New(r.ExpiresAt),
+		Discount:        r.Discount.ToProto(),
+		PromoCode:       r.PromoCode,
+	}
+	if r.ScheduledFor != nil {
+		fare.ScheduledFor = timestamppb.New(*r.ScheduledFor)
+	}
+	if r.Pickup != nil {
+		fare.Pickup = &pb.Coordinate{Latitude: r.Pickup.Latitude, Longitude: r.Pickup.Longitude}
 	}
+	return fare
 }
 
 func ToRideFaresProto(fares []*RideFareModel) []*pb.RideFare {
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"testing"
+
+	tripTypes "github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRideFarePickup(t *testing.T) {
+	// The route starts on the road nearest to the requested pickup
+	route := &tripTypes.OsrmApiResponse{Routes: []tripTypes.OsrmRoute{{}}}
+	route.Routes[0].Geometry.Coordinates = [][]float64{{-9.1402, 38.7205}, {-9.15, 38.73}}
+	pickup := &types.Coordinate{Latitude: 38.72, Longitude: -9.14}
+
+	fare := &RideFareModel{Route: route, Pickup: pickup}
+	require.NotNil(t, fare.ToProto().Pickup)
+	assert.Equal(t, pickup.Latitude, fare.ToProto().Pickup.Latitude)
+	assert.Equal(t, pickup.Longitude, fare.ToProto().Pickup.Longitude)
+
+	trip := &TripModel{RideFare: fare, Pickup: fare.Pickup}
+	require.NotNil(t, trip.ToProto().Pickup)
+	assert.Equal(t, pickup.Latitude, trip.ToProto().Pickup.Latitude)
+	assert.Equal(t, pickup.Longitude, trip.ToProto().Pickup.Longitude)
+
+	assert.Nil(t, (&RideFareModel{}).ToProto().Pickup)
+	assert.Nil(t, (&TripModel{RideFare: &RideFareModel{Route: route}}).ToProto().Pickup)
+}
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"context"
+	"errors"
+
+	tripTypes "github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+)
+
+// ErrRouteNotFound is returned when a provider has no route between the waypoints
+var ErrRouteNotFound = errors.New("route not found")
+
+// RouteProvider computes driving routes. Routes follow the waypoints in order
+// and have one leg per pair of consecutive waypoints.
+type RouteProvider interface {
+	Route(ctx context.Context, waypoints []*types.Coordinate) (*tripTypes.OsrmApiResponse, error)
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrInvalidSchedule is returned when a pickup time can't be booked
+	ErrInvalidSchedule = errors.New("invalid pickup time")
+	// ErrDispatchLeaseLost is returned when another claim took over the dispatch of a trip
+	ErrDispatchLeaseLost = errors.New("dispatch lease of the trip was lost")
+)
+
+// SchedulePolicy decides when trips can be booked for and when they are dispatched
+type SchedulePolicy struct {
+	// MinLead is how far in the future a scheduled pickup must be at least
+	MinLead time.Duration
+	// MaxAhead is how far in the future a pickup can be booked
+	MaxAhead time.Duration
+	// DispatchLead is how long before the pickup the driver search starts
+	DispatchLead time.Duration
+	// DispatchLease is how long a scheduler replica owns a trip it is dispatching,
+	// another replica retries the trip once the lease expires
+	DispatchLease time.Duration
+}
+
+func DefaultSchedulePolicy() SchedulePolicy {
+	return SchedulePolicy{
+		MinLead:       30 * time.Minute,
+		MaxAhead:      30 * 24 * time.Hour,
+		DispatchLead:  15 * time.Minute,
+		DispatchLease: 30 * time.Second,
+	}
+}
+
+// Validate checks that a pickup can be booked at scheduledFor
+func (p SchedulePolicy) Validate(scheduledFor, now time.Time) error {
+	if scheduledFor.Before(now.Add(p.MinLead)) {
+		return fmt.Errorf("%w: pickups must be scheduled at least %s in advance", ErrInvalidSchedule, p.MinLead)
+	}
+
+	if scheduledFor.After(now.Add(p.MaxAhead)) {
+		return fmt.Errorf("%w: pickups can't be scheduled more than %s in advance", ErrInvalidSchedule, p.MaxAhead)
+	}
+
+	return nil
+}
+
+// DueBefore returns the pickup time before which scheduled trips must be dispatched
+func (p SchedulePolicy) DueBefore(now time.Time) time.Time {
+	return now.Add(p.DispatchLead)
+}
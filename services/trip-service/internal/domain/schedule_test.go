@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulePolicyValidate(t *testing.T) {
+	policy := DefaultSchedulePolicy()
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		scheduledFor time.Time
+		valid        bool
+	}{
+		{"in_an_hour", now.Add(time.Hour), true},
+		{"too_soon", now.Add(5 * time.Minute), false},
+		{"in_the_past", now.Add(-time.Hour), false},
+		{"too_far", now.Add(60 * 24 * time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.scheduledFor, now)
+			assert.Equal(t, tt.valid, err == nil)
+			if err != nil {
+				assert.True(t, errors.Is(err, ErrInvalidSchedule))
+			}
+		})
+	}
+}
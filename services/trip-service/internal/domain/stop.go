@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MaxTripStops is the number of intermediate stops a trip can have
+const MaxTripStops = 5
+
+var (
+	ErrTooManyStops = fmt.Errorf("a trip can't have more than %d stops", MaxTripStops)
+	// ErrInvalidStop is returned when a stop is reached out of order or while the trip isn't being driven
+	ErrInvalidStop = errors.New("stop can't be reached")
+)
+
+// TripStop is an intermediate stop of a trip, between the pickup and the destination
+type TripStop struct {
+	Location  *types.Coordinate `bson:"location"`
+	ReachedAt *time.Time        `bson:"reachedAt,omitempty"`
+}
+
+func (s *TripStop) ToProto() *pb.TripStop {
+	stop := &pb.TripStop{
+		Location: &pb.Coordinate{
+			Latitude:  s.Location.Latitude,
+			Longitude: s.Location.Longitude,
+		},
+	}
+	if s.ReachedAt != nil {
+		stop.ReachedAt = timestamppb.New(*s.ReachedAt)
+	}
+	return stop
+}
+
+// NewTripStops creates the stops of a trip from the locations quoted in its fare
+func NewTripStops(locations []*types.Coordinate) []*TripStop {
+	stops := make([]*TripStop, len(locations))
+	for i, location := range locations {
+		stops[i] = &TripStop{Location: location}
+	}
+	return stops
+}
+
+// stopStatuses are the statuses in which a driver is on the way and can reach stops.
+// Payment is collected as soon as a driver is assigned, so paid trips can still be driven.
+var stopStatuses = []TripStatus{
+	TripStatusDriverAssigned,
+	TripStatusDriverArrived,
+	TripStatusInProgress,
+	TripStatusPaid,
+}
+
+// StopStatuses returns the statuses in which stops can be reached,
+// repositories use it to make the stop update conditional
+func StopStatuses() []TripStatus {
+	return stopStatuses
+}
+
+// CanReachStop checks that stop is the next stop of the trip and that the trip is being driven
+func (t *TripModel) CanReachStop(stop int) error {
+	if stop < 0 || stop >= len(t.Stops) {
+		return fmt.Errorf("%w: trip %s has no stop %d", ErrInvalidStop, t.ID.Hex(), stop)
+	}
+
+	if stop != t.CurrentLeg {
+		return fmt.Errorf("%w: trip %s is on leg %d, not %d", ErrInvalidStop, t.ID.Hex(), t.CurrentLeg, stop)
+	}
+
+	for _, s := range stopStatuses {
+		if t.Status == s {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: trip %s is %s", ErrInvalidStop, t.ID.Hex(), t.Status)
+}
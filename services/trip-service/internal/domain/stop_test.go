@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTripCanReachStop(t *testing.T) {
+	stops := NewTripStops([]*types.Coordinate{
+		{Latitude: 38.72, Longitude: -9.14},
+		{Latitude: 38.73, Longitude: -9.15},
+	})
+
+	tests := []struct {
+		name   string
+		status TripStatus
+		leg    int
+		stop   int
+		valid  bool
+	}{
+		{"first_stop", TripStatusDriverAssigned, 0, 0, true},
+		{"second_stop_once_paid", TripStatusPaid, 1, 1, true},
+		{"skipping_a_stop", TripStatusInProgress, 0, 1, false},
+		{"already_reached", TripStatusInProgress, 1, 0, false},
+		{"past_the_last_stop", TripStatusInProgress, 2, 2, false},
+		{"no_driver_yet", TripStatusPending, 0, 0, false},
+		{"cancelled", TripStatusCancelled, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trip := &TripModel{Status: tt.status, Stops: stops, CurrentLeg: tt.leg}
+			err := trip.CanReachStop(tt.stop)
+			assert.Equal(t, tt.valid, err == nil)
+			if err != nil {
+				assert.True(t, errors.Is(err, ErrInvalidStop))
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/mmcloughlin/geohash"
+)
+
+// SurgeCellPrecision is the geohash precision of a surge cell (~5km x 5km)
+const SurgeCellPrecision = 5
+
+// SurgeCell returns the geohash cell used to aggregate demand around a pickup location
+func SurgeCell(latitude, longitude float64) string {
+	return geohash.EncodeWithPrecision(latitude, longitude, SurgeCellPrecision)
+}
+
+// SurgePricer tracks demand per package and cell and turns it into a price multiplier
+type SurgePricer interface {
+	// Multiplier returns the current surge multiplier for a package in a cell, 1 means no surge
+	Multiplier(ctx context.Context, packageSlug, cell string) (float64, error)
+	// RecordDemand counts a trip request in a cell, unfulfilled requests
+	// (no driver was found) weigh more than regular ones
+	RecordDemand(ctx context.Context, packageSlug, cell string, unfulfilled bool) error
+}
+
+// SurgeConfig controls how demand and supply are turned into a multiplier
+type SurgeConfig struct {
+	// DemandWindow is the period over which trip requests are counted
+	DemandWindow time.Duration
+	// UpdateInterval is how long a multiplier is kept before being recomputed
+	UpdateInterval time.Duration
+	// Sensitivity is how much the multiplier grows per request above the available supply
+	Sensitivity float64
+	// UnfulfilledWeight is how many regular requests an unfulfilled request counts for
+	UnfulfilledWeight float64
+	// Smoothing is the weight of the newest value in the moving average, between 0 and 1
+	Smoothing     float64
+	MaxMultiplier float64
+}
+
+func DefaultSurgeConfig() SurgeConfig {
+	return SurgeConfig{
+		DemandWindow:      5 * time.Minute,
+		UpdateInterval:    30 * time.Second,
+		Sensitivity:       0.5,
+		UnfulfilledWeight: 2,
+		Smoothing:         0.3,
+		MaxMultiplier:     3,
+	}
+}
+
+// Target returns the multiplier matching the current demand and supply, before smoothing
+func (c SurgeConfig) Target(demand, unfulfilled float64, supply int64) float64 {
+	pressure := (demand + c.UnfulfilledWeight*unfulfilled) / math.Max(float64(supply), 1)
+	if pressure <= 1 {
+		return 1
+	}
+
+	return math.Min(1+c.Sensitivity*(pressure-1), c.MaxMultiplier)
+}
+
+// Smooth moves the previous multiplier towards target (exponential moving average),
+// so prices don't jump on a single burst of requests
+func (c SurgeConfig) Smooth(previous, target float64) float64 {
+	if previous < 1 {
+		previous = 1
+	}
+
+	smoothed := previous + c.Smoothing*(target-previous)
+	// Riders are shown the multiplier, keep it to one decimal
+	smoothed = math.Round(smoothed*10) / 10
+
+	return math.Max(1, math.Min(smoothed, c.MaxMultiplier))
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSurgeConfigTarget(t *testing.T) {
+	cfg := DefaultSurgeConfig()
+
+	tests := []struct {
+		name        string
+		demand      float64
+		unfulfilled float64
+		supply      int64
+		expected    float64
+	}{
+		{"no_demand", 0, 0, 10, 1},
+		{"demand_below_supply", 5, 0, 10, 1},
+		{"demand_above_supply", 20, 0, 10, 1.5},
+		{"unfulfilled_weighs_more", 10, 5, 10, 1.5},
+		{"no_supply", 3, 0, 0, 2},
+		{"capped", 100, 0, 1, cfg.MaxMultiplier},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, cfg.Target(tt.demand, tt.unfulfilled, tt.supply), 0.001)
+		})
+	}
+}
+
+func TestSurgeConfigSmooth(t *testing.T) {
+	cfg := DefaultSurgeConfig()
+
+	// A burst of demand only moves the price part of the way
+	assert.Equal(t, 1.6, cfg.Smooth(0, 3))
+	assert.Equal(t, 2.0, cfg.Smooth(1.6, 3))
+	// and it fades back gradually
+	assert.Equal(t, 1.7, cfg.Smooth(2, 1))
+	assert.Equal(t, 1.0, cfg.Smooth(1, 1))
+}
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TimelineEventType is what happened to a trip in a timeline entry
+type TimelineEventType string
+
+const (
+	TimelineStatusChanged         TimelineEventType = "status_changed"
+	TimelineDriverOffered         TimelineEventType = "driver_offered"
+	TimelineDriverDeclined        TimelineEventType = "driver_declined"
+	TimelineStopReached           TimelineEventType = "stop_reached"
+	TimelinePaymentSessionCreated TimelineEventType = "payment_session_created"
+	TimelinePaymentSucceeded      TimelineEventType = "payment_succeeded"
+)
+
+// Actor types, the actor ID is the user or driver ID, or the name of the service
+const (
+	ActorRider  = "rider"
+	ActorDriver = "driver"
+	ActorSystem = "system"
+)
+
+// TimelineActor is who caused a timeline entry
+type TimelineActor struct {
+	Type string `bson:"type"`
+	ID   string `bson:"id"`
+}
+
+func RiderActor(userID string) TimelineActor {
+	return TimelineActor{Type: ActorRider, ID: userID}
+}
+
+func DriverActor(driverID string) TimelineActor {
+	return TimelineActor{Type: ActorDriver, ID: driverID}
+}
+
+func SystemActor(service string) TimelineActor {
+	return TimelineActor{Type: ActorSystem, ID: service}
+}
+
+// TimelineEntry is one event in the history of a trip, entries are never updated
+type TimelineEntry struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty"`
+	TripID string             `bson:"tripID"`
+	Type   TimelineEventType  `bson:"type"`
+	At     time.Time          `bson:"at"`
+	Actor  TimelineActor      `bson:"actor"`
+	Reason string             `bson:"reason,omitempty"`
+	// From and To are the statuses of a status change
+	From TripStatus `bson:"from,omitempty"`
+	To   TripStatus `bson:"to,omitempty"`
+	// DriverID is the driver a trip was offered to
+	DriverID string `bson:"driverID,omitempty"`
+	// EventID identifies the message an entry was recorded from,
+	// an entry is recorded once per event of a trip when it is set
+	EventID string `bson:"eventID,omitempty"`
+}
+
+// NewStatusChange records a trip moving from one status to another
+func NewStatusChange(tripID string, from, to TripStatus, actor TimelineActor, reason string) *TimelineEntry {
+	return &TimelineEntry{
+		TripID: tripID,
+		Type:   TimelineStatusChanged,
+		At:     time.Now(),
+		Actor:  actor,
+		Reason: reason,
+		From:   from,
+		To:     to,
+	}
+}
+
+// NewTimelineEntry records an event that doesn't change the trip status
+func NewTimelineEntry(tripID string, eventType TimelineEventType, actor TimelineActor, reason string) *TimelineEntry {
+	return &TimelineEntry{
+		TripID: tripID,
+		Type:   eventType,
+		At:     time.Now(),
+		Actor:  actor,
+		Reason: reason,
+	}
+}
+
+func (e *TimelineEntry) ToProto() *pb.TimelineEntry {
+	return &pb.TimelineEntry{
+		Type:       string(e.Type),
+		At:         timestamppb.New(e.At),
+		ActorType:  e.Actor.Type,
+		ActorID:    e.Actor.ID,
+		Reason:     e.Reason,
+		FromStatus: string(e.From),
+		ToStatus:   string(e.To),
+		DriverID:   e.DriverID,
+	}
+}
+
+func ToTimelineProto(entries []*TimelineEntry) []*pb.TimelineEntry {
+	protoEntries := make([]*pb.TimelineEntry, len(entries))
+	for i, e := range entries {
+		protoEntries[i] = e.ToProto()
+	}
+	return protoEntries
+}
+
+type TimelineRepository interface {
+	// AppendTimelineEntry records an entry, an entry whose EventID is already in the timeline is ignored
+	AppendTimelineEntry(ctx context.Context, entry *TimelineEntry) error
+	// GetTripTimeline returns the entries of a trip, oldest first
+	GetTripTimeline(ctx context.Context, tripID string) ([]*TimelineEntry, error)
+}
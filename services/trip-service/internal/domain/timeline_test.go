@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimelineEntryToProto(t *testing.T) {
+	change := NewStatusChange("trip-1", TripStatusPending, TripStatusDriverAssigned, DriverActor("driver-1"), "driver accepted the trip")
+	change.DriverID = "driver-1"
+
+	proto := change.ToProto()
+	assert.Equal(t, string(TimelineStatusChanged), proto.Type)
+	assert.Equal(t, ActorDriver, proto.ActorType)
+	assert.Equal(t, "driver-1", proto.ActorID)
+	assert.Equal(t, string(TripStatusPending), proto.FromStatus)
+	assert.Equal(t, string(TripStatusDriverAssigned), proto.ToStatus)
+	assert.Equal(t, "driver-1", proto.DriverID)
+	assert.Equal(t, change.At.UnixNano(), proto.At.AsTime().UnixNano())
+
+	offer := NewTimelineEntry("trip-1", TimelineDriverOffered, SystemActor("driver-service"), "trip offered to the driver")
+	proto = offer.ToProto()
+	assert.Equal(t, string(TimelineDriverOffered), proto.Type)
+	assert.Equal(t, ActorSystem, proto.ActorType)
+	assert.Empty(t, proto.FromStatus)
+	assert.Empty(t, proto.ToStatus)
+}
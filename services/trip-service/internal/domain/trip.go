@@ -2,46 +2,184 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/Anurag-Mishra22/taxi/shared/types"
+	"time"
 
 	tripTypes "github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
 	pbd "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
 	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	// ErrNotFound is matched by the errors of the repositories for a missing record
+	ErrNotFound     = errors.New("not found")
+	ErrTripNotFound = fmt.Errorf("trip %w", ErrNotFound)
+	ErrTripNotOwned = errors.New("trip does not belong to the user")
+	// ErrUnscopedTripList is returned when listing trips without a rider or a driver
+	ErrUnscopedTripList = errors.New("trips are listed for a rider or a driver")
 )
 
 type TripModel struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty"`
-	UserID   string             `bson:"userID"`
-	Status   string             `bson:"status"`
-	RideFare *RideFareModel     `bson:"rideFare"`
-	Driver   *pb.TripDriver     `bson:"driver"`
+	ID               primitive.ObjectID `bson:"_id,omitempty"`
+	UserID           string             `bson:"userID"`
+	Status           TripStatus         `bson:"status"`
+	RideFare         *RideFareModel     `bson:"rideFare"`
+	Driver           *pb.TripDriver     `bson:"driver"`
+	DriverAssignedAt *time.Time         `bson:"driverAssignedAt,omitempty"`
+	Cancellation     *TripCancellation  `bson:"cancellation,omitempty"`
+	CreatedAt        time.Time          `bson:"createdAt"`
+	// ScheduledFor is the booked pickup time, nil for trips dispatched right away
+	ScheduledFor *time.Time `bson:"scheduledFor,omitempty"`
+	// DispatchedAt is set once the created event of a scheduled trip has been published
+	DispatchedAt *time.Time `bson:"dispatchedAt,omitempty"`
+	// DispatchLeaseUntil is set while a scheduler replica is dispatching the trip
+	DispatchLeaseUntil *time.Time `bson:"dispatchLeaseUntil,omitempty"`
+	// DispatchLeaseID identifies the claim holding the dispatch lease
+	DispatchLeaseID string `bson:"dispatchLeaseID,omitempty"`
+	// Stops are the intermediate stops, in the order they are visited
+	Stops []*TripStop `bson:"stops,omitempty"`
+	// CurrentLeg is the index of the leg being driven, leg i ends at stop i
+	// and the last leg ends at the destination
+	CurrentLeg int `bson:"currentLeg"`
+	// ZoneID is the service zone of the pickup, empty when the service area isn't restricted
+	ZoneID string `bson:"zoneID,omitempty"`
+	// Pickup is where the rider asked to be picked up, drivers are matched around it
+	Pickup *types.Coordinate `bson:"pickup,omitempty"`
+	// PaidAt is when the ride was paid. It is recorded apart from the status,
+	// a trip completed or cancelled before the payment keeps its status.
+	PaidAt *time.Time `bson:"paidAt,omitempty"`
+	// OutboxSequence is the Sequence of the last outbox message of the trip
+	OutboxSequence int64 `bson:"outboxSequence,omitempty" json:"-"`
+}
+
+// TripFilter selects the trips returned by ListTrips, zero-valued fields are ignored
+type TripFilter struct {
+	UserID      string
+	DriverID    string
+	Status      TripStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// AfterID is the pagination cursor: only trips created before the trip with this ID are returned
+	AfterID string
+	Limit   int
+}
+
+// Validate rejects the filters listing the trips of every user,
+// a list is always scoped to a rider or a driver
+func (f TripFilter) Validate() error {
+	if f.UserID == "" && f.DriverID == "" {
+		return ErrUnscopedTripList
+	}
+	return nil
+}
+
+// IsScheduled reports whether the trip was booked for a later pickup
+func (t *TripModel) IsScheduled() bool {
+	return t.ScheduledFor != nil
+}
+
+// HasDriver reports whether a driver has been assigned to the trip
+func (t *TripModel) HasDriver() bool {
+	return t.Driver != nil && t.Driver.Id != ""
 }
 
 func (t *TripModel) ToProto() *pb.Trip {
-	return &pb.Trip{
+	trip := &pb.Trip{
 		Id:           t.ID.Hex(),
 		UserID:       t.UserID,
 		SelectedFare: t.RideFare.ToProto(),
-		Status:       t.Status,
+		Status:       string(t.Status),
 		Driver:       t.Driver,
 		Route:        t.RideFare.Route.ToProto(),
+		CreatedAt:    timestamppb.New(t.CreatedAt),
+	}
+	if t.ScheduledFor != nil {
+		trip.ScheduledFor = timestamppb.New(*t.ScheduledFor)
+	}
+	for _, stop := range t.Stops {
+		trip.Stops = append(trip.Stops, stop.ToProto())
+	}
+	trip.CurrentLeg = int32(t.CurrentLeg)
+	trip.ZoneID = t.ZoneID
+	if t.Pickup != nil {
+		trip.Pickup = &pb.Coordinate{Latitude: t.Pickup.Latitude, Longitude: t.Pickup.Longitude}
+	}
+	if t.PaidAt != nil {
+		trip.PaidAt = timestamppb.New(*t.PaidAt)
+	}
+	return trip
+}
+
+func ToTripsProto(trips []*TripModel) []*pb.Trip {
+	protoTrips := make([]*pb.Trip, len(trips))
+	for i, t := range trips {
+		protoTrips[i] = t.ToProto()
 	}
+	return protoTrips
 }
 
 type TripRepository interface {
+	Transactor
+	TimelineRepository
 	CreateTrip(ctx context.Context, trip *TripModel) (*TripModel, error)
 	SaveRideFare(ctx context.Context, f *RideFareModel) error
 	GetRideFareByID(ctx context.Context, id string) (*RideFareModel, error)
+	// ConsumeRideFare atomically marks a fare as used, it returns ErrFareConsumed
+	// if the fare was already used to create a trip
+	ConsumeRideFare(ctx context.Context, id string) error
+	// ReleaseRideFare undoes ConsumeRideFare, for a fare whose trip could not be created
+	ReleaseRideFare(ctx context.Context, id string) error
 	GetTripByID(ctx context.Context, id string) (*TripModel, error)
-	UpdateTrip(ctx context.Context, tripID string, status string, driver *pbd.Driver) error
+	// ListTrips returns the trips matching the filter, newest first.
+	// It returns ErrUnscopedTripList for a filter without a rider or a driver.
+	ListTrips(ctx context.Context, filter TripFilter) ([]*TripModel, error)
+	// UpdateTrip moves a trip to status, but only while its current status allows it.
+	// It returns an error matching ErrInvalidTransition otherwise.
+	UpdateTrip(ctx context.Context, tripID string, status TripStatus, driver *pbd.Driver) error
+	// CancelTrip moves a trip to the cancelled status, with the same conditional
+	// status check as UpdateTrip
+	CancelTrip(ctx context.Context, tripID string, cancellation *TripCancellation) error
+	// ListScheduledTrips returns the upcoming bookings of a user, soonest pickup first
+	ListScheduledTrips(ctx context.Context, userID string) ([]*TripModel, error)
+	// ClaimDueScheduledTrip atomically picks one scheduled trip with a pickup before dueBefore
+	// that no other replica is dispatching, moves it to pending and leases it until leaseUntil
+	// under a new DispatchLeaseID. It returns nil when there is nothing to dispatch.
+	ClaimDueScheduledTrip(ctx context.Context, dueBefore, leaseUntil time.Time) (*TripModel, error)
+	// MarkTripDispatched records that the created event of a scheduled trip was published,
+	// but only while leaseID still holds the lease. It returns ErrDispatchLeaseLost otherwise.
+	MarkTripDispatched(ctx context.Context, tripID, leaseID string) error
+	// ReachStop marks the stop as reached and moves the trip to the next leg, but only while
+	// stop is the current leg of a trip driven by driverID. It returns ErrTripNotOwned when
+	// another driver drives the trip, ErrInvalidStop otherwise.
+	ReachStop(ctx context.Context, tripID, driverID string, stop int, at time.Time) error
+	// MarkTripPaid records when the ride was paid, whatever the trip status.
+	// The first payment is kept when it is recorded again.
+	MarkTripPaid(ctx context.Context, tripID string, at time.Time) error
 }
 
 type TripService interface {
+	// WithTransaction runs fn in a transaction, see Transactor
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 	CreateTrip(ctx context.Context, fare *RideFareModel) (*TripModel, error)
-	GetRoute(ctx context.Context, pickup, destination *types.Coordinate, useOsrmApi bool) (*tripTypes.OsrmApiResponse, error)
-	EstimatePackagesPriceWithRoute(route *tripTypes.OsrmApiResponse) []*RideFareModel
+	// GetRoute returns the route from pickup to destination through the stops, in order
+	GetRoute(ctx context.Context, pickup, destination *types.Coordinate, stops []*types.Coordinate) (*tripTypes.OsrmApiResponse, error)
+	// GetServiceZone returns the zone of a pickup, or ErrOutsideServiceArea.
+	// It returns nil when the service area isn't restricted.
+	GetServiceZone(ctx context.Context, pickup *types.Coordinate) (*ServiceZone, error)
+	// EstimatePackagesPriceWithRoute prices every package of the zone for the route, surge included.
+	// zone is the pickup zone, nil for the default packages and pricing.
+	// scheduledFor is the pickup time of a booking, nil to price a ride leaving now.
+	EstimatePackagesPriceWithRoute(ctx context.Context, route *tripTypes.OsrmApiResponse, zone *ServiceZone, pickup *types.Coordinate, stops []*types.Coordinate, scheduledFor *time.Time) ([]*RideFareModel, error)
+	// ListPackages returns the package catalog, only the bookable packages unless includeDisabled is set
+	ListPackages(ctx context.Context, includeDisabled bool) ([]*PackageModel, error)
+	// ApplyPromotion discounts the fares of the packages the code applies to,
+	// it fails when the code can't be used for any of them
+	ApplyPromotion(ctx context.Context, fares []*RideFareModel, userID, code string) error
 	GenerateTripFares(
 		ctx context.Context,
 		fares []*RideFareModel,
@@ -50,5 +188,32 @@ type TripService interface {
 	) ([]*RideFareModel, error)
 	GetAndValidateFare(ctx context.Context, fareID, userID string) (*RideFareModel, error)
 	GetTripByID(ctx context.Context, id string) (*TripModel, error)
-	UpdateTrip(ctx context.Context, tripID string, status string, driver *pbd.Driver) error
+	// GetTrip returns a trip to its rider or to its driver. It returns ErrTripNotOwned for anyone else.
+	GetTrip(ctx context.Context, tripID, userID, driverID string) (*TripModel, error)
+	// ListTrips returns one page of trips and the cursor of the next page, empty on the last page
+	ListTrips(ctx context.Context, filter TripFilter) ([]*TripModel, string, error)
+	// UpdateTrip moves a trip to status and records the change in its timeline
+	UpdateTrip(ctx context.Context, tripID string, status TripStatus, driver *pbd.Driver, actor TimelineActor, reason string) error
+	CancelTrip(ctx context.Context, tripID, userID, reason string) (*TripModel, error)
+	// ListScheduledTrips returns the upcoming bookings of a user, soonest pickup first
+	ListScheduledTrips(ctx context.Context, userID string) ([]*TripModel, error)
+	// ClaimDueScheduledTrip returns the next scheduled trip to dispatch, nil if there is none
+	ClaimDueScheduledTrip(ctx context.Context) (*TripModel, error)
+	// MarkTripDispatched completes the dispatch of a trip claimed under leaseID
+	MarkTripDispatched(ctx context.Context, tripID, leaseID string) error
+	// ReachStop records that the driver reached the stop and returns the updated trip
+	ReachStop(ctx context.Context, tripID, driverID string, stop int) (*TripModel, error)
+	// CompleteTrip records that the driver dropped the rider off and returns the updated trip.
+	// It returns ErrTripNotOwned when another driver drives the trip.
+	CompleteTrip(ctx context.Context, tripID, driverID string) (*TripModel, error)
+	// RecordTripPayment records the payment of the ride and moves the trip to paid while its status
+	// allows it. A trip completed or cancelled before the payment keeps its status.
+	RecordTripPayment(ctx context.Context, tripID string) error
+	// RecordTimelineEntry appends an event that doesn't change the trip status to its timeline
+	RecordTimelineEntry(ctx context.Context, entry *TimelineEntry) error
+	// GetTripTimeline returns the history of a trip, oldest first, to its rider or to its driver.
+	// It returns ErrTripNotOwned for anyone else.
+	GetTripTimeline(ctx context.Context, tripID, userID, driverID string) ([]*TimelineEntry, error)
+	// RecordTripDemand feeds a trip request into the surge pricing of its pickup cell
+	RecordTripDemand(ctx context.Context, tripID string, unfulfilled bool) error
 }
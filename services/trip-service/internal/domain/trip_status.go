@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Anurag-Mishra22/taxi/shared/contracts"
+)
+
+// TripStatus is the lifecycle state of a trip
+type TripStatus string
+
+const (
+	// TripStatusScheduled is a trip booked for a later pickup, it becomes pending when dispatched
+	TripStatusScheduled      TripStatus = "scheduled"
+	TripStatusPending        TripStatus = contracts.TripStatusPending
+	TripStatusDriverAssigned TripStatus = "driver_assigned"
+	TripStatusDriverArrived  TripStatus = "driver_arrived"
+	TripStatusInProgress     TripStatus = "in_progress"
+	TripStatusCompleted      TripStatus = "completed"
+	TripStatusPaid           TripStatus = "paid"
+	TripStatusCancelled      TripStatus = "cancelled"
+	TripStatusNoDriver       TripStatus = "no_driver"
+)
+
+// TripStatuses lists every trip status, in lifecycle order
+func TripStatuses() []TripStatus {
+	return []TripStatus{
+		TripStatusScheduled,
+		TripStatusPending,
+		TripStatusDriverAssigned,
+		TripStatusDriverArrived,
+		TripStatusInProgress,
+		TripStatusCompleted,
+		TripStatusPaid,
+		TripStatusCancelled,
+		TripStatusNoDriver,
+	}
+}
+
+// ErrInvalidTransition is matched (with errors.Is) by every rejected status change,
+// so consumers can tell a business rule violation apart from an infrastructure failure.
+var ErrInvalidTransition = errors.New("invalid trip status transition")
+
+// InvalidTransitionError describes a rejected status change
+type InvalidTransitionError struct {
+	TripID string
+	From   TripStatus
+	To     TripStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("trip %s cannot move from %q to %q", e.TripID, e.From, e.To)
+}
+
+func (e *InvalidTransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// tripTransitions lists, for every status, the statuses a trip is allowed to move to.
+// Payment is collected as soon as a driver is assigned, so "paid" is reachable from
+// every state of an assigned trip, and a paid trip is driven until the driver completes it.
+// "completed" ends the lifecycle: the ride is over whether or not it was paid.
+var tripTransitions = map[TripStatus][]TripStatus{
+	TripStatusScheduled:      {TripStatusPending, TripStatusCancelled},
+	TripStatusPending:        {TripStatusDriverAssigned, TripStatusNoDriver, TripStatusCancelled},
+	TripStatusNoDriver:       {TripStatusPending, TripStatusCancelled},
+	TripStatusDriverAssigned: {TripStatusDriverArrived, TripStatusInProgress, TripStatusCompleted, TripStatusPaid, TripStatusCancelled},
+	TripStatusDriverArrived:  {TripStatusInProgress, TripStatusCompleted, TripStatusPaid, TripStatusCancelled},
+	TripStatusInProgress:     {TripStatusCompleted, TripStatusPaid},
+	TripStatusPaid:           {TripStatusCompleted},
+	TripStatusCompleted:      {},
+	TripStatusCancelled:      {},
+}
+
+// CanTransitionTo reports whether a trip in status s may move to status next
+func (s TripStatus) CanTransitionTo(next TripStatus) bool {
+	for _, allowed := range tripTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether no further transitions are possible from s
+func (s TripStatus) IsTerminal() bool {
+	allowed, ok := tripTransitions[s]
+	return ok && len(allowed) == 0
+}
+
+// AllowedSourceStatuses returns every status from which a trip may move to next.
+// Repositories use it to make status updates conditional on the current status.
+func AllowedSourceStatuses(next TripStatus) []TripStatus {
+	var sources []TripStatus
+	for from, targets := range tripTransitions {
+		for _, to := range targets {
+			if to == next {
+				sources = append(sources, from)
+				break
+			}
+		}
+	}
+	return sources
+}
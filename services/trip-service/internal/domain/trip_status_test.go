@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTripStatusCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     TripStatus
+		to       TripStatus
+		expected bool
+	}{
+		{"pending_to_assigned", TripStatusPending, TripStatusDriverAssigned, true},
+		{"pending_to_cancelled", TripStatusPending, TripStatusCancelled, true},
+		{"assigned_to_paid", TripStatusDriverAssigned, TripStatusPaid, true},
+		{"in_progress_to_completed", TripStatusInProgress, TripStatusCompleted, true},
+		{"assigned_to_completed", TripStatusDriverAssigned, TripStatusCompleted, true},
+		{"paid_to_completed", TripStatusPaid, TripStatusCompleted, true},
+		{"completed_to_paid", TripStatusCompleted, TripStatusPaid, false},
+		{"pending_to_completed", TripStatusPending, TripStatusCompleted, false},
+		{"cancelled_to_completed", TripStatusCancelled, TripStatusCompleted, false},
+		{"no_driver_back_to_pending", TripStatusNoDriver, TripStatusPending, true},
+		{"scheduled_to_pending", TripStatusScheduled, TripStatusPending, true},
+		{"scheduled_to_cancelled", TripStatusScheduled, TripStatusCancelled, true},
+		{"scheduled_to_assigned", TripStatusScheduled, TripStatusDriverAssigned, false},
+		{"paid_to_assigned", TripStatusPaid, TripStatusDriverAssigned, false},
+		{"assigned_to_assigned", TripStatusDriverAssigned, TripStatusDriverAssigned, false},
+		{"cancelled_to_pending", TripStatusCancelled, TripStatusPending, false},
+		{"in_progress_to_cancelled", TripStatusInProgress, TripStatusCancelled, false},
+		{"unknown_status", TripStatus("payed"), TripStatusPaid, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestAllowedSourceStatuses(t *testing.T) {
+	assert.ElementsMatch(t,
+		[]TripStatus{TripStatusPending},
+		AllowedSourceStatuses(TripStatusDriverAssigned),
+	)
+	assert.ElementsMatch(t,
+		[]TripStatus{TripStatusDriverAssigned, TripStatusDriverArrived, TripStatusInProgress},
+		AllowedSourceStatuses(TripStatusPaid),
+	)
+	assert.ElementsMatch(t,
+		[]TripStatus{TripStatusDriverAssigned, TripStatusDriverArrived, TripStatusInProgress, TripStatusPaid},
+		AllowedSourceStatuses(TripStatusCompleted),
+	)
+	assert.ElementsMatch(t, []TripStatus{TripStatusScheduled, TripStatusNoDriver}, AllowedSourceStatuses(TripStatusPending))
+}
+
+func TestInvalidTransitionErrorIs(t *testing.T) {
+	var err error = &InvalidTransitionError{TripID: "1", From: TripStatusPaid, To: TripStatusDriverAssigned}
+	assert.True(t, errors.Is(err, ErrInvalidTransition))
+}
+
+func TestTripStatusesHaveTransitions(t *testing.T) {
+	statuses := TripStatuses()
+	assert.Len(t, statuses, len(tripTransitions))
+	for _, status := range statuses {
+		assert.Contains(t, tripTransitions, status)
+	}
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"errors"
+
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+)
+
+// ErrOutsideServiceArea is returned when a pickup is not in any service zone
+var ErrOutsideServiceArea = errors.New("pickup is outside the service area")
+
+// Polygon is a GeoJSON polygon: rings of [longitude, latitude] pairs,
+// the first ring is the boundary and the other ones are holes
+type Polygon [][][]float64
+
+// Contains reports whether c is inside the boundary and outside every hole
+func (p Polygon) Contains(c *types.Coordinate) bool {
+	if c == nil || len(p) == 0 || !ringContains(p[0], c) {
+		return false
+	}
+
+	for _, hole := range p[1:] {
+		if ringContains(hole, c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ringContains casts a ray from c towards the east and counts the ring edges it crosses
+func ringContains(ring [][]float64, c *types.Coordinate) bool {
+	inside := false
+
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		lonI, latI := ring[i][0], ring[i][1]
+		lonJ, latJ := ring[j][0], ring[j][1]
+
+		if (latI > c.Latitude) != (latJ > c.Latitude) &&
+			c.Longitude < (lonJ-lonI)*(c.Latitude-latI)/(latJ-latI)+lonI {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// ServiceZone is an area where drivers operate, ex: a city.
+// A zone can have its own packages and pricing, the defaults apply otherwise.
+type ServiceZone struct {
+	ID   string
+	Name string
+	// Area is one or more polygons, like a GeoJSON MultiPolygon
+	Area []Polygon
+	// Packages replaces the package catalog in the zone, nil to use the catalog
+	Packages []*PackageModel
+	// Pricing prices the fares of the zone, nil to use the default pricing
+	Pricing *PricingEngine
+}
+
+func (z *ServiceZone) Contains(c *types.Coordinate) bool {
+	for _, polygon := range z.Area {
+		if polygon.Contains(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceArea is the set of zones pickups are accepted in
+type ServiceArea struct {
+	zones []*ServiceZone
+}
+
+func NewServiceArea(zones []*ServiceZone) *ServiceArea {
+	return &ServiceArea{zones: zones}
+}
+
+// Locate returns the zone of a pickup, the first one listed when zones overlap.
+// It returns ErrOutsideServiceArea when no zone contains the pickup.
+func (a *ServiceArea) Locate(pickup *types.Coordinate) (*ServiceZone, error) {
+	for _, zone := range a.zones {
+		if zone.Contains(pickup) {
+			return zone, nil
+		}
+	}
+	return nil, ErrOutsideServiceArea
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// square returns a closed ring around (lon, lat)
+func square(lon, lat, half float64) [][]float64 {
+	return [][]float64{
+		{lon - half, lat - half},
+		{lon + half, lat - half},
+		{lon + half, lat + half},
+		{lon - half, lat + half},
+		{lon - half, lat - half},
+	}
+}
+
+func TestPolygonContains(t *testing.T) {
+	polygon := Polygon{square(0, 0, 1), square(0, 0, 0.2)}
+
+	tests := []struct {
+		name     string
+		point    *types.Coordinate
+		expected bool
+	}{
+		{"inside", &types.Coordinate{Latitude: 0.5, Longitude: 0.5}, true},
+		{"outside", &types.Coordinate{Latitude: 2, Longitude: 0}, false},
+		{"in_hole", &types.Coordinate{Latitude: 0.1, Longitude: -0.1}, false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, polygon.Contains(tt.point))
+		})
+	}
+}
+
+func TestServiceAreaLocate(t *testing.T) {
+	city := &ServiceZone{ID: "city", Area: []Polygon{{square(0, 0, 1)}}}
+	airport := &ServiceZone{ID: "airport", Area: []Polygon{{square(0.9, 0.9, 0.3)}}}
+	area := NewServiceArea([]*ServiceZone{city, airport})
+
+	zone, err := area.Locate(&types.Coordinate{Latitude: 0.95, Longitude: 0.95})
+	assert.NoError(t, err)
+	assert.Equal(t, "city", zone.ID, "the first zone listed wins when zones overlap")
+
+	zone, err = area.Locate(&types.Coordinate{Latitude: 1.1, Longitude: 1.1})
+	assert.NoError(t, err)
+	assert.Equal(t, "airport", zone.ID)
+
+	_, err = area.Locate(&types.Coordinate{Latitude: 10, Longitude: 10})
+	assert.ErrorIs(t, err, ErrOutsideServiceArea)
+}
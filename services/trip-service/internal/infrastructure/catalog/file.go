@@ -0,0 +1,68 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+)
+
+// fileCatalogVersion is the catalog file format this service understands
+const fileCatalogVersion = 1
+
+type catalogFile struct {
+	Version  int                    `json:"version"`
+	Packages []*domain.PackageModel `json:"packages"`
+}
+
+// staticCatalog serves a catalog that is loaded once at startup
+type staticCatalog struct {
+	packages []*domain.PackageModel
+}
+
+func NewStaticCatalog(packages []*domain.PackageModel) *staticCatalog {
+	return &staticCatalog{packages: packages}
+}
+
+// NewFileCatalog loads the catalog from a versioned JSON file
+func NewFileCatalog(path string) (*staticCatalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package catalog: %w", err)
+	}
+
+	var file catalogFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse package catalog: %w", err)
+	}
+
+	if file.Version != fileCatalogVersion {
+		return nil, fmt.Errorf("unsupported package catalog version %d, expected %d", file.Version, fileCatalogVersion)
+	}
+
+	if err := validate(file.Packages); err != nil {
+		return nil, err
+	}
+
+	return NewStaticCatalog(file.Packages), nil
+}
+
+func (c *staticCatalog) ListPackages(ctx context.Context) ([]*domain.PackageModel, error) {
+	return c.packages, nil
+}
+
+func validate(packages []*domain.PackageModel) error {
+	seen := make(map[string]bool, len(packages))
+	for _, p := range packages {
+		if p.Slug == "" {
+			return fmt.Errorf("package catalog contains a package without slug")
+		}
+		if seen[p.Slug] {
+			return fmt.Errorf("package catalog contains the package %q twice", p.Slug)
+		}
+		seen[p.Slug] = true
+	}
+	return nil
+}
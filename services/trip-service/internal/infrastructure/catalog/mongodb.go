@@ -0,0 +1,64 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/shared/db"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoCatalog reads the packages collection on every call,
+// so packages can be changed without restarting the service
+type mongoCatalog struct {
+	db *mongo.Database
+}
+
+func NewMongoCatalog(db *mongo.Database) *mongoCatalog {
+	return &mongoCatalog{db: db}
+}
+
+func (c *mongoCatalog) ListPackages(ctx context.Context) ([]*domain.PackageModel, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "slug", Value: 1}})
+
+	cursor, err := c.db.Collection(db.PackagesCollection).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find packages: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var packages []*domain.PackageModel
+	if err := cursor.All(ctx, &packages); err != nil {
+		return nil, fmt.Errorf("failed to decode packages: %w", err)
+	}
+
+	return packages, nil
+}
+
+// SeedIfEmpty fills an empty packages collection, so a fresh database can quote fares
+func (c *mongoCatalog) SeedIfEmpty(ctx context.Context, packages []*domain.PackageModel) error {
+	collection := c.db.Collection(db.PackagesCollection)
+
+	count, err := collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("failed to count packages: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(packages))
+	for i, p := range packages {
+		docs[i] = p
+	}
+
+	if _, err := collection.InsertMany(ctx, docs); err != nil {
+		return fmt.Errorf("failed to seed packages: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/shared/contracts"
+	"github.com/Anurag-Mishra22/taxi/shared/messaging"
+	"github.com/Anurag-Mishra22/taxi/shared/metrics"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// demandConsumer counts requested and unfulfilled trips for the surge pricing
+type demandConsumer struct {
+	rabbitmq *messaging.RabbitMQ
+	service  domain.TripService
+	metrics  *metrics.Metrics
+}
+
+func NewDemandConsumer(rabbitmq *messaging.RabbitMQ, service domain.TripService, m *metrics.Metrics) *demandConsumer {
+	return &demandConsumer{
+		rabbitmq: rabbitmq,
+		service:  service,
+		metrics:  m,
+	}
+}
+
+func (c *demandConsumer) Listen() error {
+	return c.rabbitmq.ConsumeMessages(messaging.TripDemandQueue, func(ctx context.Context, msg amqp091.Delivery) error {
+		start := time.Now()
+		var message contracts.AmqpMessage
+		if err := json.Unmarshal(msg.Body, &message); err != nil {
+			log.Printf("Failed to unmarshal message: %v", err)
+			return err
+		}
+
+		var payload messaging.TripEventData
+		if err := json.Unmarshal(message.Data, &payload); err != nil || payload.Trip == nil {
+			// Events without the trip can't be located, dropping them only makes the surge less accurate
+			log.Printf("Ignoring demand event without trip: %s", msg.RoutingKey)
+			return nil
+		}
+
+		unfulfilled := msg.RoutingKey == contracts.TripEventNoDriversFound
+
+		err := c.service.RecordTripDemand(ctx, payload.Trip.Id, unfulfilled)
+		if errors.Is(err, domain.ErrTripNotFound) {
+			log.Printf("Ignoring demand for unknown trip: %s", payload.Trip.Id)
+			err = nil
+		}
+
+		if c.metrics != nil {
+			status := "success"
+			if err != nil {
+				status = "error"
+			}
+			c.metrics.RecordMessageConsumed(messaging.TripDemandQueue, status, time.Since(start), msg.RoutingKey)
+		}
+
+		return err
+	})
+}
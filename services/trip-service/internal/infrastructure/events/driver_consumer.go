@@ -3,7 +3,7 @@ package events
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
 	"github.com/Anurag-Mishra22/taxi/shared/contracts"
@@ -16,138 +16,207 @@ import (
 )
 
 type driverConsumer struct {
-	rabbitmq *messaging.RabbitMQ
-	service  domain.TripService
-	metrics  *metrics.Metrics
+	rabbitmq  *messaging.RabbitMQ
+	service   domain.TripService
+	publisher *TripEventPublisher
+	metrics   *metrics.Metrics
 }
 
-func NewDriverConsumer(rabbitmq *messaging.RabbitMQ, service domain.TripService, m *metrics.Metrics) *driverConsumer {
+func NewDriverConsumer(rabbitmq *messaging.RabbitMQ, service domain.TripService, publisher *TripEventPublisher, m *metrics.Metrics) *driverConsumer {
 	return &driverConsumer{
-		rabbitmq: rabbitmq,
-		service:  service,
-		metrics:  m,
+		rabbitmq:  rabbitmq,
+		service:   service,
+		publisher: publisher,
+		metrics:   m,
 	}
 }
 
 func (c *driverConsumer) Listen() error {
-	return c.rabbitmq.ConsumeMessages(messaging.DriverTripResponseQueue, func(ctx context.Context, msg amqp091.Delivery) error {
-		start := time.Now()
-		var message contracts.AmqpMessage
-		if err := json.Unmarshal(msg.Body, &message); err != nil {
+	return c.rabbitmq.ConsumeMessages(messaging.DriverTripResponseQueue, c.handle)
+}
+
+func (c *driverConsumer) handle(ctx context.Context, msg amqp091.Delivery) error {
+	start := time.Now()
+	var message contracts.AmqpMessage
+	if err := json.Unmarshal(msg.Body, &message); err != nil {
+		log.Printf("Failed to unmarshal message: %v", err)
+		return err
+	}
+
+	var payload messaging.DriverTripResponseData
+	if err := json.Unmarshal(message.Data, &payload); err != nil {
+		log.Printf("Failed to unmarshal message: %v", err)
+		return err
+	}
+
+	log.Printf("driver response received message: %+v", payload)
+
+	switch msg.RoutingKey {
+	case contracts.TripEventOfferAccepted:
+		// The driver-service claimed the pending offer of the driver owning the message
+		driver := payload.Driver
+		if driver == nil {
+			driver = &pbd.Driver{Id: message.OwnerID}
+		}
+		if driver.Id != message.OwnerID {
+			log.Printf("Ignoring the accept of trip %s by driver %s for driver %s", payload.TripID, message.OwnerID, driver.Id)
+			return nil
+		}
+		if err := c.handleTripAccepted(ctx, payload.TripID, driver); err != nil {
+			log.Printf("Failed to handle the trip accept: %v", err)
+			return err
+		}
+	case contracts.DriverCmdTripDecline:
+		// The gateway sets the owner of driver commands to the connected driver
+		if payload.Driver != nil && payload.Driver.Id != message.OwnerID {
+			log.Printf("Ignoring the decline of trip %s by driver %s for driver %s", payload.TripID, message.OwnerID, payload.Driver.Id)
+			return nil
+		}
+		if err := c.handleTripDeclined(ctx, payload.TripID, message.OwnerID); err != nil {
+			log.Printf("Failed to handle the trip decline: %v", err)
+			return err
+		}
+		return nil
+	case contracts.DriverCmdStopReached:
+		var stopPayload messaging.DriverStopReachedData
+		if err := json.Unmarshal(message.Data, &stopPayload); err != nil {
 			log.Printf("Failed to unmarshal message: %v", err)
 			return err
 		}
-
-		var payload messaging.DriverTripResponseData
-		if err := json.Unmarshal(message.Data, &payload); err != nil {
+		// The gateway sets the owner of driver commands to the connected driver
+		if err := c.handleStopReached(ctx, message.OwnerID, stopPayload); err != nil {
+			log.Printf("Failed to handle the stop reached: %v", err)
+			return err
+		}
+		return nil
+	case contracts.TripEventNoDriversFound:
+		var tripPayload messaging.TripEventData
+		if err := json.Unmarshal(message.Data, &tripPayload); err != nil || tripPayload.Trip == nil {
+			log.Printf("Ignoring no drivers found without trip: %v", err)
+			return nil
+		}
+		if err := c.handleNoDriversFound(ctx, tripPayload.Trip.Id); err != nil {
+			log.Printf("Failed to handle the no drivers found: %v", err)
+			return err
+		}
+		return nil
+	case contracts.DriverCmdTripComplete:
+		var completePayload messaging.DriverTripCompleteData
+		if err := json.Unmarshal(message.Data, &completePayload); err != nil {
 			log.Printf("Failed to unmarshal message: %v", err)
 			return err
 		}
+		if err := c.handleTripCompleted(ctx, message.OwnerID, completePayload.TripID); err != nil {
+			log.Printf("Failed to handle the trip completion: %v", err)
+			return err
+		}
+		return nil
+	}
+	log.Printf("unknown trip event: %+v", payload)
 
-		log.Printf("driver response received message: %+v", payload)
+	if c.metrics != nil {
+		c.metrics.RecordMessageConsumed(messaging.DriverTripResponseQueue, "success", time.Since(start), msg.RoutingKey)
+	}
 
-		switch msg.RoutingKey {
-		case contracts.DriverCmdTripAccept:
-			if err := c.handleTripAccepted(ctx, payload.TripID, payload.Driver); err != nil {
-				log.Printf("Failed to handle the trip accept: %v", err)
-				return err
-			}
-		case contracts.DriverCmdTripDecline:
-			if err := c.handleTripDeclined(ctx, payload.TripID, payload.RiderID); err != nil {
-				log.Printf("Failed to handle the trip decline: %v", err)
-				return err
-			}
-			return nil
+	return nil
+}
+
+func (c *driverConsumer) handleTripDeclined(ctx context.Context, tripID, driverID string) error {
+	// When a driver declines, we should try to find another driver
+	return c.service.WithTransaction(ctx, func(ctx context.Context) error {
+		// The status is read in the transaction: writing the outbox message updates the trip,
+		// so an accept committed meanwhile makes the transaction conflict and retry
+		trip, err := c.service.GetTripByID(ctx, tripID)
+		if err != nil {
+			return err
 		}
-		log.Printf("unknown trip event: %+v", payload)
 
-		if c.metrics != nil {
-			c.metrics.RecordMessageConsumed(messaging.DriverTripResponseQueue, "success", time.Since(start), msg.RoutingKey)
+		entry := domain.NewTimelineEntry(tripID, domain.TimelineDriverDeclined, domain.DriverActor(driverID), "driver declined the trip")
+		if err := c.service.RecordTimelineEntry(ctx, entry); err != nil {
+			return err
 		}
 
-		return nil
+		// In broadcast mode, a driver can decline after another driver accepted
+		if trip.Status != domain.TripStatusPending {
+			return nil
+		}
+		return c.publisher.PublishDriverNotInterested(ctx, trip, driverID)
 	})
 }
 
-func (c *driverConsumer) handleTripDeclined(ctx context.Context, tripID, riderID string) error {
-	// When a driver declines, we should try to find another driver
-
-	trip, err := c.service.GetTripByID(ctx, tripID)
-	if err != nil {
-		return err
-	}
-
-	newPayload := messaging.TripEventData{
-		Trip: trip.ToProto(),
+// handleNoDriversFound gives the trip up once the driver-service has no more drivers to offer it to
+func (c *driverConsumer) handleNoDriversFound(ctx context.Context, tripID string) error {
+	err := c.service.WithTransaction(ctx, func(ctx context.Context) error {
+		return c.service.UpdateTrip(ctx, tripID, domain.TripStatusNoDriver, nil, domain.SystemActor("driver-service"), "no driver accepted the trip")
+	})
+	if errors.Is(err, domain.ErrInvalidTransition) || errors.Is(err, domain.ErrTripNotFound) {
+		// The trip was accepted or cancelled meanwhile, or the event was redelivered
+		log.Printf("Ignoring no drivers found: %v", err)
+		return nil
 	}
+	return err
+}
 
-	marshalledPayload, err := json.Marshal(newPayload)
-	if err != nil {
-		return err
+func (c *driverConsumer) handleStopReached(ctx context.Context, driverID string, payload messaging.DriverStopReachedData) error {
+	err := c.service.WithTransaction(ctx, func(ctx context.Context) error {
+		trip, err := c.service.ReachStop(ctx, payload.TripID, driverID, payload.Stop)
+		if err != nil {
+			return err
+		}
+		return c.publisher.PublishStopReached(ctx, trip)
+	})
+	if errors.Is(err, domain.ErrInvalidStop) || errors.Is(err, domain.ErrTripNotOwned) || errors.Is(err, domain.ErrTripNotFound) {
+		// Duplicated or out of order commands can't succeed on a retry
+		log.Printf("Ignoring stop reached: %v", err)
+		return nil
 	}
+	return err
+}
 
-	if err := c.rabbitmq.PublishMessage(ctx, contracts.TripEventDriverNotInterested,
-		contracts.AmqpMessage{
-			OwnerID: riderID,
-			Data:    marshalledPayload,
-		},
-	); err != nil {
-		return err
+func (c *driverConsumer) handleTripCompleted(ctx context.Context, driverID, tripID string) error {
+	err := c.service.WithTransaction(ctx, func(ctx context.Context) error {
+		trip, err := c.service.CompleteTrip(ctx, tripID, driverID)
+		if err != nil {
+			return err
+		}
+		return c.publisher.PublishTripCompleted(ctx, trip)
+	})
+	if errors.Is(err, domain.ErrInvalidTransition) || errors.Is(err, domain.ErrTripNotOwned) || errors.Is(err, domain.ErrTripNotFound) {
+		// Duplicated commands, or commands for a trip that was cancelled, can't succeed on a retry
+		log.Printf("Ignoring trip completion: %v", err)
+		return nil
 	}
-
-	return nil
+	return err
 }
 
 func (c *driverConsumer) handleTripAccepted(ctx context.Context, tripID string, driver *pbd.Driver) error {
 	// 1. Fetch the first
-	trip, err := c.service.GetTripByID(ctx, tripID)
-	if err != nil {
+	if _, err := c.service.GetTripByID(ctx, tripID); err != nil {
 		return err
 	}
 
-	if trip == nil {
-		return fmt.Errorf("Trip was not found %s", tripID)
-	}
+	// 2. Update the trip and write the driver assigned messages with it
+	err := c.service.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := c.service.UpdateTrip(ctx, tripID, domain.TripStatusDriverAssigned, driver, domain.DriverActor(driver.Id), "driver accepted the trip"); err != nil {
+			return err
+		}
 
-	// 2. Update the trip
-	if err := c.service.UpdateTrip(ctx, tripID, "accepted", driver); err != nil {
-		log.Printf("Failed to update the trip: %v", err)
-		return err
-	}
+		trip, err := c.service.GetTripByID(ctx, tripID)
+		if err != nil {
+			return err
+		}
 
-	trip, err = c.service.GetTripByID(ctx, tripID)
-	if err != nil {
-		return err
+		// 3. Driver has been assigned -> notify the rider and request the payment session
+		return c.publisher.PublishDriverAssigned(ctx, trip)
+	})
+	if errors.Is(err, domain.ErrInvalidTransition) {
+		// The trip already moved on (e.g. another driver accepted it first or it was paid),
+		// retrying won't help so we drop the message. The driver-service revokes the other offers.
+		log.Printf("Ignoring trip accept by driver %s: %v", driver.Id, err)
+		return nil
 	}
-
-	// 3. Driver has been assigned -> publish this event to RB
-	marshalledTrip, err := json.Marshal(trip)
 	if err != nil {
-		return err
-	}
-
-	// Notify the rider that a driver has been assigned
-	if err := c.rabbitmq.PublishMessage(ctx, contracts.TripEventDriverAssigned, contracts.AmqpMessage{
-		OwnerID: trip.UserID,
-		Data:    marshalledTrip,
-	}); err != nil {
-		return err
-	}
-
-	marshalledPayload, err := json.Marshal(messaging.PaymentTripResponseData{
-		TripID:   tripID,
-		UserID:   trip.UserID,
-		DriverID: driver.Id,
-		Amount:   trip.RideFare.TotalPriceInCents,
-		Currency: "USD",
-	})
-
-	if err := c.rabbitmq.PublishMessage(ctx, contracts.PaymentCmdCreateSession,
-		contracts.AmqpMessage{
-			OwnerID: trip.UserID,
-			Data:    marshalledPayload,
-		},
-	); err != nil {
+		log.Printf("Failed to update the trip: %v", err)
 		return err
 	}
 
@@ -0,0 +1,182 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/infrastructure/repository"
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/service"
+	tripTypes "github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
+	"github.com/Anurag-Mishra22/taxi/shared/contracts"
+	"github.com/Anurag-Mishra22/taxi/shared/messaging"
+	pbd "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDriverConsumer(t *testing.T, status domain.TripStatus) (*driverConsumer, domain.TripRepository, *domain.TripModel) {
+	repo := repository.NewInmemRepository()
+	consumer := NewDriverConsumer(nil, service.NewService(repo, repo, nil, nil, nil, nil, service.Config{}), NewTripEventPublisher(repo), nil)
+
+	trip, err := repo.CreateTrip(context.Background(), &domain.TripModel{
+		UserID: "rider",
+		Status: status,
+		RideFare: &domain.RideFareModel{
+			PackageSlug: "sedan",
+			TotalPrice:  types.NewMoney(1250, "USD"),
+			Route:       &tripTypes.OsrmApiResponse{Routes: []tripTypes.OsrmRoute{{Distance: 5000, Duration: 600}}},
+		},
+	})
+	require.NoError(t, err)
+
+	return consumer, repo, trip
+}
+
+// relayedMessages relays the outbox messages written by the consumer
+func relayedMessages(t *testing.T, repo domain.TripRepository) []string {
+	publisher := &fakePublisher{}
+	NewOutboxRelay(repo.(domain.OutboxRepository), publisher, time.Second, time.Second, 10, nil).relayPending(context.Background())
+	return publisher.published
+}
+
+func TestDriverConsumerNoDriversFound(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   domain.TripStatus
+		expected domain.TripStatus
+		entries  int
+	}{
+		{
+			name:     "a_pending_trip_has_no_driver",
+			status:   domain.TripStatusPending,
+			expected: domain.TripStatusNoDriver,
+			entries:  1,
+		},
+		{
+			name:     "an_accepted_trip_is_kept",
+			status:   domain.TripStatusDriverAssigned,
+			expected: domain.TripStatusDriverAssigned,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			consumer, repo, trip := newTestDriverConsumer(t, tt.status)
+
+			delivery := timelineDelivery(t, contracts.TripEventNoDriversFound, "no-drivers-1", "rider", messaging.TripEventData{Trip: &pb.Trip{Id: trip.ID.Hex()}})
+			require.NoError(t, consumer.handle(ctx, delivery))
+			// Redelivered
+			require.NoError(t, consumer.handle(ctx, delivery))
+
+			updated, err := repo.GetTripByID(ctx, trip.ID.Hex())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, updated.Status)
+
+			timeline, err := repo.GetTripTimeline(ctx, trip.ID.Hex())
+			require.NoError(t, err)
+			require.Len(t, timeline, tt.entries)
+			if tt.entries > 0 {
+				assert.Equal(t, domain.TimelineStatusChanged, timeline[0].Type)
+				assert.Equal(t, domain.TripStatusNoDriver, timeline[0].To)
+				assert.Equal(t, domain.SystemActor("driver-service"), timeline[0].Actor)
+			}
+		})
+	}
+}
+
+func TestDriverConsumerTripDeclined(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   domain.TripStatus
+		expected []string
+	}{
+		{
+			name:     "a_pending_trip_is_offered_again",
+			status:   domain.TripStatusPending,
+			expected: []string{"rider:" + contracts.TripEventDriverNotInterested},
+		},
+		{
+			name:   "an_accepted_trip_is_kept",
+			status: domain.TripStatusDriverAssigned,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			consumer, repo, trip := newTestDriverConsumer(t, tt.status)
+
+			decline := messaging.DriverTripResponseData{TripID: trip.ID.Hex(), RiderID: "rider", Driver: &pbd.Driver{Id: "driver-1"}}
+			require.NoError(t, consumer.handle(ctx, timelineDelivery(t, contracts.DriverCmdTripDecline, "decline-1", "driver-1", decline)))
+
+			assert.Equal(t, tt.expected, relayedMessages(t, repo))
+
+			timeline, err := repo.GetTripTimeline(ctx, trip.ID.Hex())
+			require.NoError(t, err)
+			require.Len(t, timeline, 1)
+			assert.Equal(t, domain.TimelineDriverDeclined, timeline[0].Type)
+		})
+	}
+}
+
+func TestDriverConsumerTripAccepted(t *testing.T) {
+	tests := []struct {
+		name     string
+		ownerID  string
+		driver   *pbd.Driver
+		expected domain.TripStatus
+	}{
+		{
+			name:     "the_accepting_driver_is_assigned",
+			ownerID:  "driver-1",
+			driver:   &pbd.Driver{Id: "driver-1", Name: "Lisa"},
+			expected: domain.TripStatusDriverAssigned,
+		},
+		{
+			name:     "an_accept_without_profile_assigns_the_owner",
+			ownerID:  "driver-1",
+			expected: domain.TripStatusDriverAssigned,
+		},
+		{
+			name:     "an_accept_for_another_driver_is_ignored",
+			ownerID:  "driver-2",
+			driver:   &pbd.Driver{Id: "driver-1"},
+			expected: domain.TripStatusPending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			consumer, repo, trip := newTestDriverConsumer(t, domain.TripStatusPending)
+
+			accept := messaging.DriverTripResponseData{TripID: trip.ID.Hex(), Driver: tt.driver}
+			require.NoError(t, consumer.handle(ctx, timelineDelivery(t, contracts.TripEventOfferAccepted, "accept-1", tt.ownerID, accept)))
+
+			updated, err := repo.GetTripByID(ctx, trip.ID.Hex())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, updated.Status)
+			if tt.expected == domain.TripStatusDriverAssigned {
+				assert.Equal(t, tt.ownerID, updated.Driver.GetId())
+			}
+		})
+	}
+}
+
+func TestDriverConsumerIgnoresADeclineForAnotherDriver(t *testing.T) {
+	ctx := context.Background()
+	consumer, repo, trip := newTestDriverConsumer(t, domain.TripStatusPending)
+
+	decline := messaging.DriverTripResponseData{TripID: trip.ID.Hex(), Driver: &pbd.Driver{Id: "driver-1"}}
+	require.NoError(t, consumer.handle(ctx, timelineDelivery(t, contracts.DriverCmdTripDecline, "decline-1", "driver-2", decline)))
+
+	assert.Empty(t, relayedMessages(t, repo))
+	timeline, err := repo.GetTripTimeline(ctx, trip.ID.Hex())
+	require.NoError(t, err)
+	assert.Empty(t, timeline)
+}
@@ -0,0 +1,152 @@
+package events
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/shared/contracts"
+	"github.com/Anurag-Mishra22/taxi/shared/metrics"
+)
+
+// outboxBatchSize is the number of trips whose next message is published per round
+const outboxBatchSize = 100
+
+// messagePublisher is implemented by messaging.ConfirmedPublisher
+type messagePublisher interface {
+	PublishMessage(ctx context.Context, routingKey string, message contracts.AmqpMessage) error
+}
+
+// outboxRelay publishes the outbox messages to RabbitMQ. Every replica can run it: a message is
+// leased before being published, and the next message of a trip is only published once the
+// previous one is confirmed, so the messages of a trip keep their order.
+// Delivery is at least once, a relay stopping between the publish and the mark
+// lets the message be published again when its lease expires.
+// A message failing maxAttempts times is dead-lettered, so it doesn't block its trip forever.
+type outboxRelay struct {
+	outbox      domain.OutboxRepository
+	publisher   messagePublisher
+	interval    time.Duration
+	lease       time.Duration
+	maxAttempts int
+	metrics     *metrics.Metrics
+}
+
+func NewOutboxRelay(outbox domain.OutboxRepository, publisher messagePublisher, interval, lease time.Duration, maxAttempts int, m *metrics.Metrics) *outboxRelay {
+	return &outboxRelay{
+		outbox:      outbox,
+		publisher:   publisher,
+		interval:    interval,
+		lease:       lease,
+		maxAttempts: maxAttempts,
+		metrics:     m,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled
+func (r *outboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.relayPending(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *outboxRelay) relayPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		messages, err := r.outbox.ListPendingOutboxMessages(ctx, outboxBatchSize)
+		if err != nil {
+			log.Printf("Failed to list the pending outbox messages: %v", err)
+			return
+		}
+
+		published := 0
+		for _, message := range messages {
+			if r.relay(ctx, message) {
+				published++
+			}
+		}
+
+		// Nothing left, or only messages leased by other relays or failing to publish
+		if published == 0 {
+			return
+		}
+	}
+}
+
+// relay publishes one message and reports whether it was published
+func (r *outboxRelay) relay(ctx context.Context, message *domain.OutboxMessage) bool {
+	now := time.Now()
+	claimed, err := r.outbox.ClaimOutboxMessage(ctx, message.ID, now, now.Add(r.lease))
+	if err != nil {
+		log.Printf("Failed to claim outbox message %s: %v", message.ID.Hex(), err)
+		return false
+	}
+	if !claimed {
+		return false
+	}
+
+	// Give up before the lease expires, so another relay never publishes the message concurrently
+	publishCtx, cancel := context.WithTimeout(ctx, r.lease/2)
+	defer cancel()
+
+	start := time.Now()
+	err = r.publisher.PublishMessage(publishCtx, message.RoutingKey, contracts.AmqpMessage{
+		OwnerID: message.OwnerID,
+		Data:    message.Data,
+		// A message published again keeps its ID
+		MessageID: message.ID.Hex(),
+	})
+	r.recordPublished(message.RoutingKey, start, err)
+	if err != nil {
+		log.Printf("Failed to publish outbox message %s (%s) for trip %s: %v", message.ID.Hex(), message.RoutingKey, message.TripID, err)
+		r.recordFailure(ctx, message, err)
+		return false
+	}
+
+	if err := r.outbox.MarkOutboxMessagePublished(ctx, message.ID, time.Now()); err != nil {
+		log.Printf("Failed to mark outbox message %s as published, it will be published again: %v", message.ID.Hex(), err)
+		return false
+	}
+
+	return true
+}
+
+// recordFailure keeps the error of a failed publish, and dead-letters the message on its last attempt
+func (r *outboxRelay) recordFailure(ctx context.Context, message *domain.OutboxMessage, publishErr error) {
+	// The claim counted this attempt
+	if message.Attempts+1 < r.maxAttempts {
+		if err := r.outbox.RecordOutboxFailure(ctx, message.ID, publishErr.Error()); err != nil {
+			log.Printf("Failed to record the failure of outbox message %s: %v", message.ID.Hex(), err)
+		}
+		return
+	}
+
+	log.Printf("Dead-lettering outbox message %s (%s) for trip %s after %d attempts", message.ID.Hex(), message.RoutingKey, message.TripID, message.Attempts+1)
+	if err := r.outbox.DeadLetterOutboxMessage(ctx, message.ID, time.Now(), publishErr.Error()); err != nil {
+		log.Printf("Failed to dead-letter outbox message %s: %v", message.ID.Hex(), err)
+	}
+}
+
+func (r *outboxRelay) recordPublished(routingKey string, start time.Time, err error) {
+	if r.metrics == nil {
+		return
+	}
+
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	r.metrics.RecordMessagePublished("trip", routingKey, status)
+	if err == nil {
+		r.metrics.MessageProcessingDuration.WithLabelValues("trip", routingKey).Observe(time.Since(start).Seconds())
+	}
+}
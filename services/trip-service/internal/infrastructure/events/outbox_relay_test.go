@@ -0,0 +1,105 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/infrastructure/repository"
+	"github.com/Anurag-Mishra22/taxi/shared/contracts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePublisher struct {
+	published []string
+	// failures makes the next publishes of a routing key fail
+	failures map[string]int
+}
+
+func (p *fakePublisher) PublishMessage(ctx context.Context, routingKey string, message contracts.AmqpMessage) error {
+	if p.failures[routingKey] > 0 {
+		p.failures[routingKey]--
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, message.OwnerID+":"+routingKey)
+	return nil
+}
+
+// saveOutboxTrip stores a trip the messages can be written for
+func saveOutboxTrip(t *testing.T, repo domain.TripRepository) string {
+	trip, err := repo.CreateTrip(context.Background(), &domain.TripModel{UserID: "rider", Status: domain.TripStatusPending})
+	require.NoError(t, err)
+	return trip.ID.Hex()
+}
+
+// addMessage writes a message of the trip, owned by ownerID
+func addMessage(t *testing.T, repo domain.OutboxRepository, tripID, ownerID, routingKey string) {
+	require.NoError(t, repo.AddOutboxMessage(context.Background(), &domain.OutboxMessage{
+		TripID:     tripID,
+		RoutingKey: routingKey,
+		OwnerID:    ownerID,
+		CreatedAt:  time.Now(),
+	}))
+}
+
+func TestOutboxRelayKeepsTheOrderOfATrip(t *testing.T) {
+	repo := repository.NewInmemRepository()
+	publisher := &fakePublisher{failures: map[string]int{contracts.TripEventDriverAssigned: 1}}
+	lease := 20 * time.Millisecond
+	relay := NewOutboxRelay(repo, publisher, time.Second, lease, 10, nil)
+
+	a, b := saveOutboxTrip(t, repo), saveOutboxTrip(t, repo)
+	addMessage(t, repo, a, "a", contracts.TripEventCreated)
+	addMessage(t, repo, a, "a", contracts.TripEventDriverAssigned)
+	addMessage(t, repo, a, "a", contracts.PaymentCmdCreateSession)
+	addMessage(t, repo, b, "b", contracts.TripEventCreated)
+
+	relay.relayPending(context.Background())
+
+	// The failure holds back the payment command of trip a, not the messages of trip b
+	assert.Equal(t, []string{
+		"a:" + contracts.TripEventCreated,
+		"b:" + contracts.TripEventCreated,
+	}, publisher.published)
+
+	// The failed message is retried once its lease expires
+	time.Sleep(2 * lease)
+	relay.relayPending(context.Background())
+
+	assert.Equal(t, []string{
+		"a:" + contracts.TripEventCreated,
+		"b:" + contracts.TripEventCreated,
+		"a:" + contracts.TripEventDriverAssigned,
+		"a:" + contracts.PaymentCmdCreateSession,
+	}, publisher.published)
+
+	pending, err := repo.ListPendingOutboxMessages(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestOutboxRelayDeadLettersAPoisonMessage(t *testing.T) {
+	repo := repository.NewInmemRepository()
+	publisher := &fakePublisher{failures: map[string]int{contracts.TripEventCreated: 100}}
+	relay := NewOutboxRelay(repo, publisher, time.Second, time.Nanosecond, 3, nil)
+
+	a := saveOutboxTrip(t, repo)
+	addMessage(t, repo, a, "a", contracts.TripEventCreated)
+	addMessage(t, repo, a, "a", contracts.TripEventDriverAssigned)
+
+	for attempt := 0; attempt < 3; attempt++ {
+		relay.relayPending(context.Background())
+		assert.Empty(t, publisher.published)
+	}
+
+	// The next messages of the trip are published once the poison message is dead-lettered
+	relay.relayPending(context.Background())
+	assert.Equal(t, []string{"a:" + contracts.TripEventDriverAssigned}, publisher.published)
+
+	pending, err := repo.ListPendingOutboxMessages(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
@@ -42,14 +42,26 @@ func (c *paymentConsumer) Listen() error {
 			return err
 		}
 
-		log.Printf("Trip has been completed and payed.")
+		paymentActor := domain.SystemActor("payment-service")
 
-		err := c.service.UpdateTrip(
-			ctx,
-			payload.TripID,
-			"payed",
-			nil,
-		)
+		if payload.ChargeType == messaging.PaymentChargeTypeCancellationFee {
+			// Cancellation fees are charged on trips that are already cancelled
+			log.Printf("Cancellation fee paid for trip: %s", payload.TripID)
+			entry := domain.NewTimelineEntry(payload.TripID, domain.TimelinePaymentSucceeded, paymentActor, "cancellation fee paid")
+			return c.service.RecordTimelineEntry(ctx, entry)
+		}
+
+		log.Printf("Trip %s has been paid", payload.TripID)
+
+		err := c.service.WithTransaction(ctx, func(ctx context.Context) error {
+			entry := domain.NewTimelineEntry(payload.TripID, domain.TimelinePaymentSucceeded, paymentActor, "ride paid")
+			entry.EventID = eventID(msg)
+			if err := c.service.RecordTimelineEntry(ctx, entry); err != nil {
+				return err
+			}
+
+			return c.service.RecordTripPayment(ctx, payload.TripID)
+		})
 
 		if c.metrics != nil {
 			status := "success"
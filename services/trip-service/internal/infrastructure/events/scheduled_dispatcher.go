@@ -0,0 +1,71 @@
+package events
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+)
+
+// scheduledTripDispatcher publishes the created event of scheduled trips shortly before their pickup.
+// The trips are claimed in MongoDB, so every replica can run it without dispatching a trip twice,
+// and a trip claimed by a replica that stopped is dispatched again once its lease expires.
+type scheduledTripDispatcher struct {
+	service   domain.TripService
+	publisher *TripEventPublisher
+	interval  time.Duration
+}
+
+func NewScheduledTripDispatcher(service domain.TripService, publisher *TripEventPublisher, interval time.Duration) *scheduledTripDispatcher {
+	return &scheduledTripDispatcher{
+		service:   service,
+		publisher: publisher,
+		interval:  interval,
+	}
+}
+
+// Run polls for due trips until ctx is cancelled
+func (d *scheduledTripDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatchDueTrips(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *scheduledTripDispatcher) dispatchDueTrips(ctx context.Context) {
+	for ctx.Err() == nil {
+		trip, err := d.service.ClaimDueScheduledTrip(ctx)
+		if err != nil {
+			log.Printf("Failed to claim a scheduled trip: %v", err)
+			return
+		}
+		if trip == nil {
+			return
+		}
+
+		// Left unmarked on failure, the trip is claimed again when its lease expires.
+		// The event is only kept if the lease is still ours when the trip is marked,
+		// a slow replica never publishes a trip another replica took over.
+		err = d.service.WithTransaction(ctx, func(ctx context.Context) error {
+			if err := d.publisher.PublishTripCreated(ctx, trip); err != nil {
+				return err
+			}
+			return d.service.MarkTripDispatched(ctx, trip.ID.Hex(), trip.DispatchLeaseID)
+		})
+		if err != nil {
+			log.Printf("Failed to dispatch scheduled trip %s: %v", trip.ID.Hex(), err)
+			continue
+		}
+
+		log.Printf("Dispatched scheduled trip %s, pickup at %s", trip.ID.Hex(), trip.ScheduledFor.Format(time.RFC3339))
+	}
+}
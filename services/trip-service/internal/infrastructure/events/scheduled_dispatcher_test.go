@@ -0,0 +1,68 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/infrastructure/repository"
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/service"
+	tripTypes "github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
+	"github.com/Anurag-Mishra22/taxi/shared/contracts"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func saveScheduledTrip(t *testing.T, repo domain.TripRepository, pickup time.Time) *domain.TripModel {
+	trip, err := repo.CreateTrip(context.Background(), &domain.TripModel{
+		UserID: "rider",
+		Status: domain.TripStatusScheduled,
+		RideFare: &domain.RideFareModel{
+			ID:          primitive.NewObjectID(),
+			PackageSlug: "sedan",
+			TotalPrice:  types.NewMoney(1250, "USD"),
+			Route:       &tripTypes.OsrmApiResponse{Routes: []tripTypes.OsrmRoute{{Distance: 5000, Duration: 600}}},
+		},
+		ScheduledFor: &pickup,
+		CreatedAt:    time.Now(),
+	})
+	require.NoError(t, err)
+	return trip
+}
+
+func TestScheduledTripDispatcher(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInmemRepository()
+	svc := service.NewService(repo, repo, nil, nil, nil, nil, service.Config{Schedule: domain.DefaultSchedulePolicy()})
+	dispatcher := NewScheduledTripDispatcher(svc, NewTripEventPublisher(repo), time.Minute)
+
+	due := saveScheduledTrip(t, repo, time.Now().Add(5*time.Minute))
+	later := saveScheduledTrip(t, repo, time.Now().Add(2*time.Hour))
+
+	dispatcher.dispatchDueTrips(ctx)
+	dispatcher.dispatchDueTrips(ctx)
+
+	// The due trip is dispatched once, the other one waits for its dispatch lead
+	publisher := &fakePublisher{}
+	NewOutboxRelay(repo, publisher, time.Second, time.Second, 10, nil).relayPending(ctx)
+	assert.Equal(t, []string{"rider:" + contracts.TripEventCreated}, publisher.published)
+
+	dispatched, err := repo.GetTripByID(ctx, due.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, domain.TripStatusPending, dispatched.Status)
+	assert.NotNil(t, dispatched.DispatchedAt)
+	assert.Empty(t, dispatched.DispatchLeaseID)
+
+	timeline, err := repo.GetTripTimeline(ctx, due.ID.Hex())
+	require.NoError(t, err)
+	require.Len(t, timeline, 1)
+	assert.Equal(t, domain.TripStatusPending, timeline[0].To)
+
+	waiting, err := repo.GetTripByID(ctx, later.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, domain.TripStatusScheduled, waiting.Status)
+	assert.Nil(t, waiting.DispatchLeaseUntil)
+}
@@ -0,0 +1,115 @@
+package events
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/shared/contracts"
+	"github.com/Anurag-Mishra22/taxi/shared/messaging"
+	"github.com/Anurag-Mishra22/taxi/shared/metrics"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// timelineConsumer records the events of the other services in the trip timelines
+type timelineConsumer struct {
+	rabbitmq *messaging.RabbitMQ
+	service  domain.TripService
+	metrics  *metrics.Metrics
+}
+
+func NewTimelineConsumer(rabbitmq *messaging.RabbitMQ, service domain.TripService, m *metrics.Metrics) *timelineConsumer {
+	return &timelineConsumer{
+		rabbitmq: rabbitmq,
+		service:  service,
+		metrics:  m,
+	}
+}
+
+func (c *timelineConsumer) Listen() error {
+	return c.rabbitmq.ConsumeMessages(messaging.TripTimelineQueue, c.handle)
+}
+
+func (c *timelineConsumer) handle(ctx context.Context, msg amqp091.Delivery) error {
+	start := time.Now()
+	var message contracts.AmqpMessage
+	if err := json.Unmarshal(msg.Body, &message); err != nil {
+		log.Printf("Failed to unmarshal message: %v", err)
+		return err
+	}
+
+	var (
+		entry *domain.TimelineEntry
+		err   error
+	)
+	switch msg.RoutingKey {
+	case contracts.DriverCmdTripRequest:
+		entry = driverOfferedEntry(message)
+	case contracts.PaymentEventSessionCreated:
+		entry, err = paymentSessionCreatedEntry(message)
+	default:
+		log.Printf("Unknown timeline event: %s", msg.RoutingKey)
+	}
+
+	// A redelivered message records the same event, the repository keeps it once
+	if entry != nil {
+		entry.EventID = eventID(msg)
+		err = c.service.RecordTimelineEntry(ctx, entry)
+	}
+
+	if c.metrics != nil {
+		status := "success"
+		if err != nil {
+			status = "error"
+		}
+		c.metrics.RecordMessageConsumed(messaging.TripTimelineQueue, status, time.Since(start), msg.RoutingKey)
+	}
+
+	return err
+}
+
+// eventID is the message ID of a delivery, or the hash of the message
+// for publishers that don't set message IDs
+func eventID(msg amqp091.Delivery) string {
+	if msg.MessageId != "" {
+		return msg.MessageId
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(msg.RoutingKey + "\n"))
+	hash.Write(msg.Body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func driverOfferedEntry(message contracts.AmqpMessage) *domain.TimelineEntry {
+	var payload messaging.TripEventData
+	if err := json.Unmarshal(message.Data, &payload); err != nil || payload.Trip == nil {
+		log.Printf("Ignoring trip request without trip: %v", err)
+		return nil
+	}
+
+	// The driver-service sends the request to the driver it picked
+	entry := domain.NewTimelineEntry(payload.Trip.Id, domain.TimelineDriverOffered, domain.SystemActor("driver-service"), "trip offered to the driver")
+	entry.DriverID = message.OwnerID
+
+	return entry
+}
+
+func paymentSessionCreatedEntry(message contracts.AmqpMessage) (*domain.TimelineEntry, error) {
+	var payload messaging.PaymentEventSessionCreatedData
+	if err := json.Unmarshal(message.Data, &payload); err != nil {
+		log.Printf("Failed to unmarshal payment session: %v", err)
+		return nil, err
+	}
+
+	reason := fmt.Sprintf("payment session %s created", payload.SessionID)
+	entry := domain.NewTimelineEntry(payload.TripID, domain.TimelinePaymentSessionCreated, domain.SystemActor("payment-service"), reason)
+
+	return entry, nil
+}
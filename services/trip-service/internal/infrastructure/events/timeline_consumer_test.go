@@ -0,0 +1,57 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/infrastructure/repository"
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/service"
+	"github.com/Anurag-Mishra22/taxi/shared/contracts"
+	"github.com/Anurag-Mishra22/taxi/shared/messaging"
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func timelineDelivery(t *testing.T, routingKey, messageID, ownerID string, data any) amqp091.Delivery {
+	payload, err := json.Marshal(data)
+	require.NoError(t, err)
+	body, err := json.Marshal(contracts.AmqpMessage{OwnerID: ownerID, Data: payload})
+	require.NoError(t, err)
+
+	return amqp091.Delivery{RoutingKey: routingKey, MessageId: messageID, Body: body}
+}
+
+func TestTimelineConsumerRecordsAnEventOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInmemRepository()
+	consumer := NewTimelineConsumer(nil, service.NewService(repo, repo, nil, nil, nil, nil, service.Config{}), nil)
+
+	offer := messaging.TripEventData{Trip: &pb.Trip{Id: "trip-1"}}
+	session := messaging.PaymentEventSessionCreatedData{TripID: "trip-1", SessionID: "session-1"}
+
+	deliveries := []amqp091.Delivery{
+		timelineDelivery(t, contracts.DriverCmdTripRequest, "offer-1", "driver-1", offer),
+		// Redelivered
+		timelineDelivery(t, contracts.DriverCmdTripRequest, "offer-1", "driver-1", offer),
+		// Offered again to another driver
+		timelineDelivery(t, contracts.DriverCmdTripRequest, "offer-2", "driver-2", offer),
+		// Without message ID, the duplicates are found by their content
+		timelineDelivery(t, contracts.PaymentEventSessionCreated, "", "rider", session),
+		timelineDelivery(t, contracts.PaymentEventSessionCreated, "", "rider", session),
+	}
+	for _, d := range deliveries {
+		require.NoError(t, consumer.handle(ctx, d))
+	}
+
+	timeline, err := repo.GetTripTimeline(ctx, "trip-1")
+	require.NoError(t, err)
+	require.Len(t, timeline, 3)
+	assert.Equal(t, domain.TimelineDriverOffered, timeline[0].Type)
+	assert.Equal(t, "driver-1", timeline[0].DriverID)
+	assert.Equal(t, "driver-2", timeline[1].DriverID)
+	assert.Equal(t, domain.TimelinePaymentSessionCreated, timeline[2].Type)
+}
@@ -6,48 +6,107 @@ import (
 	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
 	"github.com/Anurag-Mishra22/taxi/shared/contracts"
 	"github.com/Anurag-Mishra22/taxi/shared/messaging"
-	"github.com/Anurag-Mishra22/taxi/shared/metrics"
 	"time"
 )
 
+// TripEventPublisher writes the trip messages to the outbox, in the transaction of the ctx
+// it is given when there is one. The outbox relay publishes them to RabbitMQ afterwards.
 type TripEventPublisher struct {
-	rabbitmq *messaging.RabbitMQ
-	metrics  *metrics.Metrics
+	outbox domain.OutboxRepository
 }
 
-func NewTripEventPublisher(rabbitmq *messaging.RabbitMQ, m *metrics.Metrics) *TripEventPublisher {
+func NewTripEventPublisher(outbox domain.OutboxRepository) *TripEventPublisher {
 	return &TripEventPublisher{
-		rabbitmq: rabbitmq,
-		metrics:  m,
+		outbox: outbox,
 	}
 }
 
 func (p *TripEventPublisher) PublishTripCreated(ctx context.Context, trip *domain.TripModel) error {
-	start := time.Now()
-	payload := messaging.TripEventData{
+	return p.publish(ctx, trip, contracts.TripEventCreated, trip.UserID, messaging.TripEventData{
 		Trip: trip.ToProto(),
+	})
+}
+
+// PublishTripCancelled notifies the assigned driver and asks the payment service to charge
+// the cancellation fee, if any. Without a driver the event has no owner: the rider cancelled
+// the trip themselves, and the drivers with a pending offer get it revoked by the dispatch.
+func (p *TripEventPublisher) PublishTripCancelled(ctx context.Context, trip *domain.TripModel) error {
+	fee := trip.Cancellation.Fee
+
+	ownerID := ""
+	if trip.HasDriver() {
+		ownerID = trip.Driver.Id
 	}
 
-	tripEventJSON, err := json.Marshal(payload)
+	err := p.publish(ctx, trip, contracts.TripEventCancelled, ownerID, messaging.TripCancelledData{
+		Trip:            trip.ToProto(),
+		Reason:          trip.Cancellation.Reason,
+		CancellationFee: fee,
+	})
 	if err != nil {
 		return err
 	}
 
-	err = p.rabbitmq.PublishMessage(ctx, contracts.TripEventCreated, contracts.AmqpMessage{
-		OwnerID: trip.UserID,
-		Data:    tripEventJSON,
+	if fee.AmountMinor <= 0 {
+		return nil
+	}
+
+	return p.publish(ctx, trip, contracts.PaymentCmdChargeCancellationFee, trip.UserID, messaging.PaymentTripResponseData{
+		TripID:   trip.ID.Hex(),
+		UserID:   trip.UserID,
+		DriverID: trip.Driver.Id,
+		Amount:   fee,
+	})
+}
+
+// PublishDriverAssigned notifies the rider that a driver has been assigned
+// and asks the payment service to create the payment session of the ride
+func (p *TripEventPublisher) PublishDriverAssigned(ctx context.Context, trip *domain.TripModel) error {
+	if err := p.publish(ctx, trip, contracts.TripEventDriverAssigned, trip.UserID, trip); err != nil {
+		return err
+	}
+
+	return p.publish(ctx, trip, contracts.PaymentCmdCreateSession, trip.UserID, messaging.PaymentTripResponseData{
+		TripID:   trip.ID.Hex(),
+		UserID:   trip.UserID,
+		DriverID: trip.Driver.Id,
+		Amount:   trip.RideFare.TotalPrice,
+	})
+}
+
+// PublishDriverNotInterested asks for another driver after a driver declined the trip
+func (p *TripEventPublisher) PublishDriverNotInterested(ctx context.Context, trip *domain.TripModel, driverID string) error {
+	return p.publish(ctx, trip, contracts.TripEventDriverNotInterested, trip.UserID, messaging.TripDriverNotInterestedData{
+		Trip:     trip.ToProto(),
+		DriverID: driverID,
+	})
+}
+
+// PublishStopReached lets the rider follow the progress of the trip
+func (p *TripEventPublisher) PublishStopReached(ctx context.Context, trip *domain.TripModel) error {
+	return p.publish(ctx, trip, contracts.TripEventStopReached, trip.UserID, messaging.TripEventData{
+		Trip: trip.ToProto(),
+	})
+}
+
+// PublishTripCompleted tells the rider the ride is over and the driver-service the driver is free
+func (p *TripEventPublisher) PublishTripCompleted(ctx context.Context, trip *domain.TripModel) error {
+	return p.publish(ctx, trip, contracts.TripEventCompleted, trip.UserID, messaging.TripEventData{
+		Trip: trip.ToProto(),
 	})
+}
 
-	if p.metrics != nil {
-		status := "success"
-		if err != nil {
-			status = "error"
-		}
-		p.metrics.RecordMessagePublished("trip", contracts.TripEventCreated, status)
-		if err == nil {
-			p.metrics.MessageProcessingDuration.WithLabelValues("trip", contracts.TripEventCreated).Observe(time.Since(start).Seconds())
-		}
+func (p *TripEventPublisher) publish(ctx context.Context, trip *domain.TripModel, routingKey, ownerID string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
 	}
 
-	return err
+	return p.outbox.AddOutboxMessage(ctx, &domain.OutboxMessage{
+		TripID:     trip.ID.Hex(),
+		RoutingKey: routingKey,
+		OwnerID:    ownerID,
+		Data:       data,
+		CreatedAt:  time.Now(),
+	})
 }
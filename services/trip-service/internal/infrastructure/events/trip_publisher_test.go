@@ -0,0 +1,77 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/infrastructure/repository"
+	tripTypes "github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
+	"github.com/Anurag-Mishra22/taxi/shared/contracts"
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPublishTripCancelled(t *testing.T) {
+	tests := []struct {
+		name     string
+		driver   *pb.TripDriver
+		fee      int64
+		expected []string
+	}{
+		{
+			name:     "without_a_driver_no_client_is_notified",
+			driver:   &pb.TripDriver{},
+			expected: []string{":" + contracts.TripEventCancelled},
+		},
+		{
+			name:     "the_assigned_driver_is_notified",
+			driver:   &pb.TripDriver{Id: "driver"},
+			expected: []string{"driver:" + contracts.TripEventCancelled},
+		},
+		{
+			name:   "the_fee_is_charged_to_the_rider",
+			driver: &pb.TripDriver{Id: "driver"},
+			fee:    500,
+			expected: []string{
+				"driver:" + contracts.TripEventCancelled,
+				"rider:" + contracts.PaymentCmdChargeCancellationFee,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewInmemRepository()
+			trip := &domain.TripModel{
+				ID:     primitive.NewObjectID(),
+				UserID: "rider",
+				Status: domain.TripStatusCancelled,
+				RideFare: &domain.RideFareModel{
+					PackageSlug: "sedan",
+					TotalPrice:  types.NewMoney(1250, "USD"),
+					Route:       &tripTypes.OsrmApiResponse{Routes: []tripTypes.OsrmRoute{{Distance: 5000, Duration: 600}}},
+				},
+				Driver: tt.driver,
+				Cancellation: &domain.TripCancellation{
+					Fee:         types.NewMoney(tt.fee, "USD"),
+					CancelledAt: time.Now(),
+				},
+			}
+
+			_, err := repo.CreateTrip(context.Background(), trip)
+			require.NoError(t, err)
+
+			require.NoError(t, NewTripEventPublisher(repo).PublishTripCancelled(context.Background(), trip))
+
+			publisher := &fakePublisher{}
+			NewOutboxRelay(repo, publisher, time.Second, time.Second, 10, nil).relayPending(context.Background())
+
+			assert.Equal(t, tt.expected, publisher.published)
+		})
+	}
+}
@@ -2,13 +2,16 @@ package grpc
 
 import (
 	"context"
-	"log"
+	"errors"
 	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
 	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/infrastructure/events"
 	"github.com/Anurag-Mishra22/taxi/shared/metrics"
 	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
 	"github.com/Anurag-Mishra22/taxi/shared/types"
+	"log"
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -39,16 +42,26 @@ func (h *gRPCHandler) CreateTrip(ctx context.Context, req *pb.CreateTripRequest)
 
 	rideFare, err := h.service.GetAndValidateFare(ctx, fareID, userID)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to validate the fare: %v", err)
+		return nil, fareError("failed to validate the fare", err)
 	}
 
-	trip, err := h.service.CreateTrip(ctx, rideFare)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create the trip: %v", err)
-	}
+	// The created event is written with the trip, a trip is never left undispatched
+	var trip *domain.TripModel
+	err = h.service.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		trip, err = h.service.CreateTrip(ctx, rideFare)
+		if err != nil {
+			return err
+		}
 
-	if err := h.publisher.PublishTripCreated(ctx, trip); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to publish the trip created event: %v", err)
+		// Scheduled trips are published by the scheduler, shortly before the pickup
+		if trip.IsScheduled() {
+			return nil
+		}
+		return h.publisher.PublishTripCreated(ctx, trip)
+	})
+	if err != nil {
+		return nil, fareError("failed to create the trip", err)
 	}
 
 	return &pb.CreateTripResponse{
@@ -56,6 +69,141 @@ func (h *gRPCHandler) CreateTrip(ctx context.Context, req *pb.CreateTripRequest)
 	}, nil
 }
 
+func (h *gRPCHandler) CancelTrip(ctx context.Context, req *pb.CancelTripRequest) (*pb.CancelTripResponse, error) {
+	if req.GetTripID() == "" || req.GetUserID() == "" {
+		return nil, status.Error(codes.InvalidArgument, "trip ID and user ID are required")
+	}
+
+	var trip *domain.TripModel
+	err := h.service.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		trip, err = h.service.CancelTrip(ctx, req.GetTripID(), req.GetUserID(), req.GetReason())
+		if err != nil {
+			return err
+		}
+		return h.publisher.PublishTripCancelled(ctx, trip)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrTripNotFound):
+			return nil, status.Errorf(codes.NotFound, "failed to cancel the trip: %v", err)
+		case errors.Is(err, domain.ErrTripNotOwned):
+			return nil, status.Errorf(codes.PermissionDenied, "failed to cancel the trip: %v", err)
+		case errors.Is(err, domain.ErrInvalidTransition):
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to cancel the trip: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to cancel the trip: %v", err)
+	}
+
+	return &pb.CancelTripResponse{
+		Trip:            trip.ToProto(),
+		CancellationFee: trip.Cancellation.Fee.ToProto(),
+	}, nil
+}
+
+func (h *gRPCHandler) GetTrip(ctx context.Context, req *pb.GetTripRequest) (*pb.GetTripResponse, error) {
+	if req.GetUserID() == "" && req.GetDriverID() == "" {
+		return nil, status.Error(codes.InvalidArgument, "userID or driverID is required")
+	}
+
+	trip, err := h.service.GetTrip(ctx, req.GetTripID(), req.GetUserID(), req.GetDriverID())
+	if errors.Is(err, domain.ErrTripNotFound) {
+		return nil, status.Errorf(codes.NotFound, "trip not found: %s", req.GetTripID())
+	}
+	if errors.Is(err, domain.ErrTripNotOwned) {
+		return nil, status.Errorf(codes.PermissionDenied, "failed to get the trip: %v", err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get the trip: %v", err)
+	}
+
+	return &pb.GetTripResponse{
+		Trip: trip.ToProto(),
+	}, nil
+}
+
+func (h *gRPCHandler) GetTripTimeline(ctx context.Context, req *pb.GetTripTimelineRequest) (*pb.GetTripTimelineResponse, error) {
+	if req.GetUserID() == "" && req.GetDriverID() == "" {
+		return nil, status.Error(codes.InvalidArgument, "userID or driverID is required")
+	}
+
+	entries, err := h.service.GetTripTimeline(ctx, req.GetTripID(), req.GetUserID(), req.GetDriverID())
+	if errors.Is(err, domain.ErrTripNotFound) {
+		return nil, status.Errorf(codes.NotFound, "trip not found: %s", req.GetTripID())
+	}
+	if errors.Is(err, domain.ErrTripNotOwned) {
+		return nil, status.Errorf(codes.PermissionDenied, "failed to get the trip timeline: %v", err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get the trip timeline: %v", err)
+	}
+
+	return &pb.GetTripTimelineResponse{
+		Entries: domain.ToTimelineProto(entries),
+	}, nil
+}
+
+func (h *gRPCHandler) ListTrips(ctx context.Context, req *pb.ListTripsRequest) (*pb.ListTripsResponse, error) {
+	if token := req.GetPageToken(); token != "" && !primitive.IsValidObjectID(token) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid page token: %s", token)
+	}
+
+	filter := domain.TripFilter{
+		UserID:   req.GetUserID(),
+		DriverID: req.GetDriverID(),
+		Status:   domain.TripStatus(req.GetStatus()),
+		AfterID:  req.GetPageToken(),
+		Limit:    int(req.GetPageSize()),
+	}
+	if req.GetCreatedFrom() != nil {
+		from := req.GetCreatedFrom().AsTime()
+		filter.CreatedFrom = &from
+	}
+	if req.GetCreatedTo() != nil {
+		to := req.GetCreatedTo().AsTime()
+		filter.CreatedTo = &to
+	}
+
+	trips, nextPageToken, err := h.service.ListTrips(ctx, filter)
+	if errors.Is(err, domain.ErrUnscopedTripList) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list trips: %v", err)
+	}
+
+	return &pb.ListTripsResponse{
+		Trips:         domain.ToTripsProto(trips),
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+func (h *gRPCHandler) ListScheduledTrips(ctx context.Context, req *pb.ListScheduledTripsRequest) (*pb.ListScheduledTripsResponse, error) {
+	if req.GetUserID() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user ID is required")
+	}
+
+	trips, err := h.service.ListScheduledTrips(ctx, req.GetUserID())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list scheduled trips: %v", err)
+	}
+
+	return &pb.ListScheduledTripsResponse{
+		Trips: domain.ToTripsProto(trips),
+	}, nil
+}
+
+func (h *gRPCHandler) ListPackages(ctx context.Context, req *pb.ListPackagesRequest) (*pb.ListPackagesResponse, error) {
+	packages, err := h.service.ListPackages(ctx, req.GetIncludeDisabled())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list packages: %v", err)
+	}
+
+	return &pb.ListPackagesResponse{
+		Packages: domain.ToPackagesProto(packages),
+	}, nil
+}
+
 func (h *gRPCHandler) PreviewTrip(ctx context.Context, req *pb.PreviewTripRequest) (*pb.PreviewTripResponse, error) {
 	pickup := req.GetStartLocation()
 	destination := req.GetEndLocation()
@@ -71,14 +219,52 @@ func (h *gRPCHandler) PreviewTrip(ctx context.Context, req *pb.PreviewTripReques
 
 	userID := req.GetUserID()
 
-	// CHANGE THE LAST ARG TO "FALSE" if the OSRM API is not working right now
-	route, err := h.service.GetRoute(ctx, pickupCoord, destinationCoord, true)
+	if len(req.GetStops()) > domain.MaxTripStops {
+		return nil, status.Error(codes.InvalidArgument, domain.ErrTooManyStops.Error())
+	}
+
+	stops := make([]*types.Coordinate, len(req.GetStops()))
+	for i, stop := range req.GetStops() {
+		stops[i] = &types.Coordinate{
+			Latitude:  stop.GetLatitude(),
+			Longitude: stop.GetLongitude(),
+		}
+	}
+
+	// Checked before the route lookup, there is no point routing a ride no driver can take
+	zone, err := h.service.GetServiceZone(ctx, pickupCoord)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	route, err := h.service.GetRoute(ctx, pickupCoord, destinationCoord, stops)
 	if err != nil {
 		log.Println(err)
-		return nil, status.Errorf(codes.Internal, "failed to get route: %v", err)
+		if errors.Is(err, domain.ErrRouteNotFound) {
+			return nil, status.Errorf(codes.InvalidArgument, "no route between the locations")
+		}
+		return nil, status.Errorf(codes.Unavailable, "failed to get route: %v", err)
 	}
 
-	estimatedFares := h.service.EstimatePackagesPriceWithRoute(route)
+	var scheduledFor *time.Time
+	if req.GetScheduledFor() != nil {
+		t := req.GetScheduledFor().AsTime()
+		scheduledFor = &t
+	}
+
+	estimatedFares, err := h.service.EstimatePackagesPriceWithRoute(ctx, route, zone, pickupCoord, stops, scheduledFor)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidSchedule) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to estimate the ride fares: %v", err)
+	}
+
+	if code := req.GetPromoCode(); code != "" {
+		if err := h.service.ApplyPromotion(ctx, estimatedFares, userID, code); err != nil {
+			return nil, promotionError(err)
+		}
+	}
 
 	fares, err := h.service.GenerateTripFares(ctx, estimatedFares, userID, route)
 	if err != nil {
@@ -90,3 +276,34 @@ func (h *gRPCHandler) PreviewTrip(ctx context.Context, req *pb.PreviewTripReques
 		RideFares: domain.ToRideFaresProto(fares),
 	}, nil
 }
+
+// fareError maps fare validation failures to distinct gRPC codes,
+// so clients can tell a stale quote apart from a reused one
+func fareError(msg string, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrFareNotFound):
+		return status.Errorf(codes.NotFound, "%s: %v", msg, err)
+	case errors.Is(err, domain.ErrFareNotOwned):
+		return status.Errorf(codes.PermissionDenied, "%s: %v", msg, err)
+	case errors.Is(err, domain.ErrFareExpired):
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", msg, err)
+	case errors.Is(err, domain.ErrFareConsumed):
+		return status.Errorf(codes.AlreadyExists, "%s: %v", msg, err)
+	case errors.Is(err, domain.ErrPromotionExhausted):
+		return status.Errorf(codes.ResourceExhausted, "%s: %v", msg, err)
+	}
+	return status.Errorf(codes.Internal, "%s: %v", msg, err)
+}
+
+// promotionError reports the codes riders can't use as invalid arguments,
+// their message is meant to be shown as is
+func promotionError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrPromotionNotFound),
+		errors.Is(err, domain.ErrPromotionExpired),
+		errors.Is(err, domain.ErrPromotionNotApplicable),
+		errors.Is(err, domain.ErrPromotionExhausted):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Errorf(codes.Internal, "failed to apply the promotion code: %v", err)
+}
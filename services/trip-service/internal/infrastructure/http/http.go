@@ -27,8 +27,7 @@ func (s *HttpHandler) HandleTripPreview(w http.ResponseWriter, r *http.Request)
 
 	ctx := r.Context()
 
-	// CHANGE THE LAST ARG TO "FALSE" if the OSRM API is not working right now
-	t, err := s.Service.GetRoute(ctx, &reqBody.Pickup, &reqBody.Destination, true)
+	t, err := s.Service.GetRoute(ctx, &reqBody.Pickup, &reqBody.Destination, nil)
 	if err != nil {
 		log.Println(err)
 	}
@@ -0,0 +1,782 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	pbd "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testTripRepository is the contract of domain.TripRepository, every implementation
+// runs it with a factory returning an empty repository
+func testTripRepository(t *testing.T, newRepo func(t *testing.T) domain.TripRepository) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo domain.TripRepository)
+	}{
+		{"missing_records_are_not_found", testMissingRecords},
+		{"trips_are_stored_and_copied", testCreateAndGetTrip},
+		{"a_fare_starts_one_trip", testFareStartsOneTrip},
+		{"fares_are_consumed_once", testConsumeRideFare},
+		{"status_updates_follow_the_transitions", testUpdateTrip},
+		{"cancellations_follow_the_transitions", testCancelTrip},
+		{"trips_are_listed_newest_first", testListTrips},
+		{"scheduled_trips_are_claimed_once", testScheduledTrips},
+		{"only_the_lease_holder_dispatches", testDispatchLeases},
+		{"stops_are_reached_in_order", testReachStop},
+		{"the_first_payment_is_kept", testMarkTripPaid},
+		{"timelines_are_kept_in_order", testTimeline},
+		{"timeline_events_are_recorded_once", testTimelineEvents},
+		{"transactions_return_the_error_of_fn", testWithTransaction},
+		{"concurrent_updates_are_atomic", testConcurrentUpdates},
+		{"the_first_accept_wins", testConcurrentAccepts},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepo(t))
+		})
+	}
+}
+
+// saveTrip stores a fare and a trip created from it
+func saveTrip(t *testing.T, repo domain.TripRepository, userID string, status domain.TripStatus) *domain.TripModel {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+
+	fare := &domain.RideFareModel{
+		UserID:      userID,
+		PackageSlug: "sedan",
+		TotalPrice:  types.NewMoney(1250, "USD"),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(5 * time.Minute),
+	}
+	require.NoError(t, repo.SaveRideFare(ctx, fare))
+	require.False(t, fare.ID.IsZero())
+
+	trip, err := repo.CreateTrip(ctx, &domain.TripModel{
+		UserID:    userID,
+		Status:    status,
+		RideFare:  fare,
+		CreatedAt: now,
+	})
+	require.NoError(t, err)
+	require.False(t, trip.ID.IsZero())
+
+	return trip
+}
+
+func assertNotFound(t *testing.T, err error, target error) {
+	t.Helper()
+	assert.True(t, errors.Is(err, target), "expected %v, got %v", target, err)
+	assert.True(t, errors.Is(err, domain.ErrNotFound), "expected ErrNotFound, got %v", err)
+}
+
+func testMissingRecords(t *testing.T, repo domain.TripRepository) {
+	ctx := context.Background()
+	missingID := primitive.NewObjectID().Hex()
+
+	for _, id := range []string{missingID, "not-an-id"} {
+		trip, err := repo.GetTripByID(ctx, id)
+		assert.Nil(t, trip)
+		assertNotFound(t, err, domain.ErrTripNotFound)
+
+		fare, err := repo.GetRideFareByID(ctx, id)
+		assert.Nil(t, fare)
+		assertNotFound(t, err, domain.ErrFareNotFound)
+
+		assertNotFound(t, repo.ConsumeRideFare(ctx, id), domain.ErrFareNotFound)
+		assertNotFound(t, repo.UpdateTrip(ctx, id, domain.TripStatusDriverAssigned, nil), domain.ErrTripNotFound)
+		assertNotFound(t, repo.CancelTrip(ctx, id, &domain.TripCancellation{}), domain.ErrTripNotFound)
+		assertNotFound(t, repo.MarkTripDispatched(ctx, id, "lease"), domain.ErrTripNotFound)
+		assertNotFound(t, repo.ReachStop(ctx, id, "driver-1", 0, time.Now()), domain.ErrTripNotFound)
+		assertNotFound(t, repo.MarkTripPaid(ctx, id, time.Now()), domain.ErrTripNotFound)
+	}
+}
+
+func testCreateAndGetTrip(t *testing.T, repo domain.TripRepository) {
+	ctx := context.Background()
+	created := saveTrip(t, repo, "user-1", domain.TripStatusPending)
+
+	trip, err := repo.GetTripByID(ctx, created.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, trip.ID)
+	assert.Equal(t, "user-1", trip.UserID)
+	assert.Equal(t, domain.TripStatusPending, trip.Status)
+	assert.Equal(t, created.RideFare.ID, trip.RideFare.ID)
+	assert.True(t, created.CreatedAt.Equal(trip.CreatedAt))
+
+	// Changing a returned trip doesn't change the stored one
+	trip.Status = domain.TripStatusPaid
+	trip.RideFare.PackageSlug = "van"
+
+	stored, err := repo.GetTripByID(ctx, created.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, domain.TripStatusPending, stored.Status)
+	assert.Equal(t, "sedan", stored.RideFare.PackageSlug)
+
+	fare, err := repo.GetRideFareByID(ctx, created.RideFare.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", fare.UserID)
+	assert.Equal(t, types.NewMoney(1250, "USD"), fare.TotalPrice)
+}
+
+func testFareStartsOneTrip(t *testing.T, repo domain.TripRepository) {
+	trip := saveTrip(t, repo, "user-1", domain.TripStatusPending)
+
+	_, err := repo.CreateTrip(context.Background(), &domain.TripModel{
+		UserID:    "user-1",
+		Status:    domain.TripStatusPending,
+		RideFare:  trip.RideFare,
+		CreatedAt: time.Now(),
+	})
+	assert.True(t, errors.Is(err, domain.ErrFareConsumed), "got %v", err)
+}
+
+func testConsumeRideFare(t *testing.T, repo domain.TripRepository) {
+	ctx := context.Background()
+	fare := &domain.RideFareModel{UserID: "user-1", PackageSlug: "sedan", TotalPrice: types.NewMoney(900, "USD")}
+	require.NoError(t, repo.SaveRideFare(ctx, fare))
+
+	require.NoError(t, repo.ConsumeRideFare(ctx, fare.ID.Hex()))
+	assert.True(t, errors.Is(repo.ConsumeRideFare(ctx, fare.ID.Hex()), domain.ErrFareConsumed))
+
+	stored, err := repo.GetRideFareByID(ctx, fare.ID.Hex())
+	require.NoError(t, err)
+	assert.NotNil(t, stored.ConsumedAt)
+
+	// A released fare can be consumed again
+	require.NoError(t, repo.ReleaseRideFare(ctx, fare.ID.Hex()))
+	stored, err = repo.GetRideFareByID(ctx, fare.ID.Hex())
+	require.NoError(t, err)
+	assert.Nil(t, stored.ConsumedAt)
+	require.NoError(t, repo.ConsumeRideFare(ctx, fare.ID.Hex()))
+
+	assertNotFound(t, repo.ReleaseRideFare(ctx, primitive.NewObjectID().Hex()), domain.ErrFareNotFound)
+}
+
+func testUpdateTrip(t *testing.T, repo domain.TripRepository) {
+	ctx := context.Background()
+	trip := saveTrip(t, repo, "user-1", domain.TripStatusPending)
+	id := trip.ID.Hex()
+
+	driver := &pbd.Driver{Id: "driver-1", Name: "Lisa", CarPlate: "AB-123"}
+	require.NoError(t, repo.UpdateTrip(ctx, id, domain.TripStatusDriverAssigned, driver))
+
+	updated, err := repo.GetTripByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, domain.TripStatusDriverAssigned, updated.Status)
+	assert.NotNil(t, updated.DriverAssignedAt)
+	require.True(t, updated.HasDriver())
+	assert.Equal(t, "driver-1", updated.Driver.Id)
+	assert.Equal(t, "AB-123", updated.Driver.CarPlate)
+
+	// A trip can't go back to pending once a driver is assigned
+	err = repo.UpdateTrip(ctx, id, domain.TripStatusPending, nil)
+	assert.True(t, errors.Is(err, domain.ErrInvalidTransition), "got %v", err)
+
+	require.NoError(t, repo.UpdateTrip(ctx, id, domain.TripStatusPaid, nil))
+	err = repo.UpdateTrip(ctx, id, domain.TripStatusInProgress, nil)
+	assert.True(t, errors.Is(err, domain.ErrInvalidTransition), "got %v", err)
+
+	// The driver is kept when a later update has none
+	paid, err := repo.GetTripByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, domain.TripStatusPaid, paid.Status)
+	assert.Equal(t, "driver-1", paid.Driver.Id)
+}
+
+func testCancelTrip(t *testing.T, repo domain.TripRepository) {
+	ctx := context.Background()
+	trip := saveTrip(t, repo, "user-1", domain.TripStatusPending)
+	id := trip.ID.Hex()
+
+	cancellation := &domain.TripCancellation{
+		Reason:      "changed my mind",
+		Fee:         types.NewMoney(0, "USD"),
+		CancelledAt: time.Now().Truncate(time.Millisecond),
+	}
+	require.NoError(t, repo.CancelTrip(ctx, id, cancellation))
+
+	cancelled, err := repo.GetTripByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, domain.TripStatusCancelled, cancelled.Status)
+	require.NotNil(t, cancelled.Cancellation)
+	assert.Equal(t, "changed my mind", cancelled.Cancellation.Reason)
+
+	err = repo.CancelTrip(ctx, id, cancellation)
+	assert.True(t, errors.Is(err, domain.ErrInvalidTransition), "got %v", err)
+}
+
+func testListTrips(t *testing.T, repo domain.TripRepository) {
+	ctx := context.Background()
+	first := saveTrip(t, repo, "user-1", domain.TripStatusPending)
+	second := saveTrip(t, repo, "user-1", domain.TripStatusCancelled)
+	third := saveTrip(t, repo, "user-1", domain.TripStatusPending)
+	saveTrip(t, repo, "user-2", domain.TripStatusPending)
+
+	trips, err := repo.ListTrips(ctx, domain.TripFilter{UserID: "user-1"})
+	require.NoError(t, err)
+	require.Len(t, trips, 3)
+	assert.Equal(t, third.ID, trips[0].ID)
+	assert.Equal(t, second.ID, trips[1].ID)
+	assert.Equal(t, first.ID, trips[2].ID)
+
+	page, err := repo.ListTrips(ctx, domain.TripFilter{UserID: "user-1", Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+
+	next, err := repo.ListTrips(ctx, domain.TripFilter{UserID: "user-1", AfterID: page[1].ID.Hex(), Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, next, 1)
+	assert.Equal(t, first.ID, next[0].ID)
+
+	pending, err := repo.ListTrips(ctx, domain.TripFilter{UserID: "user-1", Status: domain.TripStatusPending})
+	require.NoError(t, err)
+	assert.Len(t, pending, 2)
+
+	none, err := repo.ListTrips(ctx, domain.TripFilter{UserID: "user-3"})
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	_, err = repo.ListTrips(ctx, domain.TripFilter{Status: domain.TripStatusPending})
+	assert.True(t, errors.Is(err, domain.ErrUnscopedTripList))
+}
+
+func testScheduledTrips(t *testing.T, repo domain.TripRepository) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+
+	later := saveScheduledTrip(t, repo, now.Add(2*time.Hour))
+	due := saveScheduledTrip(t, repo, now.Add(-time.Minute))
+
+	scheduled, err := repo.ListScheduledTrips(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, scheduled, 2)
+	assert.Equal(t, due.ID, scheduled[0].ID)
+	assert.Equal(t, later.ID, scheduled[1].ID)
+
+	claimed, err := repo.ClaimDueScheduledTrip(ctx, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, due.ID, claimed.ID)
+	assert.Equal(t, domain.TripStatusPending, claimed.Status)
+
+	// The trip is leased to this claim, the other one isn't due yet
+	again, err := repo.ClaimDueScheduledTrip(ctx, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, again)
+
+	assert.NotEmpty(t, claimed.DispatchLeaseID)
+	require.NoError(t, repo.MarkTripDispatched(ctx, due.ID.Hex(), claimed.DispatchLeaseID))
+	dispatched, err := repo.GetTripByID(ctx, due.ID.Hex())
+	require.NoError(t, err)
+	assert.NotNil(t, dispatched.DispatchedAt)
+	assert.Nil(t, dispatched.DispatchLeaseUntil)
+	assert.True(t, errors.Is(repo.MarkTripDispatched(ctx, due.ID.Hex(), claimed.DispatchLeaseID), domain.ErrDispatchLeaseLost))
+
+	// Once dispatched, the trip is never claimed again even after its lease
+	again, err = repo.ClaimDueScheduledTrip(ctx, now.Add(time.Hour), now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Nil(t, again)
+}
+
+func testDispatchLeases(t *testing.T, repo domain.TripRepository) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+	due := saveScheduledTrip(t, repo, now.Add(-time.Minute))
+
+	// The first claim stalls past its lease and a second replica takes the trip over
+	stalled, err := repo.ClaimDueScheduledTrip(ctx, now, now.Add(-time.Second))
+	require.NoError(t, err)
+	require.NotNil(t, stalled)
+
+	current, err := repo.ClaimDueScheduledTrip(ctx, now, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	assert.Equal(t, due.ID, current.ID)
+	assert.NotEqual(t, stalled.DispatchLeaseID, current.DispatchLeaseID)
+
+	assert.True(t, errors.Is(repo.MarkTripDispatched(ctx, due.ID.Hex(), stalled.DispatchLeaseID), domain.ErrDispatchLeaseLost))
+	require.NoError(t, repo.MarkTripDispatched(ctx, due.ID.Hex(), current.DispatchLeaseID))
+}
+
+func saveScheduledTrip(t *testing.T, repo domain.TripRepository, pickup time.Time) *domain.TripModel {
+	fare := &domain.RideFareModel{UserID: "user-1", PackageSlug: "sedan", TotalPrice: types.NewMoney(1250, "USD")}
+	require.NoError(t, repo.SaveRideFare(context.Background(), fare))
+
+	trip, err := repo.CreateTrip(context.Background(), &domain.TripModel{
+		UserID:       "user-1",
+		Status:       domain.TripStatusScheduled,
+		RideFare:     fare,
+		CreatedAt:    time.Now(),
+		ScheduledFor: &pickup,
+	})
+	require.NoError(t, err)
+	return trip
+}
+
+func testMarkTripPaid(t *testing.T, repo domain.TripRepository) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+	trip := saveTrip(t, repo, "user-1", domain.TripStatusCompleted)
+
+	require.NoError(t, repo.MarkTripPaid(ctx, trip.ID.Hex(), now))
+	require.NoError(t, repo.MarkTripPaid(ctx, trip.ID.Hex(), now.Add(time.Minute)))
+
+	paid, err := repo.GetTripByID(ctx, trip.ID.Hex())
+	require.NoError(t, err)
+	require.NotNil(t, paid.PaidAt)
+	assert.True(t, now.Equal(*paid.PaidAt))
+	// The status is left to the lifecycle
+	assert.Equal(t, domain.TripStatusCompleted, paid.Status)
+}
+
+func testReachStop(t *testing.T, repo domain.TripRepository) {
+	ctx := context.Background()
+	fare := &domain.RideFareModel{UserID: "user-1", PackageSlug: "sedan", TotalPrice: types.NewMoney(1250, "USD")}
+	require.NoError(t, repo.SaveRideFare(ctx, fare))
+
+	trip, err := repo.CreateTrip(ctx, &domain.TripModel{
+		UserID:    "user-1",
+		Status:    domain.TripStatusDriverAssigned,
+		RideFare:  fare,
+		Driver:    &pb.TripDriver{Id: "driver-1"},
+		CreatedAt: time.Now(),
+		Stops: domain.NewTripStops([]*types.Coordinate{
+			{Latitude: 38.72, Longitude: -9.14},
+			{Latitude: 38.73, Longitude: -9.15},
+		}),
+	})
+	require.NoError(t, err)
+	id := trip.ID.Hex()
+
+	err = repo.ReachStop(ctx, id, "driver-2", 0, time.Now())
+	assert.True(t, errors.Is(err, domain.ErrTripNotOwned), "got %v", err)
+
+	err = repo.ReachStop(ctx, id, "driver-1", 1, time.Now())
+	assert.True(t, errors.Is(err, domain.ErrInvalidStop), "got %v", err)
+
+	require.NoError(t, repo.ReachStop(ctx, id, "driver-1", 0, time.Now()))
+
+	// A duplicated command for the same stop is rejected
+	err = repo.ReachStop(ctx, id, "driver-1", 0, time.Now())
+	assert.True(t, errors.Is(err, domain.ErrInvalidStop), "got %v", err)
+
+	reached, err := repo.GetTripByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reached.CurrentLeg)
+	assert.NotNil(t, reached.Stops[0].ReachedAt)
+	assert.Nil(t, reached.Stops[1].ReachedAt)
+}
+
+func testTimeline(t *testing.T, repo domain.TripRepository) {
+	ctx := context.Background()
+
+	empty, err := repo.GetTripTimeline(ctx, "trip-1")
+	require.NoError(t, err)
+	assert.NotNil(t, empty)
+	assert.Empty(t, empty)
+
+	entries := []*domain.TimelineEntry{
+		domain.NewStatusChange("trip-1", "", domain.TripStatusPending, domain.RiderActor("user-1"), "trip requested"),
+		domain.NewTimelineEntry("trip-2", domain.TimelineDriverOffered, domain.SystemActor("driver-service"), "trip offered to the driver"),
+		domain.NewTimelineEntry("trip-1", domain.TimelineDriverDeclined, domain.DriverActor("driver-1"), "driver declined the trip"),
+	}
+	for _, entry := range entries {
+		require.NoError(t, repo.AppendTimelineEntry(ctx, entry))
+		assert.False(t, entry.ID.IsZero())
+	}
+
+	timeline, err := repo.GetTripTimeline(ctx, "trip-1")
+	require.NoError(t, err)
+	require.Len(t, timeline, 2)
+	assert.Equal(t, domain.TimelineStatusChanged, timeline[0].Type)
+	assert.Equal(t, domain.TripStatusPending, timeline[0].To)
+	assert.Equal(t, domain.TimelineDriverDeclined, timeline[1].Type)
+	assert.Equal(t, domain.DriverActor("driver-1"), timeline[1].Actor)
+}
+
+func testTimelineEvents(t *testing.T, repo domain.TripRepository) {
+	ctx := context.Background()
+
+	offer := func(tripID, eventID string) *domain.TimelineEntry {
+		entry := domain.NewTimelineEntry(tripID, domain.TimelineDriverOffered, domain.SystemActor("driver-service"), "trip offered to the driver")
+		entry.EventID = eventID
+		return entry
+	}
+
+	// A redelivered message, another event, and the same message ID in another trip
+	for _, entry := range []*domain.TimelineEntry{
+		offer("trip-1", "message-1"),
+		offer("trip-1", "message-1"),
+		offer("trip-1", "message-2"),
+		offer("trip-2", "message-1"),
+	} {
+		require.NoError(t, repo.AppendTimelineEntry(ctx, entry))
+	}
+
+	timeline, err := repo.GetTripTimeline(ctx, "trip-1")
+	require.NoError(t, err)
+	require.Len(t, timeline, 2)
+	assert.Equal(t, "message-1", timeline[0].EventID)
+	assert.Equal(t, "message-2", timeline[1].EventID)
+
+	timeline, err = repo.GetTripTimeline(ctx, "trip-2")
+	require.NoError(t, err)
+	assert.Len(t, timeline, 1)
+}
+
+func testWithTransaction(t *testing.T, repo domain.TripRepository) {
+	ctx := context.Background()
+	errAborted := errors.New("aborted")
+
+	err := repo.WithTransaction(ctx, func(ctx context.Context) error {
+		return errAborted
+	})
+	assert.True(t, errors.Is(err, errAborted), "got %v", err)
+
+	trip := saveTrip(t, repo, "user-1", domain.TripStatusPending)
+	err = repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.UpdateTrip(ctx, trip.ID.Hex(), domain.TripStatusNoDriver, nil); err != nil {
+			return err
+		}
+		return repo.AppendTimelineEntry(ctx, domain.NewStatusChange(trip.ID.Hex(), domain.TripStatusPending, domain.TripStatusNoDriver, domain.SystemActor("driver-service"), "no driver found"))
+	})
+	require.NoError(t, err)
+
+	committed, err := repo.GetTripByID(ctx, trip.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, domain.TripStatusNoDriver, committed.Status)
+
+	timeline, err := repo.GetTripTimeline(ctx, trip.ID.Hex())
+	require.NoError(t, err)
+	assert.Len(t, timeline, 1)
+
+	// A failed transaction rolls back every write made in it
+	fare := &domain.RideFareModel{UserID: "user-1", PackageSlug: "sedan", TotalPrice: types.NewMoney(900, "USD")}
+	require.NoError(t, repo.SaveRideFare(ctx, fare))
+	err = repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.ConsumeRideFare(ctx, fare.ID.Hex()); err != nil {
+			return err
+		}
+		if err := repo.UpdateTrip(ctx, trip.ID.Hex(), domain.TripStatusPending, nil); err != nil {
+			return err
+		}
+		if err := repo.AppendTimelineEntry(ctx, domain.NewStatusChange(trip.ID.Hex(), domain.TripStatusNoDriver, domain.TripStatusPending, domain.RiderActor("user-1"), "retried")); err != nil {
+			return err
+		}
+		return errAborted
+	})
+	assert.True(t, errors.Is(err, errAborted), "got %v", err)
+
+	rolledBack, err := repo.GetTripByID(ctx, trip.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, domain.TripStatusNoDriver, rolledBack.Status)
+
+	timeline, err = repo.GetTripTimeline(ctx, trip.ID.Hex())
+	require.NoError(t, err)
+	assert.Len(t, timeline, 1)
+
+	stored, err := repo.GetRideFareByID(ctx, fare.ID.Hex())
+	require.NoError(t, err)
+	assert.Nil(t, stored.ConsumedAt)
+}
+
+func testConcurrentUpdates(t *testing.T, repo domain.TripRepository) {
+	ctx := context.Background()
+	trip := saveTrip(t, repo, "user-1", domain.TripStatusPending)
+
+	fare := &domain.RideFareModel{UserID: "user-1", PackageSlug: "sedan", TotalPrice: types.NewMoney(900, "USD")}
+	require.NoError(t, repo.SaveRideFare(ctx, fare))
+
+	const workers = 10
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		assigned   int
+		consumed   int
+		unexpected []error
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			assignErr := repo.UpdateTrip(ctx, trip.ID.Hex(), domain.TripStatusDriverAssigned, &pbd.Driver{Id: "driver-1"})
+			consumeErr := repo.ConsumeRideFare(ctx, fare.ID.Hex())
+			_, getErr := repo.GetTripByID(ctx, trip.ID.Hex())
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case assignErr == nil:
+				assigned++
+			case !errors.Is(assignErr, domain.ErrInvalidTransition):
+				unexpected = append(unexpected, assignErr)
+			}
+			switch {
+			case consumeErr == nil:
+				consumed++
+			case !errors.Is(consumeErr, domain.ErrFareConsumed):
+				unexpected = append(unexpected, consumeErr)
+			}
+			if getErr != nil {
+				unexpected = append(unexpected, getErr)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Empty(t, unexpected)
+	assert.Equal(t, 1, assigned)
+	assert.Equal(t, 1, consumed)
+}
+
+// testConcurrentAccepts is the claim of a broadcast dispatch: several drivers accept the
+// same trip at once and only one of them is assigned
+func testConcurrentAccepts(t *testing.T, repo domain.TripRepository) {
+	ctx := context.Background()
+	trip := saveTrip(t, repo, "user-1", domain.TripStatusPending)
+
+	const drivers = 5
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		winners    []string
+		unexpected []error
+	)
+
+	for i := 0; i < drivers; i++ {
+		wg.Add(1)
+		go func(driverID string) {
+			defer wg.Done()
+
+			err := repo.UpdateTrip(ctx, trip.ID.Hex(), domain.TripStatusDriverAssigned, &pbd.Driver{Id: driverID})
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				winners = append(winners, driverID)
+			case !errors.Is(err, domain.ErrInvalidTransition):
+				unexpected = append(unexpected, err)
+			}
+		}(fmt.Sprintf("driver-%d", i))
+	}
+	wg.Wait()
+
+	assert.Empty(t, unexpected)
+	require.Len(t, winners, 1)
+
+	stored, err := repo.GetTripByID(ctx, trip.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, winners[0], stored.Driver.Id)
+}
+
+// outboxRepository stores the trips and their outbox messages
+type outboxRepository interface {
+	domain.TripRepository
+	domain.OutboxRepository
+}
+
+// testOutboxRepository is the contract of domain.OutboxRepository
+func testOutboxRepository(t *testing.T, newRepo func(t *testing.T) outboxRepository) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo outboxRepository)
+	}{
+		{"messages_are_numbered_per_trip", testOutboxSequence},
+		{"messages_of_a_trip_are_published_in_sequence", testOutboxOrder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepo(t))
+		})
+	}
+}
+
+func addOutboxMessage(t *testing.T, repo outboxRepository, message *domain.OutboxMessage) *domain.OutboxMessage {
+	message.CreatedAt = time.Now().Truncate(time.Millisecond)
+	require.NoError(t, repo.AddOutboxMessage(context.Background(), message))
+	return message
+}
+
+func testOutboxSequence(t *testing.T, repo outboxRepository) {
+	ctx := context.Background()
+	a := saveTrip(t, repo, "user-1", domain.TripStatusPending)
+	b := saveTrip(t, repo, "user-2", domain.TripStatusPending)
+
+	assert.Equal(t, int64(1), addOutboxMessage(t, repo, &domain.OutboxMessage{TripID: a.ID.Hex()}).Sequence)
+	assert.Equal(t, int64(2), addOutboxMessage(t, repo, &domain.OutboxMessage{TripID: a.ID.Hex()}).Sequence)
+	assert.Equal(t, int64(1), addOutboxMessage(t, repo, &domain.OutboxMessage{TripID: b.ID.Hex()}).Sequence)
+
+	// A rolled back message gives its number back
+	errAborted := errors.New("aborted")
+	err := repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.AddOutboxMessage(ctx, &domain.OutboxMessage{TripID: a.ID.Hex()}); err != nil {
+			return err
+		}
+		return errAborted
+	})
+	assert.True(t, errors.Is(err, errAborted), "got %v", err)
+	assert.Equal(t, int64(3), addOutboxMessage(t, repo, &domain.OutboxMessage{TripID: a.ID.Hex()}).Sequence)
+
+	err = repo.AddOutboxMessage(ctx, &domain.OutboxMessage{TripID: primitive.NewObjectID().Hex()})
+	assertNotFound(t, err, domain.ErrTripNotFound)
+}
+
+func testOutboxOrder(t *testing.T, repo outboxRepository) {
+	ctx := context.Background()
+	trip := saveTrip(t, repo, "user-1", domain.TripStatusPending)
+
+	// Replicas generate the ObjectIDs, a later message can get a lower one
+	created := addOutboxMessage(t, repo, &domain.OutboxMessage{TripID: trip.ID.Hex(), RoutingKey: "created", ID: primitive.NewObjectIDFromTimestamp(time.Now().Add(time.Second))})
+	cancelled := addOutboxMessage(t, repo, &domain.OutboxMessage{TripID: trip.ID.Hex(), RoutingKey: "cancelled", ID: primitive.NewObjectIDFromTimestamp(time.Now().Add(-time.Second))})
+
+	pending, err := repo.ListPendingOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, created.ID, pending[0].ID)
+
+	require.NoError(t, repo.MarkOutboxMessagePublished(ctx, created.ID, time.Now()))
+	pending, err = repo.ListPendingOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, cancelled.ID, pending[0].ID)
+}
+
+// testPromotionRepository is the contract of domain.PromotionRepository, save stores
+// a promotion the way operators create them
+func testPromotionRepository(t *testing.T, newRepo func(t *testing.T) (domain.PromotionRepository, func(p *domain.PromotionModel))) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo domain.PromotionRepository, save func(p *domain.PromotionModel))
+	}{
+		{"user_codes_win_over_global_codes", testGetPromotionByCode},
+		{"redemptions_respect_the_limits", testRedeemPromotion},
+		{"concurrent_redemptions_respect_the_limits", testConcurrentRedemptions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, save := newRepo(t)
+			tt.run(t, repo, save)
+		})
+	}
+}
+
+func testGetPromotionByCode(t *testing.T, repo domain.PromotionRepository, save func(p *domain.PromotionModel)) {
+	ctx := context.Background()
+	global := &domain.PromotionModel{ID: primitive.NewObjectID(), Code: "WELCOME", DiscountType: domain.DiscountTypePercent, PercentOff: 10}
+	own := &domain.PromotionModel{ID: primitive.NewObjectID(), Code: "WELCOME", UserID: "user-1", DiscountType: domain.DiscountTypePercent, PercentOff: 50}
+	save(global)
+	save(own)
+
+	found, err := repo.GetPromotionByCode(ctx, " welcome ", "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, own.ID, found.ID)
+
+	found, err = repo.GetPromotionByCode(ctx, "WELCOME", "user-2")
+	require.NoError(t, err)
+	assert.Equal(t, global.ID, found.ID)
+
+	_, err = repo.GetPromotionByCode(ctx, "MISSING", "user-1")
+	assert.True(t, errors.Is(err, domain.ErrPromotionNotFound))
+}
+
+func testRedeemPromotion(t *testing.T, repo domain.PromotionRepository, save func(p *domain.PromotionModel)) {
+	ctx := context.Background()
+	promotion := &domain.PromotionModel{
+		ID:           primitive.NewObjectID(),
+		Code:         "TWICE",
+		DiscountType: domain.DiscountTypeFixed,
+		AmountOff:    types.NewMoney(500, "USD"),
+		UsageLimit:   3,
+		PerUserLimit: 2,
+	}
+	save(promotion)
+
+	// User IDs are data, never part of a field path
+	user := "user.1$"
+	require.NoError(t, repo.RedeemPromotion(ctx, promotion.ID, user))
+	require.NoError(t, repo.RedeemPromotion(ctx, promotion.ID, user))
+	assert.True(t, errors.Is(repo.RedeemPromotion(ctx, promotion.ID, user), domain.ErrPromotionExhausted))
+
+	found, err := repo.GetPromotionByCode(ctx, "TWICE", user)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), found.Redemptions)
+	assert.Equal(t, int64(2), found.UserRedemptions)
+
+	found, err = repo.GetPromotionByCode(ctx, "TWICE", "user-2")
+	require.NoError(t, err)
+	assert.Zero(t, found.UserRedemptions)
+
+	// The usage limit is shared by the users
+	require.NoError(t, repo.RedeemPromotion(ctx, promotion.ID, "user-2"))
+	assert.True(t, errors.Is(repo.RedeemPromotion(ctx, promotion.ID, "user-3"), domain.ErrPromotionExhausted))
+
+	// A released redemption can be redeemed again, and the failed ones counted nothing
+	require.NoError(t, repo.ReleasePromotion(ctx, promotion.ID, user))
+	found, err = repo.GetPromotionByCode(ctx, "TWICE", "user-3")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), found.Redemptions)
+	assert.Zero(t, found.UserRedemptions)
+	require.NoError(t, repo.RedeemPromotion(ctx, promotion.ID, user))
+
+	assert.True(t, errors.Is(repo.RedeemPromotion(ctx, primitive.NewObjectID(), user), domain.ErrPromotionNotFound))
+}
+
+func testConcurrentRedemptions(t *testing.T, repo domain.PromotionRepository, save func(p *domain.PromotionModel)) {
+	ctx := context.Background()
+	promotion := &domain.PromotionModel{
+		ID:           primitive.NewObjectID(),
+		Code:         "RUSH",
+		DiscountType: domain.DiscountTypePercent,
+		PercentOff:   10,
+		PerUserLimit: 3,
+	}
+	save(promotion)
+
+	const workers = 10
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		redeemed   int
+		unexpected []error
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := repo.RedeemPromotion(ctx, promotion.ID, "user-1")
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				redeemed++
+			case !errors.Is(err, domain.ErrPromotionExhausted):
+				unexpected = append(unexpected, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Empty(t, unexpected)
+	assert.Equal(t, 3, redeemed)
+}
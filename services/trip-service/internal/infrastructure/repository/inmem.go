@@ -6,36 +6,125 @@ import (
 	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
 	pbd "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
 	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"sync"
+	"time"
 )
 
+// inmemRepository keeps the trip-service data in memory, for the tests and local runs
+// without MongoDB. It is safe for concurrent use: every call is atomic, and the models
+// are copied in and out so callers never share them with the repository.
 type inmemRepository struct {
+	mu sync.RWMutex
+	// txMu runs the transactions one at a time
+	txMu      sync.Mutex
 	trips     map[string]*domain.TripModel
 	rideFares map[string]*domain.RideFareModel
+	// promotions are keyed by their ID
+	promotions map[primitive.ObjectID]*domain.PromotionModel
+	// redemptions counts the redemptions of the promotions per user
+	redemptions map[promotionUser]int64
+	// outbox is in insertion order
+	outbox []*domain.OutboxMessage
+	// timeline is in insertion order
+	timeline []*domain.TimelineEntry
 }
 
 func NewInmemRepository() *inmemRepository {
 	return &inmemRepository{
-		trips:     make(map[string]*domain.TripModel),
-		rideFares: make(map[string]*domain.RideFareModel),
+		trips:       make(map[string]*domain.TripModel),
+		rideFares:   make(map[string]*domain.RideFareModel),
+		promotions:  make(map[primitive.ObjectID]*domain.PromotionModel),
+		redemptions: make(map[promotionUser]int64),
 	}
 }
 
+type promotionUser struct {
+	promotionID primitive.ObjectID
+	userID      string
+}
+
 func (r *inmemRepository) GetTripByID(ctx context.Context, id string) (*domain.TripModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	trip, ok := r.trips[id]
 	if !ok {
-		return nil, nil
+		return nil, domain.ErrTripNotFound
 	}
-	return trip, nil
+	return cloneTrip(trip), nil
+}
+
+func (r *inmemRepository) ListTrips(ctx context.Context, filter domain.TripFilter) ([]*domain.TripModel, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var trips []*domain.TripModel
+	for _, trip := range r.trips {
+		if matchesFilter(trip, filter) {
+			trips = append(trips, cloneTrip(trip))
+		}
+	}
+
+	// ObjectIDs start with their creation time, so this sorts newest first
+	sort.Slice(trips, func(i, j int) bool {
+		return trips[i].ID.Hex() > trips[j].ID.Hex()
+	})
+
+	if filter.Limit > 0 && len(trips) > filter.Limit {
+		trips = trips[:filter.Limit]
+	}
+
+	return trips, nil
 }
 
-func (r *inmemRepository) UpdateTrip(ctx context.Context, tripID string, status string, driver *pbd.Driver) error {
+func matchesFilter(trip *domain.TripModel, filter domain.TripFilter) bool {
+	if filter.UserID != "" && trip.UserID != filter.UserID {
+		return false
+	}
+	if filter.DriverID != "" && (trip.Driver == nil || trip.Driver.Id != filter.DriverID) {
+		return false
+	}
+	if filter.Status != "" && trip.Status != filter.Status {
+		return false
+	}
+	if filter.CreatedFrom != nil && trip.CreatedAt.Before(*filter.CreatedFrom) {
+		return false
+	}
+	if filter.CreatedTo != nil && !trip.CreatedAt.Before(*filter.CreatedTo) {
+		return false
+	}
+	if filter.AfterID != "" && trip.ID.Hex() >= filter.AfterID {
+		return false
+	}
+	return true
+}
+
+func (r *inmemRepository) UpdateTrip(ctx context.Context, tripID string, status domain.TripStatus, driver *pbd.Driver) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	trip, ok := r.trips[tripID]
 	if !ok {
-		return fmt.Errorf("trip not found with ID: %s", tripID)
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	if !trip.Status.CanTransitionTo(status) {
+		return &domain.InvalidTransitionError{TripID: tripID, From: trip.Status, To: status}
 	}
 
 	trip.Status = status
 
+	if status == domain.TripStatusDriverAssigned {
+		now := time.Now()
+		trip.DriverAssignedAt = &now
+	}
+
 	if driver != nil {
 		trip.Driver = &pb.TripDriver{
 			Id:             driver.Id,
@@ -47,21 +136,551 @@ func (r *inmemRepository) UpdateTrip(ctx context.Context, tripID string, status
 	return nil
 }
 
+func (r *inmemRepository) CancelTrip(ctx context.Context, tripID string, cancellation *domain.TripCancellation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trip, ok := r.trips[tripID]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	if !trip.Status.CanTransitionTo(domain.TripStatusCancelled) {
+		return &domain.InvalidTransitionError{TripID: tripID, From: trip.Status, To: domain.TripStatusCancelled}
+	}
+
+	trip.Status = domain.TripStatusCancelled
+	if cancellation != nil {
+		c := *cancellation
+		trip.Cancellation = &c
+	}
+
+	return nil
+}
+
+func (r *inmemRepository) ListScheduledTrips(ctx context.Context, userID string) ([]*domain.TripModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var trips []*domain.TripModel
+	for _, trip := range r.trips {
+		if trip.UserID == userID && trip.Status == domain.TripStatusScheduled {
+			trips = append(trips, cloneTrip(trip))
+		}
+	}
+
+	sort.Slice(trips, func(i, j int) bool {
+		return trips[i].ScheduledFor.Before(*trips[j].ScheduledFor)
+	})
+
+	return trips, nil
+}
+
+func (r *inmemRepository) ClaimDueScheduledTrip(ctx context.Context, dueBefore, leaseUntil time.Time) (*domain.TripModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	var due *domain.TripModel
+	for _, trip := range r.trips {
+		if trip.Status != domain.TripStatusScheduled && trip.Status != domain.TripStatusPending {
+			continue
+		}
+		if trip.ScheduledFor == nil || trip.ScheduledFor.After(dueBefore) || trip.DispatchedAt != nil {
+			continue
+		}
+		if trip.DispatchLeaseUntil != nil && trip.DispatchLeaseUntil.After(now) {
+			continue
+		}
+		if due == nil || trip.ScheduledFor.Before(*due.ScheduledFor) {
+			due = trip
+		}
+	}
+
+	if due == nil {
+		return nil, nil
+	}
+
+	due.Status = domain.TripStatusPending
+	due.DispatchLeaseUntil = &leaseUntil
+	due.DispatchLeaseID = primitive.NewObjectID().Hex()
+
+	return cloneTrip(due), nil
+}
+
+func (r *inmemRepository) MarkTripDispatched(ctx context.Context, tripID, leaseID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trip, ok := r.trips[tripID]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+	if trip.DispatchedAt != nil || trip.DispatchLeaseID != leaseID {
+		return fmt.Errorf("%w: %s", domain.ErrDispatchLeaseLost, tripID)
+	}
+
+	now := time.Now()
+	trip.DispatchedAt = &now
+	trip.DispatchLeaseUntil = nil
+	trip.DispatchLeaseID = ""
+
+	return nil
+}
+
+func (r *inmemRepository) MarkTripPaid(ctx context.Context, tripID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trip, ok := r.trips[tripID]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+	if trip.PaidAt == nil {
+		trip.PaidAt = &at
+	}
+
+	return nil
+}
+
+func (r *inmemRepository) ReachStop(ctx context.Context, tripID, driverID string, stop int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trip, ok := r.trips[tripID]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	if !trip.HasDriver() || trip.Driver.Id != driverID {
+		return fmt.Errorf("%w: trip %s is not driven by %s", domain.ErrTripNotOwned, tripID, driverID)
+	}
+
+	if err := trip.CanReachStop(stop); err != nil {
+		return err
+	}
+
+	trip.Stops[stop].ReachedAt = &at
+	trip.CurrentLeg++
+
+	return nil
+}
+
 func (r *inmemRepository) GetRideFareByID(ctx context.Context, id string) (*domain.RideFareModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fare, exist := r.rideFares[id]
+	if !exist {
+		return nil, fmt.Errorf("%w: %s", domain.ErrFareNotFound, id)
+	}
+
+	return cloneFare(fare), nil
+}
+
+func (r *inmemRepository) ConsumeRideFare(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	fare, exist := r.rideFares[id]
 	if !exist {
-		return nil, fmt.Errorf("fare does not exist with ID: %s", id)
+		return fmt.Errorf("%w: %s", domain.ErrFareNotFound, id)
 	}
 
-	return fare, nil
+	if fare.ConsumedAt != nil {
+		return domain.ErrFareConsumed
+	}
+
+	now := time.Now()
+	fare.ConsumedAt = &now
+
+	return nil
+}
+
+func (r *inmemRepository) ReleaseRideFare(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fare, exist := r.rideFares[id]
+	if !exist {
+		return fmt.Errorf("%w: %s", domain.ErrFareNotFound, id)
+	}
+
+	fare.ConsumedAt = nil
+	return nil
 }
 
 func (r *inmemRepository) CreateTrip(ctx context.Context, trip *domain.TripModel) (*domain.TripModel, error) {
-	r.trips[trip.ID.Hex()] = trip
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.trips {
+		if t.RideFare != nil && trip.RideFare != nil && t.RideFare.ID == trip.RideFare.ID {
+			return nil, domain.ErrFareConsumed
+		}
+	}
+
+	if trip.ID.IsZero() {
+		trip.ID = primitive.NewObjectID()
+	}
+
+	r.trips[trip.ID.Hex()] = cloneTrip(trip)
 	return trip, nil
 }
 
 func (r *inmemRepository) SaveRideFare(ctx context.Context, f *domain.RideFareModel) error {
-	r.rideFares[f.ID.Hex()] = f
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f.ID.IsZero() {
+		f.ID = primitive.NewObjectID()
+	}
+
+	r.rideFares[f.ID.Hex()] = cloneFare(f)
 	return nil
 }
+
+// SavePromotion stores a promotion, promotions are created by operators so only the tests need it
+func (r *inmemRepository) SavePromotion(p *domain.PromotionModel) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p.ID.IsZero() {
+		p.ID = primitive.NewObjectID()
+	}
+	p.Code = domain.NormalizePromoCode(p.Code)
+	r.promotions[p.ID] = clonePromotion(p)
+}
+
+func (r *inmemRepository) GetPromotionByCode(ctx context.Context, code, userID string) (*domain.PromotionModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	code = domain.NormalizePromoCode(code)
+
+	var global *domain.PromotionModel
+	for _, p := range r.promotions {
+		if p.Code != code {
+			continue
+		}
+		if p.UserID == userID {
+			return r.promotionFor(p, userID), nil
+		}
+		if p.UserID == "" {
+			global = p
+		}
+	}
+
+	if global == nil {
+		return nil, domain.ErrPromotionNotFound
+	}
+	return r.promotionFor(global, userID), nil
+}
+
+// promotionFor returns a copy of the promotion with the redemptions of the user
+func (r *inmemRepository) promotionFor(p *domain.PromotionModel, userID string) *domain.PromotionModel {
+	promotion := clonePromotion(p)
+	promotion.UserRedemptions = r.redemptions[promotionUser{promotionID: p.ID, userID: userID}]
+	return promotion
+}
+
+func (r *inmemRepository) RedeemPromotion(ctx context.Context, id primitive.ObjectID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.promotions[id]
+	if !ok {
+		return domain.ErrPromotionNotFound
+	}
+
+	if p.UsageLimit > 0 && p.Redemptions >= p.UsageLimit {
+		return domain.ErrPromotionExhausted
+	}
+	key := promotionUser{promotionID: id, userID: userID}
+	if p.PerUserLimit > 0 && r.redemptions[key] >= p.PerUserLimit {
+		return domain.ErrPromotionExhausted
+	}
+
+	p.Redemptions++
+	r.redemptions[key]++
+
+	return nil
+}
+
+func (r *inmemRepository) ReleasePromotion(ctx context.Context, id primitive.ObjectID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.promotions[id]
+	if !ok || p.Redemptions == 0 {
+		return nil
+	}
+
+	p.Redemptions--
+	key := promotionUser{promotionID: id, userID: userID}
+	if r.redemptions[key] > 0 {
+		r.redemptions[key]--
+	}
+
+	return nil
+}
+
+// inmemTransactionKey marks the context of a transaction, the transactions started with it join it
+type inmemTransactionKey struct{}
+
+// WithTransaction runs fn and restores the data as it was before fn when fn fails.
+// Transactions run one at a time but aren't isolated from the calls made outside
+// of them: a rollback also undoes those, which is enough for the tests and local runs.
+func (r *inmemRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(inmemTransactionKey{}) != nil {
+		return fn(ctx)
+	}
+
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+
+	snapshot := r.snapshot()
+	if err := fn(context.WithValue(ctx, inmemTransactionKey{}, true)); err != nil {
+		r.restore(snapshot)
+		return err
+	}
+	return nil
+}
+
+// inmemSnapshot is a copy of the data of the repository
+type inmemSnapshot struct {
+	trips       map[string]*domain.TripModel
+	rideFares   map[string]*domain.RideFareModel
+	promotions  map[primitive.ObjectID]*domain.PromotionModel
+	redemptions map[promotionUser]int64
+	outbox      []*domain.OutboxMessage
+	timeline    []*domain.TimelineEntry
+}
+
+func (r *inmemRepository) snapshot() *inmemSnapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s := &inmemSnapshot{
+		trips:       make(map[string]*domain.TripModel, len(r.trips)),
+		rideFares:   make(map[string]*domain.RideFareModel, len(r.rideFares)),
+		promotions:  make(map[primitive.ObjectID]*domain.PromotionModel, len(r.promotions)),
+		redemptions: make(map[promotionUser]int64, len(r.redemptions)),
+		outbox:      make([]*domain.OutboxMessage, len(r.outbox)),
+		// Timeline entries are never updated
+		timeline: append([]*domain.TimelineEntry(nil), r.timeline...),
+	}
+	for id, trip := range r.trips {
+		s.trips[id] = cloneTrip(trip)
+	}
+	for id, fare := range r.rideFares {
+		s.rideFares[id] = cloneFare(fare)
+	}
+	for id, promotion := range r.promotions {
+		s.promotions[id] = clonePromotion(promotion)
+	}
+	for key, count := range r.redemptions {
+		s.redemptions[key] = count
+	}
+	for i, m := range r.outbox {
+		message := *m
+		s.outbox[i] = &message
+	}
+
+	return s
+}
+
+func (r *inmemRepository) restore(s *inmemSnapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.trips = s.trips
+	r.rideFares = s.rideFares
+	r.promotions = s.promotions
+	r.redemptions = s.redemptions
+	r.outbox = s.outbox
+	r.timeline = s.timeline
+}
+
+func (r *inmemRepository) AddOutboxMessage(ctx context.Context, message *domain.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trip, ok := r.trips[message.TripID]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, message.TripID)
+	}
+	trip.OutboxSequence++
+	message.Sequence = trip.OutboxSequence
+
+	if message.ID.IsZero() {
+		message.ID = primitive.NewObjectID()
+	}
+	m := *message
+	r.outbox = append(r.outbox, &m)
+	return nil
+}
+
+func (r *inmemRepository) ListPendingOutboxMessages(ctx context.Context, limit int) ([]*domain.OutboxMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// The pending message of a trip with the lowest sequence is the next one to publish
+	var messages []*domain.OutboxMessage
+	next := make(map[string]int)
+
+	for _, m := range r.outbox {
+		if m.PublishedAt != nil || m.DeadLetteredAt != nil {
+			continue
+		}
+
+		message := *m
+		if i, ok := next[m.TripID]; ok {
+			if m.Sequence < messages[i].Sequence {
+				messages[i] = &message
+			}
+			continue
+		}
+		next[m.TripID] = len(messages)
+		messages = append(messages, &message)
+	}
+
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+	return messages, nil
+}
+
+func (r *inmemRepository) ClaimOutboxMessage(ctx context.Context, id primitive.ObjectID, now, leaseUntil time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m := r.outboxMessage(id)
+	if m == nil || m.PublishedAt != nil || m.DeadLetteredAt != nil || (m.LeaseUntil != nil && m.LeaseUntil.After(now)) {
+		return false, nil
+	}
+
+	m.LeaseUntil = &leaseUntil
+	m.Attempts++
+	return true, nil
+}
+
+func (r *inmemRepository) MarkOutboxMessagePublished(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m := r.outboxMessage(id); m != nil {
+		m.PublishedAt = &at
+		m.LeaseUntil = nil
+		m.LastError = ""
+	}
+	return nil
+}
+
+func (r *inmemRepository) RecordOutboxFailure(ctx context.Context, id primitive.ObjectID, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m := r.outboxMessage(id); m != nil {
+		m.LastError = reason
+	}
+	return nil
+}
+
+func (r *inmemRepository) DeadLetterOutboxMessage(ctx context.Context, id primitive.ObjectID, at time.Time, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m := r.outboxMessage(id); m != nil {
+		m.DeadLetteredAt = &at
+		m.LeaseUntil = nil
+		m.LastError = reason
+	}
+	return nil
+}
+
+// outboxMessage must be called with the lock held
+func (r *inmemRepository) outboxMessage(id primitive.ObjectID) *domain.OutboxMessage {
+	for _, m := range r.outbox {
+		if m.ID == id {
+			return m
+		}
+	}
+	return nil
+}
+
+func (r *inmemRepository) AppendTimelineEntry(ctx context.Context, entry *domain.TimelineEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry.EventID != "" {
+		for _, e := range r.timeline {
+			if e.TripID == entry.TripID && e.EventID == entry.EventID {
+				return nil
+			}
+		}
+	}
+
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	e := *entry
+	r.timeline = append(r.timeline, &e)
+	return nil
+}
+
+func (r *inmemRepository) GetTripTimeline(ctx context.Context, tripID string) ([]*domain.TimelineEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := []*domain.TimelineEntry{}
+	for _, e := range r.timeline {
+		if e.TripID == tripID {
+			entry := *e
+			entries = append(entries, &entry)
+		}
+	}
+	return entries, nil
+}
+
+// cloneTrip copies the parts of a trip the repository updates, the route
+// and the coordinates are never modified once stored
+func cloneTrip(trip *domain.TripModel) *domain.TripModel {
+	t := *trip
+	if trip.RideFare != nil {
+		t.RideFare = cloneFare(trip.RideFare)
+	}
+	if trip.Driver != nil {
+		t.Driver = &pb.TripDriver{
+			Id:             trip.Driver.Id,
+			Name:           trip.Driver.Name,
+			CarPlate:       trip.Driver.CarPlate,
+			ProfilePicture: trip.Driver.ProfilePicture,
+		}
+	}
+	if trip.Cancellation != nil {
+		c := *trip.Cancellation
+		t.Cancellation = &c
+	}
+	if trip.Stops != nil {
+		t.Stops = make([]*domain.TripStop, len(trip.Stops))
+		for i, stop := range trip.Stops {
+			s := *stop
+			t.Stops[i] = &s
+		}
+	}
+	return &t
+}
+
+func cloneFare(fare *domain.RideFareModel) *domain.RideFareModel {
+	f := *fare
+	f.LineItems = append([]domain.FareLineItem(nil), fare.LineItems...)
+	return &f
+}
+
+func clonePromotion(promotion *domain.PromotionModel) *domain.PromotionModel {
+	p := *promotion
+	p.PackageSlugs = append([]string(nil), promotion.PackageSlugs...)
+	return &p
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+)
+
+func TestInmemRepository(t *testing.T) {
+	testTripRepository(t, func(t *testing.T) domain.TripRepository {
+		return NewInmemRepository()
+	})
+}
+
+func TestInmemOutboxRepository(t *testing.T) {
+	testOutboxRepository(t, func(t *testing.T) outboxRepository {
+		return NewInmemRepository()
+	})
+}
+
+func TestInmemPromotionRepository(t *testing.T) {
+	testPromotionRepository(t, func(t *testing.T) (domain.PromotionRepository, func(p *domain.PromotionModel)) {
+		repo := NewInmemRepository()
+		return repo, repo.SavePromotion
+	})
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/shared/db"
+	"github.com/Anurag-Mishra22/taxi/shared/db/migrate"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// rideFareRetention is how long expired fares are kept before MongoDB deletes them,
+	// so riders starting a trip from a recently expired fare get a clear error
+	rideFareRetention = 24 * time.Hour
+	// outboxRetention is how long published messages are kept, to investigate delivery issues
+	outboxRetention = 24 * time.Hour
+)
+
+// tripStatuses are the statuses the trips validator accepts. A new status needs
+// a new migration applying the validator again.
+func tripStatuses() bson.A {
+	statuses := bson.A{}
+	for _, status := range domain.TripStatuses() {
+		statuses = append(statuses, string(status))
+	}
+	return statuses
+}
+
+// Migrations are the migrations of the trip-service database. currency is the
+// currency of the legacy amounts, which were stored as float cents.
+func Migrations(currency string) []migrate.Migration {
+	return []migrate.Migration{
+		tripIndexes(1),
+		migrate.Indexes(2, "delete the ride fares a day after they expire", db.RideFaresCollection,
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(int32(rideFareRetention.Seconds())),
+			},
+		),
+		migrate.Indexes(3, "make the promotion codes unique per user", db.PromotionsCollection,
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "code", Value: 1}, {Key: "userID", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		),
+		migrate.Indexes(4, "index the outbox relay and delete the published messages", db.OutboxCollection,
+			mongo.IndexModel{Keys: bson.D{{Key: "publishedAt", Value: 1}, {Key: "_id", Value: 1}}},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "publishedAt", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(int32(outboxRetention.Seconds())).SetName("publishedAt_ttl"),
+			},
+		),
+		migrate.Indexes(5, "index the trip timelines", db.TimelineCollection,
+			mongo.IndexModel{Keys: bson.D{{Key: "tripID", Value: 1}, {Key: "at", Value: 1}, {Key: "_id", Value: 1}}},
+		),
+		migrate.Backfill(6, "rename the legacy accepted and payed statuses", db.TripsCollection,
+			bson.M{"status": bson.M{"$in": bson.A{"accepted", "payed"}}},
+			mongo.Pipeline{{{Key: "$set", Value: bson.M{"status": bson.M{"$switch": bson.M{
+				"branches": bson.A{
+					bson.M{"case": bson.M{"$eq": bson.A{"$status", "accepted"}}, "then": "driver_assigned"},
+					bson.M{"case": bson.M{"$eq": bson.A{"$status", "payed"}}, "then": "paid"},
+				},
+				"default": "$status",
+			}}}}}},
+		),
+		// The ObjectIDs start with their creation time
+		migrate.Backfill(7, "set the creation time of the legacy trips", db.TripsCollection,
+			bson.M{"createdAt": bson.M{"$exists": false}},
+			mongo.Pipeline{{{Key: "$set", Value: bson.M{"createdAt": bson.M{"$toDate": "$_id"}}}}},
+		),
+		migrate.Backfill(8, "convert the legacy fare prices to money", db.RideFaresCollection,
+			bson.M{"totalPriceInCents": bson.M{"$exists": true}},
+			legacyMoney("totalPriceInCents", "totalPrice", currency),
+		),
+		migrate.Backfill(9, "convert the legacy fare prices of the trips to money", db.TripsCollection,
+			bson.M{"rideFare.totalPriceInCents": bson.M{"$exists": true}},
+			legacyMoney("rideFare.totalPriceInCents", "rideFare.totalPrice", currency),
+		),
+		migrate.Backfill(10, "convert the legacy cancellation fees to money", db.TripsCollection,
+			bson.M{"cancellation.feeInCents": bson.M{"$exists": true}},
+			legacyMoney("cancellation.feeInCents", "cancellation.fee", currency),
+		),
+		migrate.Validator(11, "validate the trips", db.TripsCollection, bson.M{
+			"bsonType": "object",
+			"required": bson.A{"userID", "status", "rideFare", "createdAt"},
+			"properties": bson.M{
+				"userID":    bson.M{"bsonType": "string"},
+				"status":    bson.M{"enum": tripStatuses()},
+				"rideFare":  bson.M{"bsonType": "object"},
+				"createdAt": bson.M{"bsonType": "date"},
+			},
+		}),
+		migrate.Validator(12, "validate the ride fares", db.RideFaresCollection, bson.M{
+			"bsonType": "object",
+			"required": bson.A{"userID", "packageSlug", "totalPrice"},
+			"properties": bson.M{
+				"userID":      bson.M{"bsonType": "string"},
+				"packageSlug": bson.M{"bsonType": "string"},
+				"totalPrice":  moneySchema,
+				"expiresAt":   bson.M{"bsonType": "date"},
+			},
+		}),
+		migrate.Backfill(13, "rename the package fares to their minor unit", db.PackagesCollection,
+			bson.M{"baseFareInCents": bson.M{"$exists": true}},
+			mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{
+					"baseFareMinor":    "$baseFareInCents",
+					"perKmMinor":       "$perKmInCents",
+					"perMinuteMinor":   "$perMinuteInCents",
+					"minimumFareMinor": "$minimumFareInCents",
+				}}},
+				{{Key: "$unset", Value: bson.A{"baseFareInCents", "perKmInCents", "perMinuteInCents", "minimumFareInCents"}}},
+			},
+		),
+		migrate.Indexes(14, "count the promotion redemptions once per user", db.PromotionRedemptionsCollection,
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "promotionID", Value: 1}, {Key: "userID", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		),
+		moveUserRedemptions(15),
+		migrate.Indexes(16, "record the timeline events once", db.TimelineCollection,
+			mongo.IndexModel{
+				Keys: bson.D{{Key: "tripID", Value: 1}, {Key: "eventID", Value: 1}},
+				Options: options.Index().SetUnique(true).
+					SetPartialFilterExpression(bson.M{"eventID": bson.M{"$type": "string"}}),
+			},
+		),
+		migrate.Indexes(17, "order the outbox messages of each trip", db.OutboxCollection,
+			mongo.IndexModel{Keys: bson.D{{Key: "publishedAt", Value: 1}, {Key: "tripID", Value: 1}, {Key: "sequence", Value: 1}}},
+		),
+	}
+}
+
+// tripIndexes indexes the trip lookups, and makes the fare of a trip unique since a fare
+// starts one trip. The legacy trips sharing a fare are deduplicated first: the oldest one
+// keeps the fare ID, the other ones keep it in sharedFareID. Trips without a fare ID aren't indexed.
+func tripIndexes(version int) migrate.Migration {
+	indexes := migrate.Indexes(version, "index the trip lookups and the fare of a trip", db.TripsCollection,
+		mongo.IndexModel{Keys: bson.D{{Key: "userID", Value: 1}, {Key: "_id", Value: -1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "driver.id", Value: 1}, {Key: "_id", Value: -1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}, {Key: "_id", Value: -1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}, {Key: "scheduledFor", Value: 1}}},
+		mongo.IndexModel{
+			Keys: bson.D{{Key: "rideFare._id", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"rideFare._id": bson.M{"$exists": true}}),
+		},
+	)
+
+	createIndexes := indexes.Up
+	indexes.Up = func(ctx context.Context, database *mongo.Database) error {
+		if err := dedupeTripFares(ctx, database); err != nil {
+			return err
+		}
+		return createIndexes(ctx, database)
+	}
+
+	return indexes
+}
+
+func dedupeTripFares(ctx context.Context, database *mongo.Database) error {
+	trips := database.Collection(db.TripsCollection)
+
+	cursor, err := trips.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"rideFare._id": bson.M{"$exists": true}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$rideFare._id",
+			"trips": bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return fmt.Errorf("failed to find the trips sharing a fare: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var shared struct {
+			Trips []primitive.ObjectID `bson:"trips"`
+		}
+		if err := cursor.Decode(&shared); err != nil {
+			return err
+		}
+
+		// The trips are sorted by creation, the first one keeps the fare
+		_, err := trips.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": shared.Trips[1:]}}, mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"rideFare.sharedFareID": "$rideFare._id"}}},
+			{{Key: "$unset", Value: "rideFare._id"}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to deduplicate the trips sharing a fare: %w", err)
+		}
+	}
+
+	return cursor.Err()
+}
+
+// moveUserRedemptions moves the per user counts, once kept in a map of the promotions
+// keyed by user ID, to their own collection
+func moveUserRedemptions(version int) migrate.Migration {
+	return migrate.Migration{
+		Version:     version,
+		Description: "move the redemptions per user out of the promotions",
+		Up: func(ctx context.Context, database *mongo.Database) error {
+			promotions := database.Collection(db.PromotionsCollection)
+			legacy := bson.M{"userRedemptions": bson.M{"$type": "object"}}
+
+			cursor, err := promotions.Aggregate(ctx, mongo.Pipeline{
+				{{Key: "$match", Value: legacy}},
+				{{Key: "$project", Value: bson.M{"users": bson.M{"$objectToArray": "$userRedemptions"}}}},
+				{{Key: "$unwind", Value: "$users"}},
+				{{Key: "$project", Value: bson.M{
+					"_id":         0,
+					"promotionID": "$_id",
+					"userID":      "$users.k",
+					"count":       "$users.v",
+				}}},
+				{{Key: "$merge", Value: bson.M{
+					"into":           db.PromotionRedemptionsCollection,
+					"on":             bson.A{"promotionID", "userID"},
+					"whenMatched":    "keepExisting",
+					"whenNotMatched": "insert",
+				}}},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to move the redemptions per user: %w", err)
+			}
+			cursor.Close(ctx)
+
+			_, err = promotions.UpdateMany(ctx, legacy, bson.M{"$unset": bson.M{"userRedemptions": ""}})
+			return err
+		},
+	}
+}
+
+// moneySchema matches types.Money
+var moneySchema = bson.M{
+	"bsonType": "object",
+	"required": bson.A{"amountMinor", "currency"},
+	"properties": bson.M{
+		"amountMinor": bson.M{"bsonType": bson.A{"long", "int"}},
+		"currency":    bson.M{"bsonType": "string", "minLength": 3, "maxLength": 3},
+	},
+}
+
+// legacyMoney replaces a float amount of cents with a types.Money, the cents
+// were already the minor unit of the currency
+func legacyMoney(from, to, currency string) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{to: bson.M{
+			"amountMinor": bson.M{"$toLong": bson.M{"$round": bson.A{"$" + from, 0}}},
+			"currency":    currency,
+		}}}},
+		{{Key: "$unset", Value: from}},
+	}
+}
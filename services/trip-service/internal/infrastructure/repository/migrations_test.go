@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/shared/db"
+	"github.com/Anurag-Mishra22/taxi/shared/db/migrate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMigrationsAreOrdered(t *testing.T) {
+	migrator, err := migrate.NewMigrator(nil, Migrations("USD"))
+	require.NoError(t, err)
+	assert.Equal(t, len(Migrations("USD")), migrator.Latest())
+}
+
+func TestTripStatusesFollowTheDomain(t *testing.T) {
+	statuses := tripStatuses()
+	require.Len(t, statuses, len(domain.TripStatuses()))
+	for i, status := range domain.TripStatuses() {
+		assert.Equal(t, string(status), statuses[i])
+	}
+}
+
+// TestMigrations needs MongoDB in TRIP_TEST_MONGODB_URI, it runs in its own database
+func TestMigrations(t *testing.T) {
+	uri := os.Getenv("TRIP_TEST_MONGODB_URI")
+	if uri == "" {
+		t.Skip("TRIP_TEST_MONGODB_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := db.NewMongoClient(ctx, &db.MongoConfig{URI: uri, Database: "trip-service-test"})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Disconnect(ctx) })
+
+	database := client.Database("trip-service-test-" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() { database.Drop(ctx) })
+
+	// Legacy trips and fare, from before the statuses and the money type changed.
+	// Two trips were started from the same fare, before a fare started one trip.
+	sharedFareID := primitive.NewObjectID()
+	legacyTrips := []interface{}{
+		bson.M{
+			"_id":      primitive.NewObjectIDFromTimestamp(time.Now().Add(-time.Hour)),
+			"userID":   "user-1",
+			"status":   "payed",
+			"rideFare": bson.M{"_id": sharedFareID, "totalPriceInCents": 1249.6},
+		},
+		bson.M{
+			"_id":      primitive.NewObjectID(),
+			"userID":   "user-1",
+			"status":   "cancelled",
+			"rideFare": bson.M{"_id": sharedFareID, "totalPriceInCents": 1249.6},
+		},
+		bson.M{"userID": "user-2", "status": "cancelled", "rideFare": bson.M{}},
+		bson.M{"userID": "user-3", "status": "cancelled", "rideFare": bson.M{}},
+	}
+	_, err = database.Collection(db.TripsCollection).InsertMany(ctx, legacyTrips)
+	require.NoError(t, err)
+	_, err = database.Collection(db.RideFaresCollection).InsertOne(ctx, bson.M{
+		"userID":            "user-1",
+		"packageSlug":       "sedan",
+		"totalPriceInCents": 1249.6,
+	})
+	require.NoError(t, err)
+
+	migrator, err := migrate.NewMigrator(database, Migrations("USD"))
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up(ctx, migrator.Latest()))
+
+	var trip struct {
+		Status    string    `bson:"status"`
+		CreatedAt time.Time `bson:"createdAt"`
+		RideFare  struct {
+			ID           primitive.ObjectID `bson:"_id"`
+			SharedFareID primitive.ObjectID `bson:"sharedFareID"`
+			TotalPrice   struct {
+				AmountMinor int64  `bson:"amountMinor"`
+				Currency    string `bson:"currency"`
+			} `bson:"totalPrice"`
+		} `bson:"rideFare"`
+	}
+	require.NoError(t, database.Collection(db.TripsCollection).FindOne(ctx, bson.M{"_id": legacyTrips[0].(bson.M)["_id"]}).Decode(&trip))
+	assert.Equal(t, "paid", trip.Status)
+	assert.False(t, trip.CreatedAt.IsZero())
+	assert.Equal(t, int64(1250), trip.RideFare.TotalPrice.AmountMinor)
+	assert.Equal(t, "USD", trip.RideFare.TotalPrice.Currency)
+	// The oldest trip keeps the shared fare
+	assert.Equal(t, sharedFareID, trip.RideFare.ID)
+
+	require.NoError(t, database.Collection(db.TripsCollection).FindOne(ctx, bson.M{"_id": legacyTrips[1].(bson.M)["_id"]}).Decode(&trip))
+	assert.True(t, trip.RideFare.ID.IsZero())
+	assert.Equal(t, sharedFareID, trip.RideFare.SharedFareID)
+
+	// A fare starts one trip from now on
+	_, err = database.Collection(db.TripsCollection).InsertOne(ctx, bson.M{
+		"userID":    "user-1",
+		"status":    "pending",
+		"rideFare":  bson.M{"_id": sharedFareID},
+		"createdAt": time.Now(),
+	})
+	assert.Error(t, err)
+
+	// The validator rejects a trip with an unknown status
+	_, err = database.Collection(db.TripsCollection).InsertOne(ctx, bson.M{
+		"userID":    "user-1",
+		"status":    "accepted",
+		"rideFare":  bson.M{},
+		"createdAt": time.Now(),
+	})
+	assert.Error(t, err)
+}
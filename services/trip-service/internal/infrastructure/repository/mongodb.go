@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type mongoRepository struct {
@@ -37,6 +39,10 @@ func (r *mongoRepository) CreateTrip(ctx context.Context, trip *domain.TripModel
 	if r.metrics != nil {
 		r.metrics.RecordDBQuery("insert", "trips", status, time.Since(start))
 	}
+	// The unique fare index backs ConsumeRideFare, a fare can never start two trips
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, domain.ErrFareConsumed
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +55,7 @@ func (r *mongoRepository) CreateTrip(ctx context.Context, trip *domain.TripModel
 func (r *mongoRepository) GetTripByID(ctx context.Context, id string) (*domain.TripModel, error) {
 	_id, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %s", domain.ErrTripNotFound, id)
 	}
 
 	start := time.Now()
@@ -61,6 +67,9 @@ func (r *mongoRepository) GetTripByID(ctx context.Context, id string) (*domain.T
 	if r.metrics != nil {
 		r.metrics.RecordDBQuery("find", "trips", status, time.Since(start))
 	}
+	if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrTripNotFound, id)
+	}
 	if result.Err() != nil {
 		return nil, result.Err()
 	}
@@ -74,20 +83,133 @@ func (r *mongoRepository) GetTripByID(ctx context.Context, id string) (*domain.T
 	return &trip, nil
 }
 
-func (r *mongoRepository) UpdateTrip(ctx context.Context, tripID string, status string, driver *pbd.Driver) error {
+func (r *mongoRepository) ListTrips(ctx context.Context, filter domain.TripFilter) ([]*domain.TripModel, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	query := bson.M{}
+	if filter.UserID != "" {
+		query["userID"] = filter.UserID
+	}
+	if filter.DriverID != "" {
+		query["driver.id"] = filter.DriverID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	createdAt := bson.M{}
+	if filter.CreatedFrom != nil {
+		createdAt["$gte"] = *filter.CreatedFrom
+	}
+	if filter.CreatedTo != nil {
+		createdAt["$lt"] = *filter.CreatedTo
+	}
+	if len(createdAt) > 0 {
+		query["createdAt"] = createdAt
+	}
+
+	if filter.AfterID != "" {
+		afterID, err := primitive.ObjectIDFromHex(filter.AfterID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %w", err)
+		}
+		query["_id"] = bson.M{"$lt": afterID}
+	}
+
+	// ObjectIDs start with their creation time, so this sorts newest first
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+
+	start := time.Now()
+	cursor, err := r.db.Collection(db.TripsCollection).Find(ctx, query, opts)
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("find", "trips", status, time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var trips []*domain.TripModel
+	if err := cursor.All(ctx, &trips); err != nil {
+		return nil, err
+	}
+
+	return trips, nil
+}
+
+func (r *mongoRepository) UpdateTrip(ctx context.Context, tripID string, status domain.TripStatus, driver *pbd.Driver) error {
 	_id, err := primitive.ObjectIDFromHex(tripID)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
 	}
 
 	update := bson.M{"$set": bson.M{"status": status}}
 
+	if status == domain.TripStatusDriverAssigned {
+		update["$set"].(bson.M)["driverAssignedAt"] = time.Now()
+	}
+
 	if driver != nil {
 		update["$set"].(bson.M)["driver"] = driver
 	}
 
+	// Only match the trip while its current status allows the transition,
+	// so concurrent updates cannot move it backwards
+	filter := bson.M{
+		"_id":    _id,
+		"status": bson.M{"$in": domain.AllowedSourceStatuses(status)},
+	}
+
+	start := time.Now()
+	result, err := r.db.Collection(db.TripsCollection).UpdateOne(ctx, filter, update)
+	updateStatus := "success"
+	if err != nil {
+		updateStatus = "error"
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("update", "trips", updateStatus, time.Since(start))
+	}
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		trip, err := r.GetTripByID(ctx, tripID)
+		if err != nil {
+			return err
+		}
+		return &domain.InvalidTransitionError{TripID: tripID, From: trip.Status, To: status}
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) CancelTrip(ctx context.Context, tripID string, cancellation *domain.TripCancellation) error {
+	_id, err := primitive.ObjectIDFromHex(tripID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	filter := bson.M{
+		"_id":    _id,
+		"status": bson.M{"$in": domain.AllowedSourceStatuses(domain.TripStatusCancelled)},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":       domain.TripStatusCancelled,
+		"cancellation": cancellation,
+	}}
+
 	start := time.Now()
-	result, err := r.db.Collection(db.TripsCollection).UpdateOne(ctx, bson.M{"_id": _id}, update)
+	result, err := r.db.Collection(db.TripsCollection).UpdateOne(ctx, filter, update)
 	updateStatus := "success"
 	if err != nil {
 		updateStatus = "error"
@@ -99,8 +221,198 @@ func (r *mongoRepository) UpdateTrip(ctx context.Context, tripID string, status
 		return err
 	}
 
-	if result.ModifiedCount == 0 {
-		return fmt.Errorf("trip not found: %s", tripID)
+	if result.MatchedCount == 0 {
+		trip, err := r.GetTripByID(ctx, tripID)
+		if err != nil {
+			return err
+		}
+		return &domain.InvalidTransitionError{TripID: tripID, From: trip.Status, To: domain.TripStatusCancelled}
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) ListScheduledTrips(ctx context.Context, userID string) ([]*domain.TripModel, error) {
+	query := bson.M{"userID": userID, "status": domain.TripStatusScheduled}
+	opts := options.Find().SetSort(bson.D{{Key: "scheduledFor", Value: 1}})
+
+	start := time.Now()
+	cursor, err := r.db.Collection(db.TripsCollection).Find(ctx, query, opts)
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("find", "trips", status, time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var trips []*domain.TripModel
+	if err := cursor.All(ctx, &trips); err != nil {
+		return nil, err
+	}
+
+	return trips, nil
+}
+
+func (r *mongoRepository) ClaimDueScheduledTrip(ctx context.Context, dueBefore, leaseUntil time.Time) (*domain.TripModel, error) {
+	now := time.Now()
+
+	// Pending trips that were never marked as dispatched are claimed again,
+	// their replica stopped between the claim and the publication of the event
+	filter := bson.M{
+		"status":       bson.M{"$in": []domain.TripStatus{domain.TripStatusScheduled, domain.TripStatusPending}},
+		"scheduledFor": bson.M{"$lte": dueBefore},
+		"dispatchedAt": nil,
+		"$or": bson.A{
+			bson.M{"dispatchLeaseUntil": nil},
+			bson.M{"dispatchLeaseUntil": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":             domain.TripStatusPending,
+		"dispatchLeaseUntil": leaseUntil,
+		"dispatchLeaseID":    primitive.NewObjectID().Hex(),
+	}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "scheduledFor", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	start := time.Now()
+	result := r.db.Collection(db.TripsCollection).FindOneAndUpdate(ctx, filter, update, opts)
+	status := "success"
+	if result.Err() != nil && !errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		status = "error"
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("update", "trips", status, time.Since(start))
+	}
+	if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var trip domain.TripModel
+	if err := result.Decode(&trip); err != nil {
+		return nil, err
+	}
+
+	return &trip, nil
+}
+
+func (r *mongoRepository) MarkTripDispatched(ctx context.Context, tripID, leaseID string) error {
+	_id, err := primitive.ObjectIDFromHex(tripID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	// A claim whose lease expired may have been taken over by another replica,
+	// only the holder of the lease dispatches the trip
+	filter := bson.M{"_id": _id, "dispatchLeaseID": leaseID, "dispatchedAt": nil}
+	update := bson.M{
+		"$set":   bson.M{"dispatchedAt": time.Now()},
+		"$unset": bson.M{"dispatchLeaseUntil": "", "dispatchLeaseID": ""},
+	}
+
+	start := time.Now()
+	result, err := r.db.Collection(db.TripsCollection).UpdateOne(ctx, filter, update)
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("update", "trips", status, time.Since(start))
+	}
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		if _, err := r.GetTripByID(ctx, tripID); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s", domain.ErrDispatchLeaseLost, tripID)
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) MarkTripPaid(ctx context.Context, tripID string, at time.Time) error {
+	_id, err := primitive.ObjectIDFromHex(tripID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	// A redelivered payment keeps the time of the first one
+	filter := bson.M{"_id": _id, "paidAt": nil}
+	update := bson.M{"$set": bson.M{"paidAt": at}}
+
+	start := time.Now()
+	result, err := r.db.Collection(db.TripsCollection).UpdateOne(ctx, filter, update)
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("update", "trips", status, time.Since(start))
+	}
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		_, err := r.GetTripByID(ctx, tripID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) ReachStop(ctx context.Context, tripID, driverID string, stop int, at time.Time) error {
+	_id, err := primitive.ObjectIDFromHex(tripID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	// Matching on the current leg makes a duplicated command a no-op
+	filter := bson.M{
+		"_id":        _id,
+		"driver.id":  driverID,
+		"currentLeg": stop,
+		"status":     bson.M{"$in": domain.StopStatuses()},
+	}
+	update := bson.M{
+		"$set": bson.M{fmt.Sprintf("stops.%d.reachedAt", stop): at},
+		"$inc": bson.M{"currentLeg": 1},
+	}
+
+	start := time.Now()
+	result, err := r.db.Collection(db.TripsCollection).UpdateOne(ctx, filter, update)
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("update", "trips", status, time.Since(start))
+	}
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		trip, err := r.GetTripByID(ctx, tripID)
+		if err != nil {
+			return err
+		}
+		if !trip.HasDriver() || trip.Driver.Id != driverID {
+			return fmt.Errorf("%w: trip %s is not driven by %s", domain.ErrTripNotOwned, tripID, driverID)
+		}
+		return fmt.Errorf("%w: trip %s is no longer on leg %d", domain.ErrInvalidStop, tripID, stop)
 	}
 
 	return nil
@@ -128,7 +440,7 @@ func (r *mongoRepository) SaveRideFare(ctx context.Context, fare *domain.RideFar
 func (r *mongoRepository) GetRideFareByID(ctx context.Context, id string) (*domain.RideFareModel, error) {
 	_id, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %s", domain.ErrFareNotFound, id)
 	}
 
 	start := time.Now()
@@ -140,6 +452,9 @@ func (r *mongoRepository) GetRideFareByID(ctx context.Context, id string) (*doma
 	if r.metrics != nil {
 		r.metrics.RecordDBQuery("find", "ride_fares", status, time.Since(start))
 	}
+	if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrFareNotFound, id)
+	}
 	if result.Err() != nil {
 		return nil, result.Err()
 	}
@@ -152,3 +467,63 @@ func (r *mongoRepository) GetRideFareByID(ctx context.Context, id string) (*doma
 
 	return &fare, nil
 }
+
+func (r *mongoRepository) ConsumeRideFare(ctx context.Context, id string) error {
+	_id, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrFareNotFound, id)
+	}
+
+	// Matching on a missing consumedAt makes the update a compare-and-set,
+	// so only one trip can ever be created from a fare
+	filter := bson.M{"_id": _id, "consumedAt": nil}
+	update := bson.M{"$set": bson.M{"consumedAt": time.Now()}}
+
+	start := time.Now()
+	result, err := r.db.Collection(db.RideFaresCollection).UpdateOne(ctx, filter, update)
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("update", "ride_fares", status, time.Since(start))
+	}
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		if _, err := r.GetRideFareByID(ctx, id); err != nil {
+			return err
+		}
+		return domain.ErrFareConsumed
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) ReleaseRideFare(ctx context.Context, id string) error {
+	_id, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrFareNotFound, id)
+	}
+
+	start := time.Now()
+	result, err := r.db.Collection(db.RideFaresCollection).UpdateOne(ctx, bson.M{"_id": _id}, bson.M{"$unset": bson.M{"consumedAt": ""}})
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("update", "ride_fares", status, time.Since(start))
+	}
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrFareNotFound, id)
+	}
+
+	return nil
+}
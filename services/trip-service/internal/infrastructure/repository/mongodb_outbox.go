@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/shared/db"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WithTransaction runs fn in a MongoDB transaction. Calls made within a transaction
+// join it instead of starting another one. Transactions need a replica set (or a sharded
+// cluster), a standalone mongod rejects them.
+func (r *mongoRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := r.db.Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start a session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	// The driver retries fn on transient errors, like a write conflict with another transaction
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+
+	return err
+}
+
+func (r *mongoRepository) AddOutboxMessage(ctx context.Context, message *domain.OutboxMessage) error {
+	tripID, err := primitive.ObjectIDFromHex(message.TripID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, message.TripID)
+	}
+
+	// The counter of the trip is written in the transaction of the message: the transactions writing
+	// messages of the same trip conflict, so the sequence follows the order they commit in. The ObjectIDs
+	// can't order them, they are generated by the replicas and only sort by time to the second.
+	var trip struct {
+		OutboxSequence int64 `bson:"outboxSequence"`
+	}
+	start := time.Now()
+	err = r.db.Collection(db.TripsCollection).FindOneAndUpdate(ctx,
+		bson.M{"_id": tripID},
+		bson.M{"$inc": bson.M{"outboxSequence": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"outboxSequence": 1}),
+	).Decode(&trip)
+	r.recordOutboxQuery("update", start, err)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, message.TripID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to number the outbox message of trip %s: %w", message.TripID, err)
+	}
+	message.Sequence = trip.OutboxSequence
+
+	if message.ID.IsZero() {
+		message.ID = primitive.NewObjectID()
+	}
+
+	start = time.Now()
+	_, err = r.db.Collection(db.OutboxCollection).InsertOne(ctx, message)
+	r.recordOutboxQuery("insert", start, err)
+
+	return err
+}
+
+func (r *mongoRepository) ListPendingOutboxMessages(ctx context.Context, limit int) ([]*domain.OutboxMessage, error) {
+	// The pending message of a trip with the lowest sequence is the next one to publish
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"publishedAt": nil, "deadLetteredAt": nil}}},
+		{{Key: "$sort", Value: bson.D{{Key: "tripID", Value: 1}, {Key: "sequence", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$tripID", "message": bson.M{"$first": "$$ROOT"}}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$message"}}},
+		{{Key: "$sort", Value: bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	start := time.Now()
+	cursor, err := r.db.Collection(db.OutboxCollection).Aggregate(ctx, pipeline)
+	r.recordOutboxQuery("aggregate", start, err)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var messages []*domain.OutboxMessage
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (r *mongoRepository) ClaimOutboxMessage(ctx context.Context, id primitive.ObjectID, now, leaseUntil time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":            id,
+		"publishedAt":    nil,
+		"deadLetteredAt": nil,
+		"$or": bson.A{
+			bson.M{"leaseUntil": nil},
+			bson.M{"leaseUntil": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"leaseUntil": leaseUntil},
+		"$inc": bson.M{"attempts": 1},
+	}
+
+	start := time.Now()
+	result, err := r.db.Collection(db.OutboxCollection).UpdateOne(ctx, filter, update)
+	r.recordOutboxQuery("update", start, err)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+func (r *mongoRepository) MarkOutboxMessagePublished(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	update := bson.M{
+		"$set":   bson.M{"publishedAt": at},
+		"$unset": bson.M{"leaseUntil": "", "lastError": ""},
+	}
+
+	start := time.Now()
+	_, err := r.db.Collection(db.OutboxCollection).UpdateOne(ctx, bson.M{"_id": id}, update)
+	r.recordOutboxQuery("update", start, err)
+
+	return err
+}
+
+func (r *mongoRepository) RecordOutboxFailure(ctx context.Context, id primitive.ObjectID, reason string) error {
+	start := time.Now()
+	_, err := r.db.Collection(db.OutboxCollection).UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastError": reason}})
+	r.recordOutboxQuery("update", start, err)
+
+	return err
+}
+
+func (r *mongoRepository) DeadLetterOutboxMessage(ctx context.Context, id primitive.ObjectID, at time.Time, reason string) error {
+	update := bson.M{
+		"$set":   bson.M{"deadLetteredAt": at, "lastError": reason},
+		"$unset": bson.M{"leaseUntil": ""},
+	}
+
+	start := time.Now()
+	_, err := r.db.Collection(db.OutboxCollection).UpdateOne(ctx, bson.M{"_id": id}, update)
+	r.recordOutboxQuery("update", start, err)
+
+	return err
+}
+
+func (r *mongoRepository) recordOutboxQuery(op string, start time.Time, err error) {
+	if r.metrics == nil {
+		return
+	}
+
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	r.metrics.RecordDBQuery(op, db.OutboxCollection, status, time.Since(start))
+}
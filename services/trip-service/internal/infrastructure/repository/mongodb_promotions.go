@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/shared/db"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// promotionRedemption counts the redemptions of a promotion by a user, it is unique
+// per promotion and user so the promotion document doesn't grow with its users
+type promotionRedemption struct {
+	PromotionID primitive.ObjectID `bson:"promotionID"`
+	UserID      string             `bson:"userID"`
+	Count       int64              `bson:"count"`
+}
+
+func (r *mongoRepository) GetPromotionByCode(ctx context.Context, code, userID string) (*domain.PromotionModel, error) {
+	code = domain.NormalizePromoCode(code)
+
+	// A code issued to the user wins over a global code with the same name
+	for _, owner := range []string{userID, ""} {
+		start := time.Now()
+		result := r.db.Collection(db.PromotionsCollection).FindOne(ctx, bson.M{"code": code, "userID": owner})
+		err := result.Err()
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			err = nil
+		}
+		r.recordPromotionQuery("find", db.PromotionsCollection, start, err)
+		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			continue
+		}
+		if result.Err() != nil {
+			return nil, result.Err()
+		}
+
+		var promotion domain.PromotionModel
+		if err := result.Decode(&promotion); err != nil {
+			return nil, err
+		}
+
+		count, err := r.userRedemptions(ctx, promotion.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		promotion.UserRedemptions = count
+
+		return &promotion, nil
+	}
+
+	return nil, domain.ErrPromotionNotFound
+}
+
+func (r *mongoRepository) RedeemPromotion(ctx context.Context, id primitive.ObjectID, userID string) error {
+	var promotion domain.PromotionModel
+	opts := options.FindOne().SetProjection(bson.M{"perUserLimit": 1})
+
+	start := time.Now()
+	err := r.db.Collection(db.PromotionsCollection).FindOne(ctx, bson.M{"_id": id}, opts).Decode(&promotion)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		r.recordPromotionQuery("find", db.PromotionsCollection, start, nil)
+		return domain.ErrPromotionNotFound
+	}
+	r.recordPromotionQuery("find", db.PromotionsCollection, start, err)
+	if err != nil {
+		return err
+	}
+
+	// The limits are checked in the filters, so concurrent redemptions
+	// can never go past them
+	if err := r.countUserRedemption(ctx, id, userID, promotion.PerUserLimit); err != nil {
+		return err
+	}
+
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"usageLimit": 0},
+			bson.M{"$expr": bson.M{"$lt": bson.A{"$redemptions", "$usageLimit"}}},
+		},
+	}
+
+	start = time.Now()
+	result, err := r.db.Collection(db.PromotionsCollection).UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"redemptions": 1}})
+	r.recordPromotionQuery("update", db.PromotionsCollection, start, err)
+	if err == nil && result.MatchedCount == 0 {
+		err = domain.ErrPromotionExhausted
+	}
+	if err != nil {
+		// Give back the redemption of the user, the code wasn't redeemed
+		if releaseErr := r.uncountUserRedemption(ctx, id, userID); releaseErr != nil {
+			return errors.Join(err, releaseErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) ReleasePromotion(ctx context.Context, id primitive.ObjectID, userID string) error {
+	filter := bson.M{"_id": id, "redemptions": bson.M{"$gt": 0}}
+
+	start := time.Now()
+	_, err := r.db.Collection(db.PromotionsCollection).UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"redemptions": -1}})
+	r.recordPromotionQuery("update", db.PromotionsCollection, start, err)
+	if err != nil {
+		return err
+	}
+
+	return r.uncountUserRedemption(ctx, id, userID)
+}
+
+func (r *mongoRepository) userRedemptions(ctx context.Context, id primitive.ObjectID, userID string) (int64, error) {
+	var redemption promotionRedemption
+
+	start := time.Now()
+	err := r.db.Collection(db.PromotionRedemptionsCollection).
+		FindOne(ctx, bson.M{"promotionID": id, "userID": userID}).
+		Decode(&redemption)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = nil
+	}
+	r.recordPromotionQuery("find", db.PromotionRedemptionsCollection, start, err)
+
+	return redemption.Count, err
+}
+
+// countUserRedemption counts one redemption for the user, it returns ErrPromotionExhausted
+// when the user already redeemed the code limit times. A zero limit means unlimited.
+func (r *mongoRepository) countUserRedemption(ctx context.Context, id primitive.ObjectID, userID string, limit int64) error {
+	collection := r.db.Collection(db.PromotionRedemptionsCollection)
+	key := bson.M{"promotionID": id, "userID": userID}
+
+	filter := bson.M{"promotionID": id, "userID": userID}
+	if limit > 0 {
+		filter["count"] = bson.M{"$lt": limit}
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		start := time.Now()
+		result, err := collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"count": 1}})
+		r.recordPromotionQuery("update", db.PromotionRedemptionsCollection, start, err)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 1 {
+			return nil
+		}
+
+		// Nothing below the limit: either the user reached it or never redeemed the code
+		start = time.Now()
+		existing, err := collection.CountDocuments(ctx, key)
+		r.recordPromotionQuery("count", db.PromotionRedemptionsCollection, start, err)
+		if err != nil {
+			return err
+		}
+		if existing > 0 {
+			return domain.ErrPromotionExhausted
+		}
+
+		start = time.Now()
+		_, err = collection.InsertOne(ctx, promotionRedemption{PromotionID: id, UserID: userID, Count: 1})
+		if mongo.IsDuplicateKeyError(err) {
+			// A concurrent first redemption of the user won, count on its document
+			r.recordPromotionQuery("insert", db.PromotionRedemptionsCollection, start, nil)
+			continue
+		}
+		r.recordPromotionQuery("insert", db.PromotionRedemptionsCollection, start, err)
+		return err
+	}
+
+	return domain.ErrPromotionExhausted
+}
+
+func (r *mongoRepository) uncountUserRedemption(ctx context.Context, id primitive.ObjectID, userID string) error {
+	filter := bson.M{"promotionID": id, "userID": userID, "count": bson.M{"$gt": 0}}
+
+	start := time.Now()
+	_, err := r.db.Collection(db.PromotionRedemptionsCollection).UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"count": -1}})
+	r.recordPromotionQuery("update", db.PromotionRedemptionsCollection, start, err)
+
+	return err
+}
+
+func (r *mongoRepository) recordPromotionQuery(op, collection string, start time.Time, err error) {
+	if r.metrics == nil {
+		return
+	}
+
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	r.metrics.RecordDBQuery(op, collection, status, time.Since(start))
+}
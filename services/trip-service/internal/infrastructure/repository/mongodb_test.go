@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/shared/db"
+	"github.com/Anurag-Mishra22/taxi/shared/db/migrate"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TestMongoRepository needs a MongoDB replica set for the transactions, in TRIP_TEST_MONGODB_URI,
+// the CI workflow starts one.
+// Every test runs in its own database, dropped afterwards.
+func TestMongoRepository(t *testing.T) {
+	uri := os.Getenv("TRIP_TEST_MONGODB_URI")
+	if uri == "" {
+		t.Skip("TRIP_TEST_MONGODB_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := db.NewMongoClient(ctx, &db.MongoConfig{URI: uri, Database: "trip-service-test"})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Disconnect(ctx) })
+
+	newDatabase := func(t *testing.T) *mongo.Database {
+		database := client.Database("trip-service-test-" + primitive.NewObjectID().Hex())
+		t.Cleanup(func() { database.Drop(ctx) })
+
+		migrator, err := migrate.NewMigrator(database, Migrations("USD"))
+		require.NoError(t, err)
+		require.NoError(t, migrator.Up(ctx, migrator.Latest()))
+
+		return database
+	}
+
+	testTripRepository(t, func(t *testing.T) domain.TripRepository {
+		return NewMongoRepository(newDatabase(t), nil)
+	})
+
+	testOutboxRepository(t, func(t *testing.T) outboxRepository {
+		return NewMongoRepository(newDatabase(t), nil)
+	})
+
+	testPromotionRepository(t, func(t *testing.T) (domain.PromotionRepository, func(p *domain.PromotionModel)) {
+		database := newDatabase(t)
+		save := func(p *domain.PromotionModel) {
+			p.Code = domain.NormalizePromoCode(p.Code)
+			_, err := database.Collection(db.PromotionsCollection).InsertOne(ctx, p)
+			require.NoError(t, err)
+		}
+		return NewMongoRepository(database, nil), save
+	})
+}
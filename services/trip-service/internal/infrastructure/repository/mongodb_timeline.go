@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/shared/db"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *mongoRepository) AppendTimelineEntry(ctx context.Context, entry *domain.TimelineEntry) error {
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+
+	start := time.Now()
+	var err error
+	if entry.EventID == "" {
+		_, err = r.db.Collection(db.TimelineCollection).InsertOne(ctx, entry)
+	} else {
+		// An upsert rather than an insert, a duplicate key error would abort the surrounding transaction
+		filter := bson.M{"tripID": entry.TripID, "eventID": entry.EventID}
+		opts := options.Update().SetUpsert(true)
+		_, err = r.db.Collection(db.TimelineCollection).UpdateOne(ctx, filter, bson.M{"$setOnInsert": entry}, opts)
+	}
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("insert", db.TimelineCollection, status, time.Since(start))
+	}
+
+	return err
+}
+
+func (r *mongoRepository) GetTripTimeline(ctx context.Context, tripID string) ([]*domain.TimelineEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}})
+
+	start := time.Now()
+	cursor, err := r.db.Collection(db.TimelineCollection).Find(ctx, bson.M{"tripID": tripID}, opts)
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("find", db.TimelineCollection, status, time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []*domain.TimelineEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
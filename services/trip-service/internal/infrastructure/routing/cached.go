@@ -0,0 +1,105 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	tripTypes "github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
+	"github.com/Anurag-Mishra22/taxi/shared/cache"
+	"github.com/Anurag-Mishra22/taxi/shared/metrics"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// routeCacheName labels the route cache in the cache metrics
+const routeCacheName = "route"
+
+// cachedProvider keeps the routes of another provider in Redis. The waypoints are
+// rounded before building the key, so previews of nearby locations share a route.
+// Identical lookups running at the same time in this pod share one request.
+type cachedProvider struct {
+	next      domain.RouteProvider
+	redis     *cache.RedisClient
+	ttl       time.Duration
+	precision int
+	inflight  singleflight.Group
+	metrics   *metrics.Metrics
+}
+
+// NewCachedProvider wraps next with a Redis cache, precision is the number of decimals
+// the coordinates are rounded to (4 decimals is about 10 meters).
+// redis can be nil to only coalesce the concurrent lookups.
+func NewCachedProvider(next domain.RouteProvider, redis *cache.RedisClient, ttl time.Duration, precision int, m *metrics.Metrics) *cachedProvider {
+	return &cachedProvider{
+		next:      next,
+		redis:     redis,
+		ttl:       ttl,
+		precision: precision,
+		metrics:   m,
+	}
+}
+
+func (p *cachedProvider) Route(ctx context.Context, waypoints []*types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	key := p.key(waypoints)
+
+	route, err, _ := p.inflight.Do(key, func() (any, error) {
+		// The lookup is shared, it must not fail because the first caller went away
+		return p.lookup(context.WithoutCancel(ctx), key, waypoints)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return route.(*tripTypes.OsrmApiResponse), nil
+}
+
+func (p *cachedProvider) lookup(ctx context.Context, key string, waypoints []*types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	if p.redis != nil {
+		var cached tripTypes.OsrmApiResponse
+		err := p.redis.GetJSON(ctx, key, &cached)
+		if err == nil {
+			p.recordLookup(true)
+			return &cached, nil
+		}
+		if !errors.Is(err, redis.Nil) {
+			// A cache failure only costs a route lookup
+			log.Printf("Failed to read route %s from the cache: %v", key, err)
+		}
+	}
+	p.recordLookup(false)
+
+	route, err := p.next.Route(ctx, waypoints)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.redis != nil {
+		if err := p.redis.SetJSON(ctx, key, route, p.ttl); err != nil {
+			log.Printf("Failed to cache route %s: %v", key, err)
+		}
+	}
+
+	return route, nil
+}
+
+// key rounds the waypoints, "lon,lat;lon,lat" like the OSRM URLs
+func (p *cachedProvider) key(waypoints []*types.Coordinate) string {
+	coordinates := make([]string, len(waypoints))
+	for i, w := range waypoints {
+		coordinates[i] = fmt.Sprintf("%.*f,%.*f", p.precision, w.Longitude, p.precision, w.Latitude)
+	}
+	return "route:" + strings.Join(coordinates, ";")
+}
+
+func (p *cachedProvider) recordLookup(hit bool) {
+	if p.metrics != nil && p.redis != nil {
+		p.metrics.RecordCacheLookup(routeCacheName, hit)
+	}
+}
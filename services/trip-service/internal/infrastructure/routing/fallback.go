@@ -0,0 +1,55 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	tripTypes "github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+)
+
+// NamedProvider is a provider of a fallback chain, the name is only used in logs
+type NamedProvider struct {
+	Name     string
+	Provider domain.RouteProvider
+}
+
+// fallbackProvider asks its providers in order and returns the first route found.
+// The next provider is only asked when the previous one is unavailable: a route that
+// doesn't exist doesn't exist for the other providers either.
+type fallbackProvider struct {
+	providers []NamedProvider
+}
+
+func NewFallbackProvider(providers ...NamedProvider) *fallbackProvider {
+	return &fallbackProvider{providers: providers}
+}
+
+func (p *fallbackProvider) Route(ctx context.Context, waypoints []*types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	var errs []error
+
+	for _, provider := range p.providers {
+		route, err := provider.Provider.Route(ctx, waypoints)
+		if err == nil {
+			return route, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name, err))
+		if !fallsBack(err) || ctx.Err() != nil {
+			break
+		}
+
+		log.Printf("Route provider %s failed: %v", provider.Name, err)
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// fallsBack tells if the next provider can answer after err: timeouts, server errors
+// and open breakers, or a fixture provider without a recording for the waypoints
+func fallsBack(err error) bool {
+	return errors.Is(err, ErrNoFixture) || IsRouteFailure(err)
+}
@@ -0,0 +1,60 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	tripTypes "github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+)
+
+// ErrNoFixture is returned for waypoints without a recorded route. It is a route not found
+// for the callers, but the next provider of a fallback chain is still asked.
+var ErrNoFixture = fmt.Errorf("%w: no recorded route", domain.ErrRouteNotFound)
+
+// fixtureProvider replays recorded OSRM responses, so tests and demos get realistic
+// routes without the network. Routes are keyed by their waypoints, see FixtureKey.
+type fixtureProvider struct {
+	routes map[string]*tripTypes.OsrmApiResponse
+}
+
+func NewFixtureProvider(routes map[string]*tripTypes.OsrmApiResponse) *fixtureProvider {
+	return &fixtureProvider{routes: routes}
+}
+
+// NewFileFixtureProvider loads the fixtures from a JSON object mapping keys to OSRM responses
+func NewFileFixtureProvider(path string) (*fixtureProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read route fixtures: %w", err)
+	}
+
+	var routes map[string]*tripTypes.OsrmApiResponse
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return nil, fmt.Errorf("failed to parse route fixtures: %w", err)
+	}
+
+	return NewFixtureProvider(routes), nil
+}
+
+// FixtureKey identifies a route by its waypoints, as "lon,lat;lon,lat" with 5 decimals
+// (about one meter), the same order as in the OSRM URLs
+func FixtureKey(waypoints []*types.Coordinate) string {
+	coordinates := make([]string, len(waypoints))
+	for i, w := range waypoints {
+		coordinates[i] = fmt.Sprintf("%.5f,%.5f", w.Longitude, w.Latitude)
+	}
+	return strings.Join(coordinates, ";")
+}
+
+func (p *fixtureProvider) Route(ctx context.Context, waypoints []*types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	route, ok := p.routes[FixtureKey(waypoints)]
+	if !ok {
+		return nil, fmt.Errorf("%w for %s", ErrNoFixture, FixtureKey(waypoints))
+	}
+	return route, nil
+}
@@ -0,0 +1,48 @@
+package routing
+
+import (
+	"context"
+
+	tripTypes "github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+)
+
+// greatCircleProvider estimates routes without any external service: every leg is
+// the great-circle distance stretched by a road factor, driven at an average speed
+type greatCircleProvider struct {
+	roadFactor     float64
+	speedKmPerHour float64
+}
+
+// NewGreatCircleProvider creates an offline estimator. roadFactor accounts for roads
+// not being straight lines, 1.3 is a common value for cities.
+func NewGreatCircleProvider(roadFactor, speedKmPerHour float64) *greatCircleProvider {
+	return &greatCircleProvider{
+		roadFactor:     roadFactor,
+		speedKmPerHour: speedKmPerHour,
+	}
+}
+
+func (p *greatCircleProvider) Route(ctx context.Context, waypoints []*types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	route := tripTypes.OsrmRoute{}
+
+	for i, w := range waypoints {
+		// Same [longitude, latitude] order as the OSRM GeoJSON geometry
+		route.Geometry.Coordinates = append(route.Geometry.Coordinates, []float64{w.Longitude, w.Latitude})
+		if i == 0 {
+			continue
+		}
+
+		distanceKm := waypoints[i-1].DistanceKm(w) * p.roadFactor
+		leg := tripTypes.OsrmLeg{
+			Distance: distanceKm * 1000,
+			Duration: distanceKm / p.speedKmPerHour * 3600,
+		}
+
+		route.Legs = append(route.Legs, leg)
+		route.Distance += leg.Distance
+		route.Duration += leg.Duration
+	}
+
+	return &tripTypes.OsrmApiResponse{Routes: []tripTypes.OsrmRoute{route}}, nil
+}
@@ -0,0 +1,107 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	tripTypes "github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
+	"github.com/Anurag-Mishra22/taxi/shared/metrics"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+)
+
+// osrmProvider fetches routes from an OSRM server,
+// the public demo server or our self hosted one
+type osrmProvider struct {
+	baseURL string
+	client  *http.Client
+	metrics *metrics.Metrics
+}
+
+// NewOSRMProvider creates an OSRM client, timeout bounds the whole request
+// so a slow server can't block the trip previews
+func NewOSRMProvider(baseURL string, timeout time.Duration, m *metrics.Metrics) *osrmProvider {
+	return &osrmProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				MaxIdleConnsPerHost:   10,
+				IdleConnTimeout:       90 * time.Second,
+				TLSHandshakeTimeout:   timeout,
+				ResponseHeaderTimeout: timeout,
+			},
+		},
+		metrics: m,
+	}
+}
+
+func (p *osrmProvider) Route(ctx context.Context, waypoints []*types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	start := time.Now()
+	route, err := p.fetch(ctx, waypoints)
+
+	if p.metrics != nil {
+		status := "success"
+		if err != nil {
+			status = "error"
+		}
+		p.metrics.ExternalAPICallsTotal.WithLabelValues("osrm", status).Inc()
+		p.metrics.ExternalAPICallDuration.WithLabelValues("osrm").Observe(time.Since(start).Seconds())
+	}
+
+	return route, err
+}
+
+func (p *osrmProvider) fetch(ctx context.Context, waypoints []*types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	coordinates := make([]string, len(waypoints))
+	for i, w := range waypoints {
+		coordinates[i] = fmt.Sprintf("%f,%f", w.Longitude, w.Latitude)
+	}
+
+	url := fmt.Sprintf(
+		"%s/route/v1/driving/%s?overview=full&geometries=geojson",
+		p.baseURL,
+		strings.Join(coordinates, ";"),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch route from OSRM API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response: %w", err)
+	}
+
+	// OSRM answers 400 with code "NoRoute" when the waypoints can't be connected
+	var routeResp struct {
+		Code string `json:"code"`
+		tripTypes.OsrmApiResponse
+	}
+	if err := json.Unmarshal(body, &routeResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response (status %d): %w", resp.StatusCode, err)
+	}
+
+	if routeResp.Code == "NoRoute" || (resp.StatusCode == http.StatusOK && len(routeResp.Routes) == 0) {
+		return nil, domain.ErrRouteNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OSRM API returned status %d: %s", resp.StatusCode, routeResp.Code)
+	}
+
+	return &routeResp.OsrmApiResponse, nil
+}
@@ -0,0 +1,37 @@
+package routing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	tripTypes "github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
+	"github.com/Anurag-Mishra22/taxi/shared/resilience"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+)
+
+// resilientProvider calls another provider through a resilience policy. Inside a fallback
+// chain the next provider answers right away while the breaker is open.
+type resilientProvider struct {
+	next   domain.RouteProvider
+	policy *resilience.Policy
+}
+
+func NewResilientProvider(next domain.RouteProvider, policy *resilience.Policy) *resilientProvider {
+	return &resilientProvider{
+		next:   next,
+		policy: policy,
+	}
+}
+
+func (p *resilientProvider) Route(ctx context.Context, waypoints []*types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	return resilience.Call(ctx, p.policy, func(ctx context.Context) (*tripTypes.OsrmApiResponse, error) {
+		return p.next.Route(ctx, waypoints)
+	})
+}
+
+// IsRouteFailure is the breaker failure check of route providers,
+// a route that doesn't exist says nothing about the provider health
+func IsRouteFailure(err error) bool {
+	return err != nil && !errors.Is(err, domain.ErrRouteNotFound) && !errors.Is(err, context.Canceled)
+}
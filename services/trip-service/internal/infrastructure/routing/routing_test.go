@@ -0,0 +1,250 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	tripTypes "github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
+	"github.com/Anurag-Mishra22/taxi/shared/cache"
+	"github.com/Anurag-Mishra22/taxi/shared/resilience"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	rossio  = &types.Coordinate{Latitude: 38.7139, Longitude: -9.1394}
+	belem   = &types.Coordinate{Latitude: 38.6916, Longitude: -9.2160}
+	airport = &types.Coordinate{Latitude: 38.7742, Longitude: -9.1342}
+)
+
+func TestGreatCircleProvider(t *testing.T) {
+	provider := NewGreatCircleProvider(1.3, 30)
+
+	resp, err := provider.Route(context.Background(), []*types.Coordinate{belem, rossio, airport})
+	require.NoError(t, err)
+
+	route := resp.Routes[0]
+	require.Len(t, route.Legs, 2)
+	assert.Len(t, route.Geometry.Coordinates, 3)
+
+	// Belém to Rossio is about 7.1 km as the crow flies
+	assert.InDelta(t, 7100*1.3, route.Legs[0].Distance, 200)
+	assert.InDelta(t, route.Legs[0].Distance/1000/30*3600, route.Legs[0].Duration, 0.001)
+	assert.InDelta(t, route.Legs[0].Distance+route.Legs[1].Distance, route.Distance, 0.001)
+}
+
+func TestFixtureProvider(t *testing.T) {
+	recorded := &tripTypes.OsrmApiResponse{Routes: []tripTypes.OsrmRoute{{Distance: 8412, Duration: 987}}}
+	provider := NewFixtureProvider(map[string]*tripTypes.OsrmApiResponse{
+		"-9.21600,38.69160;-9.13940,38.71390": recorded,
+	})
+
+	resp, err := provider.Route(context.Background(), []*types.Coordinate{belem, rossio})
+	require.NoError(t, err)
+	assert.Equal(t, recorded, resp)
+
+	_, err = provider.Route(context.Background(), []*types.Coordinate{rossio, belem})
+	assert.True(t, errors.Is(err, domain.ErrRouteNotFound))
+}
+
+func TestOSRMProvider(t *testing.T) {
+	var requested *url.URL
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL
+		fmt.Fprint(w, `{"code":"Ok","routes":[{"distance":14200,"duration":1320,
+			"geometry":{"coordinates":[[-9.216,38.6916],[-9.1394,38.7139],[-9.1342,38.7742]]},
+			"legs":[{"distance":8400,"duration":780},{"distance":5800,"duration":540}]}]}`)
+	}))
+	defer server.Close()
+
+	resp, err := NewOSRMProvider(server.URL+"/", time.Second, nil).
+		Route(context.Background(), []*types.Coordinate{belem, rossio, airport})
+	require.NoError(t, err)
+
+	// Every waypoint is sent in order, as longitude,latitude
+	assert.Equal(t, "/route/v1/driving/-9.216000,38.691600;-9.139400,38.713900;-9.134200,38.774200", requested.Path)
+	assert.Equal(t, "full", requested.Query().Get("overview"))
+	assert.Equal(t, "geojson", requested.Query().Get("geometries"))
+
+	route := resp.Routes[0]
+	assert.Equal(t, 14200.0, route.Distance)
+	assert.Equal(t, []tripTypes.OsrmLeg{{Distance: 8400, Duration: 780}, {Distance: 5800, Duration: 540}}, route.Legs)
+	assert.Len(t, route.Geometry.Coordinates, 3)
+}
+
+func TestOSRMProviderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		notFound bool
+	}{
+		{"no_route", http.StatusBadRequest, `{"code":"NoRoute"}`, true},
+		{"no_routes", http.StatusOK, `{"code":"Ok","routes":[]}`, true},
+		{"server_error", http.StatusBadGateway, `{"code":"Unavailable"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			_, err := NewOSRMProvider(server.URL, time.Second, nil).
+				Route(context.Background(), []*types.Coordinate{belem, rossio})
+			require.Error(t, err)
+			assert.Equal(t, tt.notFound, errors.Is(err, domain.ErrRouteNotFound))
+		})
+	}
+}
+
+func TestFallbackProvider(t *testing.T) {
+	failing := NewFixtureProvider(nil)
+	provider := NewFallbackProvider(
+		NamedProvider{Name: "fixture", Provider: failing},
+		NamedProvider{Name: "greatcircle", Provider: NewGreatCircleProvider(1.3, 30)},
+	)
+
+	resp, err := provider.Route(context.Background(), []*types.Coordinate{belem, rossio})
+	require.NoError(t, err)
+	assert.Len(t, resp.Routes[0].Legs, 1)
+
+	_, err = NewFallbackProvider(NamedProvider{Name: "fixture", Provider: failing}).
+		Route(context.Background(), []*types.Coordinate{belem, rossio})
+	assert.True(t, errors.Is(err, domain.ErrRouteNotFound))
+}
+
+// stubProvider fails every lookup with err and counts them
+type stubProvider struct {
+	err   error
+	calls int
+}
+
+func (p *stubProvider) Route(ctx context.Context, waypoints []*types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	p.calls++
+	return nil, p.err
+}
+
+func TestFallbackProviderOnlyFallsBackWhenUnavailable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		fellBack bool
+	}{
+		{"route_not_found", domain.ErrRouteNotFound, false},
+		{"cancelled", context.Canceled, false},
+		{"timeout", context.DeadlineExceeded, true},
+		{"server_error", errors.New("OSRM API returned status 502"), true},
+		{"breaker_open", resilience.ErrCircuitOpen, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &stubProvider{err: tt.err}
+			secondary := &stubProvider{err: domain.ErrRouteNotFound}
+
+			_, err := NewFallbackProvider(
+				NamedProvider{Name: "primary", Provider: primary},
+				NamedProvider{Name: "secondary", Provider: secondary},
+			).Route(context.Background(), []*types.Coordinate{belem, rossio})
+
+			assert.True(t, errors.Is(err, tt.err))
+			assert.Equal(t, 1, primary.calls)
+			assert.Equal(t, tt.fellBack, secondary.calls == 1)
+		})
+	}
+}
+
+// blockingProvider counts its lookups and holds them until release is closed,
+// started receives every lookup as it starts
+type blockingProvider struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingProvider() *blockingProvider {
+	return &blockingProvider{started: make(chan struct{}, 16), release: make(chan struct{})}
+}
+
+func (p *blockingProvider) Route(ctx context.Context, waypoints []*types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	p.calls.Add(1)
+	p.started <- struct{}{}
+	<-p.release
+	return NewGreatCircleProvider(1.3, 30).Route(ctx, waypoints)
+}
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *cache.RedisClient) {
+	server := miniredis.RunT(t)
+	t.Setenv("REDIS_HOST", server.Host())
+	t.Setenv("REDIS_PORT", server.Port())
+
+	client, err := cache.NewRedisClient()
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return server, client
+}
+
+func TestCachedProviderCoalescesLookups(t *testing.T) {
+	server, redisClient := newTestRedis(t)
+	next := newBlockingProvider()
+	provider := NewCachedProvider(next, redisClient, time.Minute, 4, nil)
+
+	// Both pickups round to the same key
+	nearRossio := &types.Coordinate{Latitude: 38.71392, Longitude: -9.13941}
+
+	var wg sync.WaitGroup
+	lookup := func(pickup *types.Coordinate) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := provider.Route(context.Background(), []*types.Coordinate{pickup, airport})
+			assert.NoError(t, err)
+		}()
+	}
+
+	// The other lookups either join the one in flight or find its route in the cache
+	lookup(rossio)
+	<-next.started
+	for _, pickup := range []*types.Coordinate{nearRossio, rossio, nearRossio} {
+		lookup(pickup)
+	}
+	close(next.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), next.calls.Load())
+	assert.Len(t, server.Keys(), 1)
+}
+
+func TestCachedProviderOnlyCachesItsOwnRoutes(t *testing.T) {
+	server, redisClient := newTestRedis(t)
+	primary := &stubProvider{err: errors.New("OSRM API returned status 502")}
+	provider := NewFallbackProvider(
+		NamedProvider{Name: "primary", Provider: NewCachedProvider(primary, redisClient, time.Minute, 4, nil)},
+		NamedProvider{Name: "greatcircle", Provider: NewGreatCircleProvider(1.3, 30)},
+	)
+
+	for i := 0; i < 2; i++ {
+		_, err := provider.Route(context.Background(), []*types.Coordinate{rossio, airport})
+		require.NoError(t, err)
+	}
+
+	// The estimates of the outage are never cached, the primary is asked every time
+	assert.Equal(t, 2, primary.calls)
+	assert.Empty(t, server.Keys())
+}
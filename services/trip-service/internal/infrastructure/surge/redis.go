@@ -0,0 +1,164 @@
+package surge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/shared/cache"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// demandKey counts the trip requests of a package in a cell for one demand window
+	demandKey      = "surge:demand:%s:%s:%d"
+	unfulfilledKey = "surge:unfulfilled:%s:%s:%d"
+	multiplierKey  = "surge:multiplier:%s:%s"
+)
+
+type storedMultiplier struct {
+	Value     float64   `json:"value"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// storeMultiplierScript sets KEYS[1] to ARGV[2] for ARGV[3] milliseconds, unless another
+// value than ARGV[1] was stored in the meantime. It returns the value the key ends up holding.
+var storeMultiplierScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1]) or ''
+if current ~= '' and current ~= ARGV[1] then
+	return current
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return ARGV[2]
+`)
+
+// RedisSurgePricer keeps the demand counters and the smoothed multipliers in Redis,
+// so every trip-service pod quotes the same price.
+type RedisSurgePricer struct {
+	redis  *cache.RedisClient
+	supply DriverSupply
+	config domain.SurgeConfig
+}
+
+func NewRedisSurgePricer(redis *cache.RedisClient, supply DriverSupply, config domain.SurgeConfig) *RedisSurgePricer {
+	return &RedisSurgePricer{
+		redis:  redis,
+		supply: supply,
+		config: config,
+	}
+}
+
+func (p *RedisSurgePricer) RecordDemand(ctx context.Context, packageSlug, cell string, unfulfilled bool) error {
+	keyFormat := demandKey
+	if unfulfilled {
+		keyFormat = unfulfilledKey
+	}
+
+	key := fmt.Sprintf(keyFormat, packageSlug, cell, p.window(time.Now()))
+	if _, err := p.redis.Incr(ctx, key); err != nil {
+		return fmt.Errorf("failed to record demand: %w", err)
+	}
+
+	// The previous window is still read while the current one fills up
+	return p.redis.Expire(ctx, key, 2*p.config.DemandWindow)
+}
+
+func (p *RedisSurgePricer) Multiplier(ctx context.Context, packageSlug, cell string) (float64, error) {
+	now := time.Now()
+	key := fmt.Sprintf(multiplierKey, packageSlug, cell)
+
+	previous, err := p.redis.Get(ctx, key)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 1, fmt.Errorf("failed to get surge multiplier: %w", err)
+	}
+
+	var stored storedMultiplier
+	if previous != "" {
+		if err := json.Unmarshal([]byte(previous), &stored); err != nil {
+			return 1, fmt.Errorf("failed to decode surge multiplier: %w", err)
+		}
+	}
+
+	if now.Sub(stored.UpdatedAt) < p.config.UpdateInterval {
+		return stored.Value, nil
+	}
+
+	supply, err := p.supply.CountDrivers(ctx, packageSlug, cell)
+	if err != nil {
+		return 1, err
+	}
+
+	demand, err := p.demand(ctx, demandKey, packageSlug, cell, now)
+	if err != nil {
+		return 1, err
+	}
+
+	unfulfilled, err := p.demand(ctx, unfulfilledKey, packageSlug, cell, now)
+	if err != nil {
+		return 1, err
+	}
+
+	updated, err := json.Marshal(storedMultiplier{
+		Value:     p.config.Smooth(stored.Value, p.config.Target(demand, unfulfilled, supply)),
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return 1, err
+	}
+
+	// Keep the average long enough to smooth the next computation, idle cells fade back to 1.
+	// When another pod updated the multiplier in the meantime, its value wins: the average
+	// moves once per interval, from the value every pod has seen.
+	ttl := 10 * p.config.DemandWindow
+	result, err := storeMultiplierScript.Run(ctx, p.redis.GetClient(), []string{key}, previous, updated, ttl.Milliseconds()).Text()
+	if err != nil {
+		return 1, fmt.Errorf("failed to store surge multiplier: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(result), &stored); err != nil {
+		return 1, fmt.Errorf("failed to decode surge multiplier: %w", err)
+	}
+
+	return stored.Value, nil
+}
+
+// demand estimates the number of requests over the last demand window,
+// weighting the previous window by how much of it still overlaps
+func (p *RedisSurgePricer) demand(ctx context.Context, keyFormat, packageSlug, cell string, now time.Time) (float64, error) {
+	window := p.window(now)
+
+	current, err := p.count(ctx, fmt.Sprintf(keyFormat, packageSlug, cell, window))
+	if err != nil {
+		return 0, err
+	}
+
+	previous, err := p.count(ctx, fmt.Sprintf(keyFormat, packageSlug, cell, window-1))
+	if err != nil {
+		return 0, err
+	}
+
+	windowStart := time.Unix(0, window*int64(p.config.DemandWindow))
+	elapsed := float64(now.Sub(windowStart)) / float64(p.config.DemandWindow)
+
+	return current + previous*(1-elapsed), nil
+}
+
+func (p *RedisSurgePricer) count(ctx context.Context, key string) (float64, error) {
+	value, err := p.redis.Get(ctx, key)
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get demand counter: %w", err)
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func (p *RedisSurgePricer) window(t time.Time) int64 {
+	return t.UnixNano() / int64(p.config.DemandWindow)
+}
@@ -0,0 +1,101 @@
+package surge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/shared/cache"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cell = "eyckr"
+
+// fakeSupply returns a fixed number of drivers, onCount runs on every count
+type fakeSupply struct {
+	drivers int64
+	onCount func()
+}
+
+func (s *fakeSupply) CountDrivers(ctx context.Context, packageSlug, cell string) (int64, error) {
+	if s.onCount != nil {
+		s.onCount()
+	}
+	return s.drivers, nil
+}
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *cache.RedisClient) {
+	server := miniredis.RunT(t)
+	t.Setenv("REDIS_HOST", server.Host())
+	t.Setenv("REDIS_PORT", server.Port())
+
+	client, err := cache.NewRedisClient()
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return server, client
+}
+
+func TestRedisSurgePricer(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestRedis(t)
+	pricer := NewRedisSurgePricer(client, &fakeSupply{drivers: 1}, domain.DefaultSurgeConfig())
+
+	multiplier, err := pricer.Multiplier(ctx, "sedan", cell)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, multiplier)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, pricer.RecordDemand(ctx, "sedan", cell, false))
+	}
+
+	// Still the stored multiplier until the update interval passes
+	multiplier, err = pricer.Multiplier(ctx, "sedan", cell)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, multiplier)
+
+	// Five requests for one driver target a 3x surge, the average moves 30% towards it
+	pricer.config.UpdateInterval = 0
+	multiplier, err = pricer.Multiplier(ctx, "sedan", cell)
+	require.NoError(t, err)
+	assert.Equal(t, 1.6, multiplier)
+
+	// The other packages and cells have their own demand
+	multiplier, err = pricer.Multiplier(ctx, "van", cell)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, multiplier)
+	multiplier, err = pricer.Multiplier(ctx, "sedan", "eyckq")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, multiplier)
+}
+
+func TestRedisSurgePricerKeepsAConcurrentUpdate(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestRedis(t)
+	key := fmt.Sprintf(multiplierKey, "sedan", cell)
+
+	stale, err := json.Marshal(storedMultiplier{Value: 1.2, UpdatedAt: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+	require.NoError(t, server.Set(key, string(stale)))
+
+	// Another pod stores its update while this one computes the multiplier
+	concurrent, err := json.Marshal(storedMultiplier{Value: 2.4, UpdatedAt: time.Now()})
+	require.NoError(t, err)
+	supply := &fakeSupply{drivers: 10, onCount: func() {
+		require.NoError(t, server.Set(key, string(concurrent)))
+	}}
+
+	multiplier, err := NewRedisSurgePricer(client, supply, domain.DefaultSurgeConfig()).Multiplier(ctx, "sedan", cell)
+	require.NoError(t, err)
+	assert.Equal(t, 2.4, multiplier)
+
+	stored, err := server.Get(key)
+	require.NoError(t, err)
+	assert.Equal(t, string(concurrent), stored)
+}
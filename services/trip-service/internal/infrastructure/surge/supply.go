@@ -0,0 +1,88 @@
+package surge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Anurag-Mishra22/taxi/shared/cache"
+	pbd "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// driversByPackageKey is the set of online drivers per package and driverDataPrefix
+// the hash holding the profile of a driver, both maintained by the driver-service
+const (
+	driversByPackageKey = "drivers:online:%s"
+	driverDataPrefix    = "driver:data:"
+)
+
+// driverStatusKey is the HASH of the status of the online drivers, maintained by the driver-service.
+// Only the drivers with driverStatusAvailable can be matched with a new trip.
+const (
+	driverStatusKey       = "drivers:status"
+	driverStatusAvailable = "available"
+)
+
+// DriverSupply counts the drivers who can serve the requests of a surge cell
+type DriverSupply interface {
+	CountDrivers(ctx context.Context, packageSlug, cell string) (int64, error)
+}
+
+// RedisDriverSupply counts the available drivers of the package located in the cell, from
+// the geohash of their profile. The drivers on a trip, with a pending offer or paused don't serve new requests.
+type RedisDriverSupply struct {
+	redis *cache.RedisClient
+}
+
+func NewRedisDriverSupply(redis *cache.RedisClient) *RedisDriverSupply {
+	return &RedisDriverSupply{redis: redis}
+}
+
+func (s *RedisDriverSupply) CountDrivers(ctx context.Context, packageSlug, cell string) (int64, error) {
+	driverIDs, err := s.redis.SMembers(ctx, fmt.Sprintf(driversByPackageKey, packageSlug))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the drivers of package %s: %w", packageSlug, err)
+	}
+
+	var inCell []string
+	for _, driverID := range driverIDs {
+		var driver pbd.Driver
+		err := s.redis.HGetJSON(ctx, driverDataPrefix+driverID, "data", &driver)
+		// The profile expired, the driver is gone
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to get driver %s: %w", driverID, err)
+		}
+
+		// A cell is a geohash prefix of every location inside it
+		if strings.HasPrefix(driver.Geohash, cell) {
+			inCell = append(inCell, driverID)
+		}
+	}
+	return s.countAvailable(ctx, inCell)
+}
+
+// countAvailable counts the drivers among driverIDs who are available
+func (s *RedisDriverSupply) countAvailable(ctx context.Context, driverIDs []string) (int64, error) {
+	if len(driverIDs) == 0 {
+		return 0, nil
+	}
+
+	statuses, err := s.redis.GetClient().HMGet(ctx, driverStatusKey, driverIDs...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the status of the drivers: %w", err)
+	}
+
+	var available int64
+	for _, status := range statuses {
+		if status == driverStatusAvailable {
+			available++
+		}
+	}
+	return available, nil
+}
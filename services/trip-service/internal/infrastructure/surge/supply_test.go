@@ -0,0 +1,43 @@
+package surge
+
+import (
+	"context"
+	"testing"
+
+	pbd "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisDriverSupplyCountsTheAvailableDriversOfTheCell(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestRedis(t)
+	supply := NewRedisDriverSupply(client)
+
+	for _, driver := range []*pbd.Driver{
+		{Id: "driver-1", PackageSlug: "sedan", Geohash: cell + "0000000"},
+		{Id: "driver-2", PackageSlug: "sedan", Geohash: cell + "zzzzzzz"},
+		// On a trip
+		{Id: "driver-6", PackageSlug: "sedan", Geohash: cell + "0000001"},
+		// Outside the cell
+		{Id: "driver-3", PackageSlug: "sedan", Geohash: "u4pruydqqvj"},
+		// Another package
+		{Id: "driver-4", PackageSlug: "van", Geohash: cell + "0000000"},
+	} {
+		require.NoError(t, client.SAdd(ctx, "drivers:online:"+driver.PackageSlug, driver.Id))
+		require.NoError(t, client.HSetJSON(ctx, "driver:data:"+driver.Id, "data", driver))
+		require.NoError(t, client.HSet(ctx, driverStatusKey, driver.Id, driverStatusAvailable))
+	}
+	require.NoError(t, client.HSet(ctx, driverStatusKey, "driver-6", "on_trip"))
+	// driver-5 has no profile, they disconnected
+	require.NoError(t, client.SAdd(ctx, "drivers:online:sedan", "driver-5"))
+
+	count, err := supply.CountDrivers(ctx, "sedan", cell)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	count, err = supply.CountDrivers(ctx, "suv", cell)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
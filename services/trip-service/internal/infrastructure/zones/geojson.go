@@ -0,0 +1,181 @@
+package zones
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+)
+
+// featureCollection is the GeoJSON file of the service zones, one feature per zone
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Properties zoneProperties `json:"properties"`
+	Geometry   struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+}
+
+type zoneProperties struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Packages replaces the package catalog in the zone
+	Packages []*domain.PackageModel `json:"packages"`
+	// Pricing overrides some fields of the default pricing config in the zone
+	Pricing json.RawMessage `json:"pricing"`
+}
+
+// LoadServiceZones reads the zones of a GeoJSON FeatureCollection of Polygon and MultiPolygon features.
+// The pricing of a zone is its overrides applied over the default pricing config.
+func LoadServiceZones(path string, pricing domain.PricingConfig) ([]*domain.ServiceZone, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service zones: %w", err)
+	}
+
+	var collection featureCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("failed to parse service zones: %w", err)
+	}
+
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("service zones must be a GeoJSON FeatureCollection, got %q", collection.Type)
+	}
+
+	zones := make([]*domain.ServiceZone, 0, len(collection.Features))
+	seen := make(map[string]bool, len(collection.Features))
+
+	for _, f := range collection.Features {
+		zone, err := newServiceZone(f, pricing)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[zone.ID] {
+			return nil, fmt.Errorf("service zone %q is defined twice", zone.ID)
+		}
+		seen[zone.ID] = true
+
+		zones = append(zones, zone)
+	}
+
+	return zones, nil
+}
+
+// ValidatePackages checks that every package of the zones is in the catalog, once per zone
+func ValidatePackages(zones []*domain.ServiceZone, catalog []*domain.PackageModel) error {
+	inCatalog := make(map[string]bool, len(catalog))
+	for _, p := range catalog {
+		inCatalog[p.Slug] = true
+	}
+
+	for _, zone := range zones {
+		seen := make(map[string]bool, len(zone.Packages))
+		for _, p := range zone.Packages {
+			if !inCatalog[p.Slug] {
+				return fmt.Errorf("service zone %q has the package %q that isn't in the package catalog", zone.ID, p.Slug)
+			}
+			if seen[p.Slug] {
+				return fmt.Errorf("service zone %q contains the package %q twice", zone.ID, p.Slug)
+			}
+			seen[p.Slug] = true
+		}
+	}
+
+	return nil
+}
+
+func newServiceZone(f feature, pricing domain.PricingConfig) (*domain.ServiceZone, error) {
+	props := f.Properties
+	if props.ID == "" {
+		return nil, fmt.Errorf("service zones contain a zone without id")
+	}
+
+	area, err := parseArea(f.Geometry.Type, f.Geometry.Coordinates)
+	if err != nil {
+		return nil, fmt.Errorf("invalid area for service zone %q: %w", props.ID, err)
+	}
+
+	zone := &domain.ServiceZone{
+		ID:       props.ID,
+		Name:     props.Name,
+		Area:     area,
+		Packages: props.Packages,
+	}
+
+	if props.Pricing != nil {
+		zone.Pricing, err = zonePricing(pricing, props.Pricing)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pricing for service zone %q: %w", props.ID, err)
+		}
+	}
+
+	return zone, nil
+}
+
+func parseArea(geometryType string, coordinates json.RawMessage) ([]domain.Polygon, error) {
+	var area []domain.Polygon
+
+	switch geometryType {
+	case "Polygon":
+		var polygon domain.Polygon
+		if err := json.Unmarshal(coordinates, &polygon); err != nil {
+			return nil, err
+		}
+		area = []domain.Polygon{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(coordinates, &area); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q", geometryType)
+	}
+
+	for _, polygon := range area {
+		if len(polygon) == 0 {
+			return nil, fmt.Errorf("polygon without rings")
+		}
+		for _, ring := range polygon {
+			// GeoJSON rings are closed, the first and last positions are the same
+			if len(ring) < 4 {
+				return nil, fmt.Errorf("rings need at least 4 positions")
+			}
+			for _, position := range ring {
+				if len(position) < 2 {
+					return nil, fmt.Errorf("positions need a longitude and a latitude")
+				}
+			}
+		}
+	}
+
+	return area, nil
+}
+
+func zonePricing(defaults domain.PricingConfig, overrides json.RawMessage) (*domain.PricingEngine, error) {
+	// Round trip the defaults, so the overrides can't change the slices they share with them
+	data, err := json.Marshal(defaults)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg domain.PricingConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(overrides, &cfg); err != nil {
+		return nil, err
+	}
+
+	// Fares, fees and payments all use the service currency
+	if cfg.Currency != defaults.Currency {
+		return nil, fmt.Errorf("currency %s differs from the service currency %s", cfg.Currency, defaults.Currency)
+	}
+
+	return domain.NewDefaultPricingEngine(cfg)
+}
@@ -0,0 +1,71 @@
+package zones
+
+import (
+	"testing"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadServiceZones(t *testing.T) {
+	zones, err := LoadServiceZones("../../../config/service_zones.geojson", domain.DefaultPricingConfig())
+	require.NoError(t, err)
+	require.Len(t, zones, 2)
+
+	area := domain.NewServiceArea(zones)
+
+	zone, err := area.Locate(&types.Coordinate{Latitude: 37.7749, Longitude: -122.4194})
+	require.NoError(t, err)
+	assert.Equal(t, "san-francisco", zone.ID)
+	assert.Len(t, zone.Packages, 2)
+	assert.NotNil(t, zone.Pricing)
+
+	zone, err = area.Locate(&types.Coordinate{Latitude: 37.6213, Longitude: -122.3790})
+	require.NoError(t, err)
+	assert.Equal(t, "sfo-airport", zone.ID)
+	assert.Nil(t, zone.Packages)
+
+	_, err = area.Locate(&types.Coordinate{Latitude: 40.7128, Longitude: -74.0060})
+	assert.ErrorIs(t, err, domain.ErrOutsideServiceArea)
+
+	assert.NoError(t, ValidatePackages(zones, domain.DefaultPackages()))
+}
+
+func TestValidatePackages(t *testing.T) {
+	catalog := []*domain.PackageModel{{Slug: "sedan"}, {Slug: "suv"}}
+
+	tests := []struct {
+		name     string
+		packages []*domain.PackageModel
+		valid    bool
+	}{
+		{"catalog_packages", nil, true},
+		{"packages_of_the_catalog", []*domain.PackageModel{{Slug: "sedan"}, {Slug: "suv"}}, true},
+		{"package_missing_from_the_catalog", []*domain.PackageModel{{Slug: "sedan"}, {Slug: "van"}}, false},
+		{"package_listed_twice", []*domain.PackageModel{{Slug: "sedan"}, {Slug: "sedan"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePackages([]*domain.ServiceZone{{ID: "zone", Packages: tt.packages}}, catalog)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestZonePricingKeepsTheDefaults(t *testing.T) {
+	defaults := domain.DefaultPricingConfig()
+
+	_, err := zonePricing(defaults, []byte(`{"peakHours": [{"startHour": 6, "endHour": 9}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultPricingConfig().PeakHours, defaults.PeakHours)
+
+	_, err = zonePricing(defaults, []byte(`{"currency": "EUR"}`))
+	assert.Error(t, err)
+}
@@ -2,45 +2,105 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"log"
-	"net/http"
 	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
 	tripTypes "github.com/Anurag-Mishra22/taxi/services/trip-service/pkg/types"
-	"github.com/Anurag-Mishra22/taxi/shared/env"
 	"github.com/Anurag-Mishra22/taxi/shared/metrics"
 	pbd "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
 	"github.com/Anurag-Mishra22/taxi/shared/proto/trip"
 	"github.com/Anurag-Mishra22/taxi/shared/types"
+	"log"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Config holds the business rules of the trip service
+type Config struct {
+	CancellationPolicy domain.CancellationPolicy
+	// FareTTL is how long a previewed fare can be used to start a trip
+	FareTTL  time.Duration
+	Pricing  *domain.PricingEngine
+	Schedule domain.SchedulePolicy
+	// ServiceArea restricts the pickups to its zones, nil accepts pickups anywhere
+	ServiceArea *domain.ServiceArea
+}
+
 type service struct {
-	repo    domain.TripRepository
-	metrics *metrics.Metrics
+	repo       domain.TripRepository
+	promotions domain.PromotionRepository
+	catalog    domain.PackageCatalog
+	routes     domain.RouteProvider
+	surge      domain.SurgePricer
+	metrics    *metrics.Metrics
+	config     Config
 }
 
-func NewService(repo domain.TripRepository, m *metrics.Metrics) *service {
+// NewService creates the trip service, surge can be nil to always quote the base price
+func NewService(repo domain.TripRepository, promotions domain.PromotionRepository, catalog domain.PackageCatalog, routes domain.RouteProvider, surge domain.SurgePricer, m *metrics.Metrics, cfg Config) *service {
 	return &service{
-		repo:    repo,
-		metrics: m,
+		repo:       repo,
+		promotions: promotions,
+		catalog:    catalog,
+		routes:     routes,
+		surge:      surge,
+		metrics:    m,
+		config:     cfg,
 	}
 }
 
+func (s *service) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.repo.WithTransaction(ctx, fn)
+}
+
 func (s *service) CreateTrip(ctx context.Context, fare *domain.RideFareModel) (*domain.TripModel, error) {
+	// The discount was quoted with the fare, but the code can only be
+	// counted as used once the rider actually starts the trip
+	if fare.PromotionID != nil {
+		if err := s.promotions.RedeemPromotion(ctx, *fare.PromotionID, fare.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Claim the fare first so concurrent requests can't start several trips from it
+	if err := s.repo.ConsumeRideFare(ctx, fare.ID.Hex()); err != nil {
+		s.releasePromotion(ctx, fare)
+		return nil, err
+	}
+
 	t := &domain.TripModel{
-		ID:       primitive.NewObjectID(),
-		UserID:   fare.UserID,
-		Status:   "pending",
-		RideFare: fare,
-		Driver:   &trip.TripDriver{},
+		ID:        primitive.NewObjectID(),
+		UserID:    fare.UserID,
+		Status:    domain.TripStatusPending,
+		RideFare:  fare,
+		Driver:    &trip.TripDriver{},
+		CreatedAt: time.Now(),
+		Stops:     domain.NewTripStops(fare.Stops),
+		ZoneID:    fare.ZoneID,
+		Pickup:    fare.Pickup,
+	}
+
+	// Fares quoted for a later pickup book the trip, the scheduler dispatches it
+	if fare.ScheduledFor != nil {
+		t.Status = domain.TripStatusScheduled
+		t.ScheduledFor = fare.ScheduledFor
 	}
 
 	trip, err := s.repo.CreateTrip(ctx, t)
+	if err != nil {
+		// The rider can retry with the same quote, unless another trip was created from it
+		if !errors.Is(err, domain.ErrFareConsumed) {
+			s.releaseFare(ctx, fare)
+		}
+		s.releasePromotion(ctx, fare)
+	} else {
+		reason := "trip requested"
+		if t.IsScheduled() {
+			reason = "trip booked for a later pickup"
+		}
+		err = s.repo.AppendTimelineEntry(ctx, domain.NewStatusChange(t.ID.Hex(), "", t.Status, domain.RiderActor(t.UserID), reason))
+	}
 	if err == nil && s.metrics != nil {
 		s.metrics.RecordTripCreated(fare.PackageSlug, "success")
 		s.metrics.ActiveTrips.Inc()
@@ -51,111 +111,224 @@ func (s *service) CreateTrip(ctx context.Context, fare *domain.RideFareModel) (*
 	return trip, err
 }
 
-func (s *service) GetRoute(ctx context.Context, pickup, destination *types.Coordinate, useOSRMApi bool) (*tripTypes.OsrmApiResponse, error) {
+// releaseFare makes the fare of a trip that could not be created usable again
+func (s *service) releaseFare(ctx context.Context, fare *domain.RideFareModel) {
+	if err := s.repo.ReleaseRideFare(ctx, fare.ID.Hex()); err != nil {
+		log.Printf("Failed to release fare %s: %v", fare.ID.Hex(), err)
+	}
+}
+
+// releasePromotion gives back the redemption of a fare whose trip could not be created
+func (s *service) releasePromotion(ctx context.Context, fare *domain.RideFareModel) {
+	if fare.PromotionID == nil {
+		return
+	}
+
+	if err := s.promotions.ReleasePromotion(ctx, *fare.PromotionID, fare.UserID); err != nil {
+		log.Printf("Failed to release promotion %s for fare %s: %v", fare.PromoCode, fare.ID.Hex(), err)
+	}
+}
+
+func (s *service) ApplyPromotion(ctx context.Context, fares []*domain.RideFareModel, userID, code string) error {
+	promotion, err := s.promotions.GetPromotionByCode(ctx, code, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := promotion.Validate(userID, time.Now()); err != nil {
+		return err
+	}
+
+	applied := false
+	for _, fare := range fares {
+		if !promotion.AppliesTo(fare.PackageSlug) {
+			continue
+		}
+		fare.ApplyDiscount(promotion)
+		applied = true
+	}
+
+	if !applied {
+		return domain.ErrPromotionNotApplicable
+	}
+
+	return nil
+}
+
+func (s *service) GetRoute(ctx context.Context, pickup, destination *types.Coordinate, stops []*types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	waypoints := make([]*types.Coordinate, 0, len(stops)+2)
+	waypoints = append(waypoints, pickup)
+	waypoints = append(waypoints, stops...)
+	waypoints = append(waypoints, destination)
+
+	route, err := s.routes.Route(ctx, waypoints)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get route: %w", err)
+	}
+
+	return route, nil
+}
+
+func (s *service) GetServiceZone(ctx context.Context, pickup *types.Coordinate) (*domain.ServiceZone, error) {
+	if s.config.ServiceArea == nil {
+		return nil, nil
+	}
+	return s.config.ServiceArea.Locate(pickup)
+}
+
+func (s *service) EstimatePackagesPriceWithRoute(ctx context.Context, route *tripTypes.OsrmApiResponse, zone *domain.ServiceZone, pickup *types.Coordinate, stops []*types.Coordinate, scheduledFor *time.Time) ([]*domain.RideFareModel, error) {
 	start := time.Now()
-	defer func() {
-		if s.metrics != nil {
-			duration := time.Since(start)
-			s.metrics.ExternalAPICallDuration.WithLabelValues("osrm").Observe(duration.Seconds())
+
+	if len(stops) > domain.MaxTripStops {
+		return nil, domain.ErrTooManyStops
+	}
+
+	pickupAt := time.Now()
+	if scheduledFor != nil {
+		if err := s.config.Schedule.Validate(*scheduledFor, pickupAt); err != nil {
+			return nil, err
 		}
-	}()
-	if !useOSRMApi {
-		// Return a simple mock response in case we don't want to rely on an external API
-		return &tripTypes.OsrmApiResponse{
-			Routes: []struct {
-				Distance float64 `json:"distance"`
-				Duration float64 `json:"duration"`
-				Geometry struct {
-					Coordinates [][]float64 `json:"coordinates"`
-				} `json:"geometry"`
-			}{
-				{
-					Distance: 5.0, // 5km
-					Duration: 600, // 10 minutes
-					Geometry: struct {
-						Coordinates [][]float64 `json:"coordinates"`
-					}{
-						Coordinates: [][]float64{
-							{pickup.Latitude, pickup.Longitude},
-							{destination.Latitude, destination.Longitude},
-						},
-					},
-				},
-			},
-		}, nil
-	}
-
-	// or use our self hosted API (check the course lesson: "Preparing for External API Failures")
-	baseURL := env.GetString("OSRM_API", "http://router.project-osrm.org")
-
-	url := fmt.Sprintf(
-		"%s/route/v1/driving/%f,%f;%f,%f?overview=full&geometries=geojson",
-		baseURL,
-		pickup.Longitude, pickup.Latitude,
-		destination.Longitude, destination.Latitude,
-	)
-
-	log.Printf("Started Fetching from OSRM API: URL: %s", url)
-
-	resp, err := http.Get(url)
+		pickupAt = *scheduledFor
+	}
+
+	packages, err := s.zonePackages(ctx, zone)
 	if err != nil {
+		return nil, err
+	}
+
+	pricing := s.config.Pricing
+	zoneID := ""
+	if zone != nil {
+		zoneID = zone.ID
+		if zone.Pricing != nil {
+			pricing = zone.Pricing
+		}
+	}
+
+	estimatedFares := make([]*domain.RideFareModel, len(packages))
+	cell := domain.SurgeCell(pickup.Latitude, pickup.Longitude)
+
+	for i, p := range packages {
+		// The current demand says nothing about the demand at a later pickup
+		surgeMultiplier := 1.0
+		if scheduledFor == nil {
+			surgeMultiplier = s.surgeMultiplier(ctx, p.Slug, cell)
+		}
+
+		estimatedFares[i] = s.estimateFareRoute(pricing, p, route, pickup, len(stops), pickupAt, surgeMultiplier)
+		estimatedFares[i].SurgeCell = cell
+		estimatedFares[i].ZoneID = zoneID
+		estimatedFares[i].ScheduledFor = scheduledFor
+		estimatedFares[i].Stops = stops
+		estimatedFares[i].Pickup = pickup
 		if s.metrics != nil {
-			s.metrics.ExternalAPICallsTotal.WithLabelValues("osrm", "error").Inc()
+			s.metrics.TripsFareCalculated.WithLabelValues(p.Slug).Inc()
 		}
-		return nil, fmt.Errorf("failed to fetch route from OSRM API: %v", err)
 	}
-	defer resp.Body.Close()
 
 	if s.metrics != nil {
-		s.metrics.ExternalAPICallsTotal.WithLabelValues("osrm", "success").Inc()
+		s.metrics.FareCalculationDuration.Observe(time.Since(start).Seconds())
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	return estimatedFares, nil
+}
+
+func (s *service) ListPackages(ctx context.Context, includeDisabled bool) ([]*domain.PackageModel, error) {
+	packages, err := s.catalog.ListPackages(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read the response: %v", err)
+		return nil, fmt.Errorf("failed to list packages: %w", err)
 	}
 
-	log.Printf("Got response from OSRM API %s", string(body))
+	if includeDisabled {
+		return packages, nil
+	}
 
-	var routeResp tripTypes.OsrmApiResponse
-	if err := json.Unmarshal(body, &routeResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %v", err)
+	enabled := make([]*domain.PackageModel, 0, len(packages))
+	for _, p := range packages {
+		if p.Enabled {
+			enabled = append(enabled, p)
+		}
 	}
 
-	return &routeResp, nil
+	return enabled, nil
 }
 
-func (s *service) EstimatePackagesPriceWithRoute(route *tripTypes.OsrmApiResponse) []*domain.RideFareModel {
-	start := time.Now()
-	baseFares := getBaseFares()
-	estimatedFares := make([]*domain.RideFareModel, len(baseFares))
+// zonePackages returns the bookable packages of a zone, the catalog ones unless the zone has its own
+func (s *service) zonePackages(ctx context.Context, zone *domain.ServiceZone) ([]*domain.PackageModel, error) {
+	if zone == nil || zone.Packages == nil {
+		return s.ListPackages(ctx, false)
+	}
 
-	for i, f := range baseFares {
-		estimatedFares[i] = estimateFareRoute(f, route)
-		if s.metrics != nil {
-			s.metrics.TripsFareCalculated.WithLabelValues(f.PackageSlug).Inc()
+	enabled := make([]*domain.PackageModel, 0, len(zone.Packages))
+	for _, p := range zone.Packages {
+		if p.Enabled {
+			enabled = append(enabled, p)
 		}
 	}
 
-	if s.metrics != nil {
-		s.metrics.FareCalculationDuration.Observe(time.Since(start).Seconds())
+	return enabled, nil
+}
+
+// surgeMultiplier never fails the preview, riders are quoted the base price
+// when the demand data is unavailable
+func (s *service) surgeMultiplier(ctx context.Context, packageSlug, cell string) float64 {
+	if s.surge == nil {
+		return 1
 	}
 
-	return estimatedFares
+	multiplier, err := s.surge.Multiplier(ctx, packageSlug, cell)
+	if err != nil {
+		log.Printf("Failed to get surge multiplier for package %s in cell %s: %v", packageSlug, cell, err)
+		return 1
+	}
+
+	return multiplier
+}
+
+func (s *service) RecordTripDemand(ctx context.Context, tripID string, unfulfilled bool) error {
+	if s.surge == nil {
+		return nil
+	}
+
+	trip, err := s.repo.GetTripByID(ctx, tripID)
+	if err != nil {
+		return fmt.Errorf("failed to get trip: %w", err)
+	}
+
+	fare := trip.RideFare
+	if fare == nil || fare.SurgeCell == "" {
+		// Trip quoted before surge pricing, its pickup cell is unknown
+		return nil
+	}
+
+	return s.surge.RecordDemand(ctx, fare.PackageSlug, fare.SurgeCell, unfulfilled)
 }
 
 func (s *service) GenerateTripFares(ctx context.Context, rideFares []*domain.RideFareModel, userID string, route *tripTypes.OsrmApiResponse) ([]*domain.RideFareModel, error) {
 	fares := make([]*domain.RideFareModel, len(rideFares))
+	now := time.Now()
 
 	for i, f := range rideFares {
 		id := primitive.NewObjectID()
 
 		fare := &domain.RideFareModel{
-			UserID:            userID,
-			ID:                id,
-			TotalPriceInCents: f.TotalPriceInCents,
-			PackageSlug:       f.PackageSlug,
-			Route:             route,
+			UserID:          userID,
+			ID:              id,
+			TotalPrice:      f.TotalPrice,
+			PackageSlug:     f.PackageSlug,
+			SurgeMultiplier: f.SurgeMultiplier,
+			SurgeCell:       f.SurgeCell,
+			LineItems:       f.LineItems,
+			Route:           route,
+			CreatedAt:       now,
+			ExpiresAt:       now.Add(s.config.FareTTL),
+			Discount:        f.Discount,
+			PromotionID:     f.PromotionID,
+			PromoCode:       f.PromoCode,
+			ScheduledFor:    f.ScheduledFor,
+			Stops:           f.Stops,
+			ZoneID:          f.ZoneID,
+			Pickup:          f.Pickup,
 		}
 
 		if err := s.repo.SaveRideFare(ctx, fare); err != nil {
@@ -174,64 +347,274 @@ func (s *service) GetAndValidateFare(ctx context.Context, fareID, userID string)
 		return nil, fmt.Errorf("failed to get trip fare: %w", err)
 	}
 
-	if fare == nil {
-		return nil, fmt.Errorf("fare does not exist")
-	}
-
 	// User fare validation (user is owner of this fare?)
 	if userID != fare.UserID {
-		return nil, fmt.Errorf("fare does not belong to the user")
+		return nil, domain.ErrFareNotOwned
+	}
+
+	if fare.ConsumedAt != nil {
+		return nil, domain.ErrFareConsumed
+	}
+
+	if fare.IsExpired(time.Now()) {
+		return nil, domain.ErrFareExpired
 	}
 
 	return fare, nil
 }
 
-func estimateFareRoute(f *domain.RideFareModel, route *tripTypes.OsrmApiResponse) *domain.RideFareModel {
-	pricingCfg := tripTypes.DefaultPricingConfig()
-	carPackagePrice := f.TotalPriceInCents
-
-	distanceKm := route.Routes[0].Distance
-	durationInMinutes := route.Routes[0].Duration
+func (s *service) estimateFareRoute(pricing *domain.PricingEngine, p *domain.PackageModel, route *tripTypes.OsrmApiResponse, pickup *types.Coordinate, stops int, pickupAt time.Time, surgeMultiplier float64) *domain.RideFareModel {
+	// OSRM returns the distance in meters, the duration in seconds
+	// and the geometry as GeoJSON [longitude, latitude] pairs
+	geometry := route.Routes[0].Geometry.Coordinates
+	path := make([]*types.Coordinate, len(geometry))
+	for i, c := range geometry {
+		path[i] = &types.Coordinate{Latitude: c[1], Longitude: c[0]}
+	}
 
-	distanceFare := distanceKm * pricingCfg.PricePerUnitOfDistance
-	timeFare := durationInMinutes * pricingCfg.PricingPerMinute
-	totalPrice := carPackagePrice + distanceFare + timeFare
+	totalPrice, lineItems := pricing.Price(domain.PricingInput{
+		Package:         p,
+		DistanceKm:      route.Routes[0].Distance / 1000,
+		DurationMinutes: route.Routes[0].Duration / 60,
+		Pickup:          pickup,
+		Path:            path,
+		PickupAt:        pickupAt,
+		SurgeMultiplier: surgeMultiplier,
+		Stops:           stops,
+	})
 
 	return &domain.RideFareModel{
-		TotalPriceInCents: totalPrice,
-		PackageSlug:       f.PackageSlug,
+		TotalPrice:      totalPrice,
+		PackageSlug:     p.Slug,
+		SurgeMultiplier: surgeMultiplier,
+		LineItems:       lineItems,
 	}
 }
 
-func getBaseFares() []*domain.RideFareModel {
-	return []*domain.RideFareModel{
-		{
-			PackageSlug:       "suv",
-			TotalPriceInCents: 200,
-		},
-		{
-			PackageSlug:       "sedan",
-			TotalPriceInCents: 350,
-		},
-		{
-			PackageSlug:       "van",
-			TotalPriceInCents: 400,
-		},
-		{
-			PackageSlug:       "luxury",
-			TotalPriceInCents: 1000,
-		},
+func (s *service) GetTripByID(ctx context.Context, id string) (*domain.TripModel, error) {
+	return s.repo.GetTripByID(ctx, id)
+}
+
+const (
+	defaultTripsPageSize = 20
+	maxTripsPageSize     = 100
+)
+
+func (s *service) ListTrips(ctx context.Context, filter domain.TripFilter) ([]*domain.TripModel, string, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultTripsPageSize
+	}
+	if filter.Limit > maxTripsPageSize {
+		filter.Limit = maxTripsPageSize
+	}
+
+	pageSize := filter.Limit
+	// Fetch one extra trip to know whether there is a next page
+	filter.Limit++
+
+	trips, err := s.repo.ListTrips(ctx, filter)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list trips: %w", err)
+	}
+
+	if len(trips) <= pageSize {
+		return trips, "", nil
 	}
+
+	trips = trips[:pageSize]
+	return trips, trips[pageSize-1].ID.Hex(), nil
 }
 
-func (s *service) GetTripByID(ctx context.Context, id string) (*domain.TripModel, error) {
-	return s.repo.GetTripByID(ctx, id)
+func (s *service) UpdateTrip(ctx context.Context, tripID string, status domain.TripStatus, driver *pbd.Driver, actor domain.TimelineActor, reason string) error {
+	trip, err := s.repo.GetTripByID(ctx, tripID)
+	if err != nil {
+		return fmt.Errorf("failed to get trip: %w", err)
+	}
+
+	// Reject early with a descriptive error, the repository re-checks the
+	// current status atomically in case the trip changed in the meantime
+	if !trip.Status.CanTransitionTo(status) {
+		return &domain.InvalidTransitionError{TripID: tripID, From: trip.Status, To: status}
+	}
+
+	if err := s.repo.UpdateTrip(ctx, tripID, status, driver); err != nil {
+		return err
+	}
+
+	entry := domain.NewStatusChange(tripID, trip.Status, status, actor, reason)
+	if driver != nil {
+		entry.DriverID = driver.Id
+	}
+	if err := s.repo.AppendTimelineEntry(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record the status change: %w", err)
+	}
+
+	if status.IsTerminal() && s.metrics != nil {
+		s.metrics.ActiveTrips.Dec()
+	}
+	return nil
 }
 
-func (s *service) UpdateTrip(ctx context.Context, tripID string, status string, driver *pbd.Driver) error {
-	err := s.repo.UpdateTrip(ctx, tripID, status, driver)
-	if err == nil && status == "payed" && s.metrics != nil {
+func (s *service) CancelTrip(ctx context.Context, tripID, userID, reason string) (*domain.TripModel, error) {
+	trip, err := s.repo.GetTripByID(ctx, tripID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trip: %w", err)
+	}
+
+	if trip.UserID != userID {
+		return nil, domain.ErrTripNotOwned
+	}
+
+	if !trip.Status.CanTransitionTo(domain.TripStatusCancelled) {
+		return nil, &domain.InvalidTransitionError{TripID: tripID, From: trip.Status, To: domain.TripStatusCancelled}
+	}
+
+	now := time.Now()
+	cancellation := &domain.TripCancellation{
+		Reason:      reason,
+		Fee:         s.config.CancellationPolicy.FeeFor(trip, now),
+		CancelledAt: now,
+	}
+
+	if err := s.repo.CancelTrip(ctx, tripID, cancellation); err != nil {
+		return nil, err
+	}
+
+	entry := domain.NewStatusChange(tripID, trip.Status, domain.TripStatusCancelled, domain.RiderActor(userID), reason)
+	if err := s.repo.AppendTimelineEntry(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to record the cancellation: %w", err)
+	}
+
+	if s.metrics != nil {
+		s.metrics.TripsCancelled.WithLabelValues("rider").Inc()
 		s.metrics.ActiveTrips.Dec()
 	}
+
+	trip.Status = domain.TripStatusCancelled
+	trip.Cancellation = cancellation
+
+	return trip, nil
+}
+
+func (s *service) ListScheduledTrips(ctx context.Context, userID string) ([]*domain.TripModel, error) {
+	trips, err := s.repo.ListScheduledTrips(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled trips: %w", err)
+	}
+	return trips, nil
+}
+
+func (s *service) ClaimDueScheduledTrip(ctx context.Context) (*domain.TripModel, error) {
+	now := time.Now()
+	return s.repo.ClaimDueScheduledTrip(ctx, s.config.Schedule.DueBefore(now), now.Add(s.config.Schedule.DispatchLease))
+}
+
+func (s *service) MarkTripDispatched(ctx context.Context, tripID, leaseID string) error {
+	if err := s.repo.MarkTripDispatched(ctx, tripID, leaseID); err != nil {
+		return err
+	}
+
+	// The claim already moved the trip to pending, it is recorded once it is dispatched
+	entry := domain.NewStatusChange(tripID, domain.TripStatusScheduled, domain.TripStatusPending, domain.SystemActor("trip-service"), "scheduled pickup is due")
+	return s.repo.AppendTimelineEntry(ctx, entry)
+}
+
+func (s *service) ReachStop(ctx context.Context, tripID, driverID string, stop int) (*domain.TripModel, error) {
+	trip, err := s.repo.GetTripByID(ctx, tripID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trip: %w", err)
+	}
+
+	if !trip.HasDriver() || trip.Driver.Id != driverID {
+		return nil, domain.ErrTripNotOwned
+	}
+
+	// Reject early with a descriptive error, the repository re-checks
+	// the current leg atomically in case of a duplicated command
+	if err := trip.CanReachStop(stop); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.repo.ReachStop(ctx, tripID, driverID, stop, now); err != nil {
+		return nil, err
+	}
+
+	trip.Stops[stop].ReachedAt = &now
+	trip.CurrentLeg++
+
+	entry := domain.NewTimelineEntry(tripID, domain.TimelineStopReached, domain.DriverActor(driverID), fmt.Sprintf("stop %d reached", stop+1))
+	if err := s.repo.AppendTimelineEntry(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to record the stop: %w", err)
+	}
+
+	return trip, nil
+}
+
+func (s *service) CompleteTrip(ctx context.Context, tripID, driverID string) (*domain.TripModel, error) {
+	trip, err := s.repo.GetTripByID(ctx, tripID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trip: %w", err)
+	}
+
+	if !trip.HasDriver() || trip.Driver.Id != driverID {
+		return nil, domain.ErrTripNotOwned
+	}
+
+	if err := s.UpdateTrip(ctx, tripID, domain.TripStatusCompleted, nil, domain.DriverActor(driverID), "driver completed the trip"); err != nil {
+		return nil, err
+	}
+
+	trip.Status = domain.TripStatusCompleted
+	return trip, nil
+}
+
+func (s *service) RecordTripPayment(ctx context.Context, tripID string) error {
+	if err := s.repo.MarkTripPaid(ctx, tripID, time.Now()); err != nil {
+		return fmt.Errorf("failed to record the payment: %w", err)
+	}
+
+	err := s.UpdateTrip(ctx, tripID, domain.TripStatusPaid, nil, domain.SystemActor("payment-service"), "payment succeeded")
+	if errors.Is(err, domain.ErrInvalidTransition) {
+		// The rider paid after the drop-off or the cancellation, or the payment was notified again
+		return nil
+	}
 	return err
 }
+
+func (s *service) RecordTimelineEntry(ctx context.Context, entry *domain.TimelineEntry) error {
+	if err := s.repo.AppendTimelineEntry(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record the %s event: %w", entry.Type, err)
+	}
+	return nil
+}
+
+func (s *service) GetTrip(ctx context.Context, tripID, userID, driverID string) (*domain.TripModel, error) {
+	trip, err := s.repo.GetTripByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The trip names its rider and its driver, only they see it
+	isRider := userID != "" && trip.UserID == userID
+	isDriver := driverID != "" && trip.HasDriver() && trip.Driver.Id == driverID
+	if !isRider && !isDriver {
+		return nil, domain.ErrTripNotOwned
+	}
+
+	return trip, nil
+}
+
+func (s *service) GetTripTimeline(ctx context.Context, tripID, userID, driverID string) ([]*domain.TimelineEntry, error) {
+	// The timeline of an unknown trip is an error, not an empty history. It names
+	// the drivers the trip was offered to, so it is scoped like the trip.
+	if _, err := s.GetTrip(ctx, tripID, userID, driverID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetTripTimeline(ctx, tripID)
+}
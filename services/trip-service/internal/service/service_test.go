@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/domain"
+	"github.com/Anurag-Mishra22/taxi/services/trip-service/internal/infrastructure/repository"
+	pbd "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T, cfg Config) (*service, domain.TripRepository) {
+	repo := repository.NewInmemRepository()
+	return NewService(repo, repo, nil, nil, nil, nil, cfg), repo
+}
+
+// startTrip previews a fare for the user and starts a trip from it
+func startTrip(t *testing.T, s *service, repo domain.TripRepository, userID string) *domain.TripModel {
+	fare := saveFare(t, repo, userID, time.Now().Add(5*time.Minute))
+
+	trip, err := s.CreateTrip(context.Background(), fare)
+	require.NoError(t, err)
+	return trip
+}
+
+func TestCancelTrip(t *testing.T) {
+	ctx := context.Background()
+	fee := types.NewMoney(500, "USD")
+
+	tests := []struct {
+		name        string
+		gracePeriod time.Duration
+		assign      bool
+		expectedFee int64
+	}{
+		{"pending_trips_are_free_to_cancel", 0, false, 0},
+		{"free_inside_the_grace_period", time.Hour, true, 0},
+		{"charged_after_the_grace_period", 0, true, 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestService(t, Config{
+				CancellationPolicy: domain.CancellationPolicy{GracePeriod: tt.gracePeriod, Fee: fee},
+			})
+			trip := startTrip(t, s, repo, "rider")
+			tripID := trip.ID.Hex()
+
+			if tt.assign {
+				driver := &pbd.Driver{Id: "driver"}
+				require.NoError(t, s.UpdateTrip(ctx, tripID, domain.TripStatusDriverAssigned, driver, domain.DriverActor("driver"), "accepted"))
+			}
+
+			cancelled, err := s.CancelTrip(ctx, tripID, "rider", "changed my mind")
+			require.NoError(t, err)
+			assert.Equal(t, domain.TripStatusCancelled, cancelled.Status)
+			assert.Equal(t, tt.expectedFee, cancelled.Cancellation.Fee.AmountMinor)
+			assert.Equal(t, tt.assign, cancelled.HasDriver())
+
+			stored, err := repo.GetTripByID(ctx, tripID)
+			require.NoError(t, err)
+			assert.Equal(t, domain.TripStatusCancelled, stored.Status)
+			assert.Equal(t, "changed my mind", stored.Cancellation.Reason)
+
+			timeline, err := repo.GetTripTimeline(ctx, tripID)
+			require.NoError(t, err)
+			last := timeline[len(timeline)-1]
+			assert.Equal(t, domain.TripStatusCancelled, last.To)
+			assert.Equal(t, domain.RiderActor("rider"), last.Actor)
+		})
+	}
+}
+
+func TestCancelTripRejections(t *testing.T) {
+	ctx := context.Background()
+	s, repo := newTestService(t, Config{})
+	trip := startTrip(t, s, repo, "rider")
+	tripID := trip.ID.Hex()
+
+	_, err := s.CancelTrip(ctx, tripID, "someone-else", "")
+	assert.True(t, errors.Is(err, domain.ErrTripNotOwned))
+
+	_, err = s.CancelTrip(ctx, "000000000000000000000000", "rider", "")
+	assert.True(t, errors.Is(err, domain.ErrTripNotFound))
+
+	_, err = s.CancelTrip(ctx, tripID, "rider", "")
+	require.NoError(t, err)
+
+	// A trip is cancelled once
+	_, err = s.CancelTrip(ctx, tripID, "rider", "")
+	assert.True(t, errors.Is(err, domain.ErrInvalidTransition))
+}
+
+func TestListTripsPagination(t *testing.T) {
+	ctx := context.Background()
+	s, repo := newTestService(t, Config{})
+
+	var started []string
+	for i := 0; i < 5; i++ {
+		started = append(started, startTrip(t, s, repo, "rider").ID.Hex())
+	}
+	startTrip(t, s, repo, "other-rider")
+
+	var listed []string
+	pageToken := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3, "too many pages")
+
+		trips, next, err := s.ListTrips(ctx, domain.TripFilter{UserID: "rider", AfterID: pageToken, Limit: 2})
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(trips), 2)
+		for _, trip := range trips {
+			listed = append(listed, trip.ID.Hex())
+		}
+
+		if next == "" {
+			break
+		}
+		pageToken = next
+	}
+
+	// Newest first, every trip of the rider once
+	expected := make([]string, len(started))
+	for i, id := range started {
+		expected[len(started)-1-i] = id
+	}
+	assert.Equal(t, expected, listed)
+}
+
+func TestListTripsPageSize(t *testing.T) {
+	ctx := context.Background()
+	s, repo := newTestService(t, Config{})
+
+	for i := 0; i < defaultTripsPageSize+1; i++ {
+		startTrip(t, s, repo, "rider")
+	}
+
+	trips, next, err := s.ListTrips(ctx, domain.TripFilter{UserID: "rider"})
+	require.NoError(t, err)
+	assert.Len(t, trips, defaultTripsPageSize)
+	assert.Equal(t, trips[len(trips)-1].ID.Hex(), next)
+
+	trips, next, err = s.ListTrips(ctx, domain.TripFilter{UserID: "rider", Limit: maxTripsPageSize + 1})
+	require.NoError(t, err)
+	assert.Len(t, trips, defaultTripsPageSize+1)
+	assert.Empty(t, next)
+}
+
+func TestListTripsRequiresARiderOrADriver(t *testing.T) {
+	s, _ := newTestService(t, Config{})
+
+	_, _, err := s.ListTrips(context.Background(), domain.TripFilter{Status: domain.TripStatusPending})
+	assert.True(t, errors.Is(err, domain.ErrUnscopedTripList))
+}
+
+func TestTripIsShownToTheRiderAndTheDriver(t *testing.T) {
+	ctx := context.Background()
+	s, repo := newTestService(t, Config{})
+	tripID := startTrip(t, s, repo, "rider").ID.Hex()
+	require.NoError(t, s.UpdateTrip(ctx, tripID, domain.TripStatusDriverAssigned, &pbd.Driver{Id: "driver"}, domain.DriverActor("driver"), "accepted"))
+
+	tests := []struct {
+		name     string
+		userID   string
+		driverID string
+		allowed  bool
+	}{
+		{"the_rider", "rider", "", true},
+		{"the_driver", "", "driver", true},
+		{"another_rider", "someone-else", "", false},
+		{"another_driver", "", "other-driver", false},
+		{"nobody", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trip, err := s.GetTrip(ctx, tripID, tt.userID, tt.driverID)
+			timeline, timelineErr := s.GetTripTimeline(ctx, tripID, tt.userID, tt.driverID)
+			if !tt.allowed {
+				assert.True(t, errors.Is(err, domain.ErrTripNotOwned))
+				assert.True(t, errors.Is(timelineErr, domain.ErrTripNotOwned))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tripID, trip.ID.Hex())
+			require.NoError(t, timelineErr)
+			assert.Len(t, timeline, 2)
+		})
+	}
+
+	_, err := s.GetTrip(ctx, "000000000000000000000000", "rider", "")
+	assert.True(t, errors.Is(err, domain.ErrTripNotFound))
+	_, err = s.GetTripTimeline(ctx, "000000000000000000000000", "rider", "")
+	assert.True(t, errors.Is(err, domain.ErrTripNotFound))
+}
+
+func TestCompleteTrip(t *testing.T) {
+	ctx := context.Background()
+	s, repo := newTestService(t, Config{})
+
+	tripID := startTrip(t, s, repo, "rider").ID.Hex()
+	_, err := s.CompleteTrip(ctx, tripID, "driver")
+	assert.True(t, errors.Is(err, domain.ErrTripNotOwned), "a trip without a driver can't be completed")
+
+	require.NoError(t, s.UpdateTrip(ctx, tripID, domain.TripStatusDriverAssigned, &pbd.Driver{Id: "driver"}, domain.DriverActor("driver"), "accepted"))
+	// The rider pays as soon as the driver is assigned, the ride goes on
+	require.NoError(t, s.UpdateTrip(ctx, tripID, domain.TripStatusPaid, nil, domain.SystemActor("payment-service"), "paid"))
+
+	_, err = s.CompleteTrip(ctx, tripID, "other-driver")
+	assert.True(t, errors.Is(err, domain.ErrTripNotOwned))
+
+	trip, err := s.CompleteTrip(ctx, tripID, "driver")
+	require.NoError(t, err)
+	assert.Equal(t, domain.TripStatusCompleted, trip.Status)
+
+	stored, err := repo.GetTripByID(ctx, tripID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.TripStatusCompleted, stored.Status)
+
+	// A duplicated command, or a late payment, doesn't move the trip back
+	_, err = s.CompleteTrip(ctx, tripID, "driver")
+	assert.True(t, errors.Is(err, domain.ErrInvalidTransition))
+	err = s.UpdateTrip(ctx, tripID, domain.TripStatusPaid, nil, domain.SystemActor("payment-service"), "paid")
+	assert.True(t, errors.Is(err, domain.ErrInvalidTransition))
+}
+
+func TestRecordTripPayment(t *testing.T) {
+	tests := []struct {
+		name     string
+		complete bool
+		expected domain.TripStatus
+	}{
+		{
+			name:     "a_driven_trip_is_paid",
+			expected: domain.TripStatusPaid,
+		},
+		{
+			name:     "a_completed_trip_stays_completed",
+			complete: true,
+			expected: domain.TripStatusCompleted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, repo := newTestService(t, Config{})
+
+			tripID := startTrip(t, s, repo, "rider").ID.Hex()
+			require.NoError(t, s.UpdateTrip(ctx, tripID, domain.TripStatusDriverAssigned, &pbd.Driver{Id: "driver"}, domain.DriverActor("driver"), "accepted"))
+			if tt.complete {
+				_, err := s.CompleteTrip(ctx, tripID, "driver")
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, s.RecordTripPayment(ctx, tripID))
+			paid, err := repo.GetTripByID(ctx, tripID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, paid.Status)
+			require.NotNil(t, paid.PaidAt)
+
+			// A redelivered payment keeps the first one
+			require.NoError(t, s.RecordTripPayment(ctx, tripID))
+			stored, err := repo.GetTripByID(ctx, tripID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stored.Status)
+			assert.Equal(t, *paid.PaidAt, *stored.PaidAt)
+		})
+	}
+}
+
+func saveFare(t *testing.T, repo domain.TripRepository, userID string, expiresAt time.Time) *domain.RideFareModel {
+	fare := &domain.RideFareModel{
+		UserID:      userID,
+		PackageSlug: "sedan",
+		TotalPrice:  types.NewMoney(1250, "USD"),
+		CreatedAt:   time.Now(),
+		ExpiresAt:   expiresAt,
+	}
+	require.NoError(t, repo.SaveRideFare(context.Background(), fare))
+	return fare
+}
+
+func TestGetAndValidateFare(t *testing.T) {
+	ctx := context.Background()
+	s, repo := newTestService(t, Config{})
+
+	fare := saveFare(t, repo, "rider", time.Now().Add(time.Minute))
+	validated, err := s.GetAndValidateFare(ctx, fare.ID.Hex(), "rider")
+	require.NoError(t, err)
+	assert.Equal(t, fare.ID, validated.ID)
+
+	_, err = s.GetAndValidateFare(ctx, fare.ID.Hex(), "someone-else")
+	assert.True(t, errors.Is(err, domain.ErrFareNotOwned))
+
+	expired := saveFare(t, repo, "rider", time.Now().Add(-time.Second))
+	_, err = s.GetAndValidateFare(ctx, expired.ID.Hex(), "rider")
+	assert.True(t, errors.Is(err, domain.ErrFareExpired))
+
+	// A fare starts one trip
+	_, err = s.CreateTrip(ctx, validated)
+	require.NoError(t, err)
+	_, err = s.GetAndValidateFare(ctx, fare.ID.Hex(), "rider")
+	assert.True(t, errors.Is(err, domain.ErrFareConsumed))
+	_, err = s.CreateTrip(ctx, validated)
+	assert.True(t, errors.Is(err, domain.ErrFareConsumed))
+}
+
+// failingTripRepository fails to insert the trips
+type failingTripRepository struct {
+	domain.TripRepository
+}
+
+func (r *failingTripRepository) CreateTrip(ctx context.Context, trip *domain.TripModel) (*domain.TripModel, error) {
+	return nil, errors.New("insert failed")
+}
+
+func TestCreateTripReleasesTheFareOfAFailedInsert(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInmemRepository()
+	s := NewService(&failingTripRepository{TripRepository: repo}, repo, nil, nil, nil, nil, Config{})
+
+	fare := saveFare(t, repo, "rider", time.Now().Add(time.Minute))
+	_, err := s.CreateTrip(ctx, fare)
+	require.Error(t, err)
+
+	// The rider can try again with the same quote
+	stored, err := repo.GetRideFareByID(ctx, fare.ID.Hex())
+	require.NoError(t, err)
+	assert.Nil(t, stored.ConsumedAt)
+
+	retry := NewService(repo, repo, nil, nil, nil, nil, Config{})
+	_, err = retry.CreateTrip(ctx, fare)
+	assert.NoError(t, err)
+}
+
+func TestCreateTripKeepsTheRequestedPickup(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(t, Config{FareTTL: time.Minute})
+
+	pickup := &types.Coordinate{Latitude: 38.72, Longitude: -9.14}
+	estimated := &domain.RideFareModel{PackageSlug: "sedan", TotalPrice: types.NewMoney(1250, "USD"), Pickup: pickup}
+	fares, err := s.GenerateTripFares(ctx, []*domain.RideFareModel{estimated}, "rider", nil)
+	require.NoError(t, err)
+	assert.Equal(t, pickup, fares[0].Pickup)
+
+	trip, err := s.CreateTrip(ctx, fares[0])
+	require.NoError(t, err)
+	assert.Equal(t, pickup, trip.Pickup)
+}
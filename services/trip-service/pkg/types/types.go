@@ -3,13 +3,22 @@ package types
 import pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
 
 type OsrmApiResponse struct {
-	Routes []struct {
-		Distance float64 `json:"distance"`
-		Duration float64 `json:"duration"`
-		Geometry struct {
-			Coordinates [][]float64 `json:"coordinates"`
-		} `json:"geometry"`
-	} `json:"routes"`
+	Routes []OsrmRoute `json:"routes"`
+}
+
+type OsrmRoute struct {
+	Distance float64 `json:"distance"`
+	Duration float64 `json:"duration"`
+	Geometry struct {
+		Coordinates [][]float64 `json:"coordinates"`
+	} `json:"geometry"`
+	// Legs are the parts of the route between two consecutive waypoints
+	Legs []OsrmLeg `json:"legs"`
+}
+
+type OsrmLeg struct {
+	Distance float64 `json:"distance"`
+	Duration float64 `json:"duration"`
 }
 
 func (o *OsrmApiResponse) ToProto() *pb.Route {
@@ -23,6 +32,14 @@ func (o *OsrmApiResponse) ToProto() *pb.Route {
 		}
 	}
 
+	legs := make([]*pb.RouteLeg, len(route.Legs))
+	for i, leg := range route.Legs {
+		legs[i] = &pb.RouteLeg{
+			Distance: leg.Distance,
+			Duration: leg.Duration,
+		}
+	}
+
 	return &pb.Route{
 		Geometry: []*pb.Geometry{
 			{
@@ -31,17 +48,6 @@ func (o *OsrmApiResponse) ToProto() *pb.Route {
 		},
 		Distance: route.Distance,
 		Duration: route.Duration,
-	}
-}
-
-type PricingConfig struct {
-	PricePerUnitOfDistance float64
-	PricingPerMinute       float64
-}
-
-func DefaultPricingConfig() *PricingConfig {
-	return &PricingConfig{
-		PricePerUnitOfDistance: 1.5,
-		PricingPerMinute:       0.25,
+		Legs:     legs,
 	}
 }
@@ -70,6 +70,11 @@ func (r *RedisClient) Set(ctx context.Context, key string, value interface{}, tt
 	return r.client.Set(ctx, key, value, ttl).Err()
 }
 
+// SetNX stores a key-value pair only if the key does not exist, it reports whether it was stored
+func (r *RedisClient) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, ttl).Result()
+}
+
 // Get retrieves a value by key
 func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
 	return r.client.Get(ctx, key).Result()
@@ -120,6 +125,11 @@ func (r *RedisClient) HGetAll(ctx context.Context, key string) (map[string]strin
 	return r.client.HGetAll(ctx, key).Result()
 }
 
+// HMGet gets several fields of a hash, missing fields are nil
+func (r *RedisClient) HMGet(ctx context.Context, key string, fields ...string) ([]interface{}, error) {
+	return r.client.HMGet(ctx, key, fields...).Result()
+}
+
 // HDel deletes a field from a hash
 func (r *RedisClient) HDel(ctx context.Context, key string, fields ...string) error {
 	return r.client.HDel(ctx, key, fields...).Err()
@@ -146,6 +156,15 @@ func (r *RedisClient) SetJSON(ctx context.Context, key string, value interface{}
 	return r.Set(ctx, key, jsonData, ttl)
 }
 
+// SetNXJSON stores a JSON-serialized object only if the key does not exist
+func (r *RedisClient) SetNXJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return r.SetNX(ctx, key, jsonData, ttl)
+}
+
 // GetJSON retrieves and deserializes a JSON object
 func (r *RedisClient) GetJSON(ctx context.Context, key string, dest interface{}) error {
 	data, err := r.Get(ctx, key)
@@ -212,6 +231,36 @@ func (r *RedisClient) SIsMember(ctx context.Context, key string, member interfac
 	return r.client.SIsMember(ctx, key, member).Result()
 }
 
+// --- Geo Operations (for locating drivers) ---
+
+// GeoAdd adds or moves members of a geospatial index
+func (r *RedisClient) GeoAdd(ctx context.Context, key string, locations ...*redis.GeoLocation) error {
+	return r.client.GeoAdd(ctx, key, locations...).Err()
+}
+
+// GeoSearchLocation finds the members of a geospatial index around a point
+func (r *RedisClient) GeoSearchLocation(ctx context.Context, key string, query *redis.GeoSearchLocationQuery) ([]redis.GeoLocation, error) {
+	return r.client.GeoSearchLocation(ctx, key, query).Result()
+}
+
+// --- Sorted Set Operations (for scheduling by time) ---
+
+// ZAdd adds members to a sorted set, or updates their score
+func (r *RedisClient) ZAdd(ctx context.Context, key string, members ...redis.Z) error {
+	return r.client.ZAdd(ctx, key, members...).Err()
+}
+
+// ZRangeByScore gets the members of a sorted set within a score range, lowest first
+func (r *RedisClient) ZRangeByScore(ctx context.Context, key string, opt *redis.ZRangeBy) ([]string, error) {
+	return r.client.ZRangeByScore(ctx, key, opt).Result()
+}
+
+// ZRem removes members from a sorted set, like a geospatial index,
+// and returns the number of members removed
+func (r *RedisClient) ZRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return r.client.ZRem(ctx, key, members...).Result()
+}
+
 // --- Pub/Sub Operations ---
 
 // Publish publishes a message to a channel
@@ -4,8 +4,15 @@ package contracts
 type AmqpMessage struct {
 	OwnerID string `json:"ownerId"`
 	Data    []byte `json:"data"`
+	// MessageID is sent as the AMQP message ID, so consumers can drop the duplicates.
+	// Publishers that retry a message set it, a new ID is generated otherwise.
+	MessageID string `json:"-"`
 }
 
+// TripStatusPending is the status of a trip the events carry while it waits for a driver.
+// The trip-service owns the lifecycle of the trips and the other statuses.
+const TripStatusPending = "pending"
+
 // Routing keys - using consistent event/command patterns
 const (
 	// Trip events (trip.event.*)
@@ -13,13 +20,23 @@ const (
 	TripEventDriverAssigned      = "trip.event.driver_assigned"
 	TripEventNoDriversFound      = "trip.event.no_drivers_found"
 	TripEventDriverNotInterested = "trip.event.driver_not_interested"
+	TripEventCancelled           = "trip.event.cancelled"
+	TripEventStopReached         = "trip.event.stop_reached"
+	TripEventOfferRevoked        = "trip.event.offer_revoked"
+	TripEventCompleted           = "trip.event.completed"
+	// TripEventOfferAccepted is an accept of a pending offer, checked by the driver-service
+	TripEventOfferAccepted = "trip.event.offer_accepted"
 
 	// Driver commands (driver.cmd.*)
-	DriverCmdTripRequest = "driver.cmd.trip_request"
-	DriverCmdTripAccept  = "driver.cmd.trip_accept"
-	DriverCmdTripDecline = "driver.cmd.trip_decline"
-	DriverCmdLocation    = "driver.cmd.location"
-	DriverCmdRegister    = "driver.cmd.register"
+	DriverCmdTripRequest  = "driver.cmd.trip_request"
+	DriverCmdTripAccept   = "driver.cmd.trip_accept"
+	DriverCmdTripDecline  = "driver.cmd.trip_decline"
+	DriverCmdLocation     = "driver.cmd.location"
+	DriverCmdRegister     = "driver.cmd.register"
+	DriverCmdStopReached  = "driver.cmd.stop_reached"
+	DriverCmdTripComplete = "driver.cmd.trip_complete"
+	DriverCmdPause        = "driver.cmd.pause"
+	DriverCmdResume       = "driver.cmd.resume"
 
 	// Payment events (payment.event.*)
 	PaymentEventSessionCreated = "payment.event.session_created"
@@ -28,5 +45,6 @@ const (
 	PaymentEventCancelled      = "payment.event.cancelled"
 
 	// Payment commands (payment.cmd.*)
-	PaymentCmdCreateSession = "payment.cmd.create_session"
+	PaymentCmdCreateSession         = "payment.cmd.create_session"
+	PaymentCmdChargeCancellationFee = "payment.cmd.charge_cancellation_fee"
 )
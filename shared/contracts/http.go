@@ -11,3 +11,6 @@ type APIError struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 }
+
+// ErrCodeOutsideServiceArea is the APIError code of a pickup no driver operates in
+const ErrCodeOutsideServiceArea = "outside_service_area"
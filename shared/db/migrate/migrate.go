@@ -0,0 +1,324 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/db"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrIrreversible is returned when reverting a migration without a Down step
+	ErrIrreversible = errors.New("migration can't be reverted")
+	// ErrLocked is returned when another replica kept the migrations lock for too long
+	ErrLocked = errors.New("migrations are locked by another process")
+	// ErrLockLost is returned when the lock expired while the migrations were running
+	ErrLockLost = errors.New("migrations lock was lost")
+)
+
+// lockID is the single document of the lock collection
+const lockID = "migrations"
+
+// Migration is one versioned change of the database schema.
+// Migrations are applied in the order of their versions and never edited once released.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	// Down reverts Up, nil when the migration can't be reverted (like a backfill)
+	Down func(ctx context.Context, db *mongo.Database) error
+}
+
+// Record is the document of an applied migration in the schema_migrations collection
+type Record struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+// Status reports whether a migration was applied, AppliedAt is nil for a pending migration
+type Status struct {
+	Version     int
+	Description string
+	AppliedAt   *time.Time
+}
+
+// Migrator applies and reverts the migrations of a database. A lock in the database
+// makes sure only one process runs the migrations at a time, the others wait for it.
+type Migrator struct {
+	db         *mongo.Database
+	migrations []Migration
+	owner      string
+	// lockTTL is how long the lock is held without being refreshed, in case the process stops
+	lockTTL time.Duration
+	// lockWait is how long to wait for another process to release the lock
+	lockWait time.Duration
+}
+
+// NewMigrator checks that the migrations are ordered by strictly increasing versions
+func NewMigrator(database *mongo.Database, migrations []Migration) (*Migrator, error) {
+	previous := 0
+	for _, m := range migrations {
+		if m.Version <= previous {
+			return nil, fmt.Errorf("migration %d must come after migration %d", m.Version, previous)
+		}
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d has no Up step", m.Version)
+		}
+		previous = m.Version
+	}
+
+	hostname, _ := os.Hostname()
+
+	return &Migrator{
+		db:         database,
+		migrations: migrations,
+		owner:      fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		lockTTL:    5 * time.Minute,
+		lockWait:   2 * time.Minute,
+	}, nil
+}
+
+// Latest is the version of the last migration, 0 without migrations
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies the pending migrations up to target included
+func (m *Migrator) Up(ctx context.Context, target int) error {
+	return m.withLock(ctx, func(ctx context.Context) error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version > target {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := migration.Up(ctx, m.db); err != nil {
+				return fmt.Errorf("failed to apply migration %d (%s): %w", migration.Version, migration.Description, err)
+			}
+
+			record := Record{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now()}
+			if _, err := m.db.Collection(db.SchemaMigrationsCollection).InsertOne(ctx, record); err != nil {
+				return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+			}
+			log.Printf("Applied migration %d: %s", migration.Version, migration.Description)
+		}
+
+		return nil
+	})
+}
+
+// Down reverts the applied migrations above target, newest first. Nothing is
+// reverted when one of them can't be.
+func (m *Migrator) Down(ctx context.Context, target int) error {
+	return m.withLock(ctx, func(ctx context.Context) error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		var revert []Migration
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version <= target {
+				break
+			}
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == nil {
+				return fmt.Errorf("%w: %d (%s)", ErrIrreversible, migration.Version, migration.Description)
+			}
+			revert = append(revert, migration)
+		}
+
+		for _, migration := range revert {
+			if err := migration.Down(ctx, m.db); err != nil {
+				return fmt.Errorf("failed to revert migration %d (%s): %w", migration.Version, migration.Description, err)
+			}
+
+			if _, err := m.db.Collection(db.SchemaMigrationsCollection).DeleteOne(ctx, bson.M{"_id": migration.Version}); err != nil {
+				return fmt.Errorf("failed to unrecord migration %d: %w", migration.Version, err)
+			}
+			log.Printf("Reverted migration %d: %s", migration.Version, migration.Description)
+		}
+
+		return nil
+	})
+}
+
+// Status lists every migration with the time it was applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = Status{Version: migration.Version, Description: migration.Description}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+
+	return statuses, nil
+}
+
+// Pending returns the number of migrations not applied yet
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]Record, error) {
+	cursor, err := m.db.Collection(db.SchemaMigrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the applied migrations: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []Record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to read the applied migrations: %w", err)
+	}
+
+	applied := make(map[int]Record, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// withLock runs fn while holding the migrations lock. The lock is refreshed in the background
+// for as long as fn runs, and the ctx of fn is cancelled with ErrLockLost if it expires anyway.
+func (m *Migrator) withLock(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.unlock(context.WithoutCancel(ctx))
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	var heartbeat sync.WaitGroup
+	heartbeat.Add(1)
+	go func() {
+		defer heartbeat.Done()
+		m.heartbeat(ctx, cancel)
+	}()
+
+	err := fn(ctx)
+	lost := context.Cause(ctx)
+	cancel(nil)
+	heartbeat.Wait()
+
+	if errors.Is(lost, ErrLockLost) {
+		return ErrLockLost
+	}
+	return err
+}
+
+// heartbeat refreshes the lock until ctx is done
+func (m *Migrator) heartbeat(ctx context.Context, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(m.lockTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := m.refreshLock(ctx)
+		if errors.Is(err, ErrLockLost) {
+			cancel(err)
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			// The next beat tries again, the lock outlives a few missed ones
+			log.Printf("%v", err)
+		}
+	}
+}
+
+func (m *Migrator) lock(ctx context.Context) error {
+	locks := m.db.Collection(db.SchemaMigrationsLockCollection)
+	deadline := time.Now().Add(m.lockWait)
+
+	for {
+		now := time.Now()
+
+		// Only an expired lock matches, the upsert of a held lock fails on the duplicate _id
+		filter := bson.M{"_id": lockID, "lockedUntil": bson.M{"$lte": now}}
+		update := bson.M{"$set": bson.M{"owner": m.owner, "lockedUntil": now.Add(m.lockTTL)}}
+
+		_, err := locks.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		if err == nil {
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("failed to lock the migrations: %w", err)
+		}
+
+		if now.After(deadline) {
+			return ErrLocked
+		}
+
+		log.Printf("Waiting for the migrations lock")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (m *Migrator) refreshLock(ctx context.Context) error {
+	update := bson.M{"$set": bson.M{"lockedUntil": time.Now().Add(m.lockTTL)}}
+
+	result, err := m.db.Collection(db.SchemaMigrationsLockCollection).UpdateOne(ctx, bson.M{"_id": lockID, "owner": m.owner}, update)
+	if err != nil {
+		return fmt.Errorf("failed to refresh the migrations lock: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+func (m *Migrator) unlock(ctx context.Context) {
+	_, err := m.db.Collection(db.SchemaMigrationsLockCollection).DeleteOne(ctx, bson.M{"_id": lockID, "owner": m.owner})
+	if err != nil {
+		log.Printf("Failed to release the migrations lock: %v", err)
+	}
+}
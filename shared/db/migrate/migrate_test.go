@@ -0,0 +1,177 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func noop(ctx context.Context, db *mongo.Database) error { return nil }
+
+func TestNewMigratorChecksTheOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []int
+		valid    bool
+	}{
+		{"ordered", []int{1, 2, 5}, true},
+		{"empty", nil, true},
+		{"duplicated", []int{1, 2, 2}, false},
+		{"unordered", []int{1, 3, 2}, false},
+		{"zero", []int{0, 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var migrations []Migration
+			for _, v := range tt.versions {
+				migrations = append(migrations, Migration{Version: v, Up: noop})
+			}
+
+			_, err := NewMigrator(nil, migrations)
+			assert.Equal(t, tt.valid, err == nil, "got %v", err)
+		})
+	}
+}
+
+// testMigrations create an index, backfill a field and validate the documents of the items collection
+func testMigrations() []Migration {
+	return []Migration{
+		Indexes(1, "index the item names", "items",
+			mongo.IndexModel{Keys: bson.D{{Key: "name", Value: 1}}},
+		),
+		Backfill(2, "set the item sizes", "items",
+			bson.M{"size": bson.M{"$exists": false}},
+			mongo.Pipeline{{{Key: "$set", Value: bson.M{"size": "medium"}}}},
+		),
+		Validator(3, "validate the items", "items", bson.M{
+			"bsonType": "object",
+			"required": bson.A{"name", "size"},
+		}),
+	}
+}
+
+// newTestDatabase needs MongoDB in TRIP_TEST_MONGODB_URI, every test runs in its own database
+func newTestDatabase(t *testing.T) *mongo.Database {
+	uri := os.Getenv("TRIP_TEST_MONGODB_URI")
+	if uri == "" {
+		t.Skip("TRIP_TEST_MONGODB_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := db.NewMongoClient(ctx, &db.MongoConfig{URI: uri, Database: "migrate-test"})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Disconnect(ctx) })
+
+	database := client.Database("migrate-test-" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() { database.Drop(ctx) })
+
+	return database
+}
+
+func TestMigrator(t *testing.T) {
+	database := newTestDatabase(t)
+	ctx := context.Background()
+
+	_, err := database.Collection("items").InsertOne(ctx, bson.M{"name": "legacy"})
+	require.NoError(t, err)
+
+	migrator, err := NewMigrator(database, testMigrations())
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Up(ctx, migrator.Latest()))
+	pending, err := migrator.Pending(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, pending)
+
+	// Applying again is a no-op
+	require.NoError(t, migrator.Up(ctx, migrator.Latest()))
+
+	var item struct {
+		Size string `bson:"size"`
+	}
+	require.NoError(t, database.Collection("items").FindOne(ctx, bson.M{"name": "legacy"}).Decode(&item))
+	assert.Equal(t, "medium", item.Size)
+
+	_, err = database.Collection("items").InsertOne(ctx, bson.M{"name": "without-size"})
+	assert.Error(t, err)
+
+	// The backfill can't be reverted, so nothing is
+	err = migrator.Down(ctx, 0)
+	assert.True(t, errors.Is(err, ErrIrreversible), "got %v", err)
+
+	require.NoError(t, migrator.Down(ctx, 2))
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, statuses[1].AppliedAt)
+	assert.Nil(t, statuses[2].AppliedAt)
+
+	// Another process holding the lock blocks the migrations
+	_, err = database.Collection(db.SchemaMigrationsLockCollection).InsertOne(ctx, bson.M{
+		"_id":         lockID,
+		"owner":       "another-replica",
+		"lockedUntil": time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	migrator.lockWait = 0
+	assert.True(t, errors.Is(migrator.Up(ctx, migrator.Latest()), ErrLocked))
+}
+
+func TestMigratorKeepsTheLockOfALongMigration(t *testing.T) {
+	database := newTestDatabase(t)
+	ctx := context.Background()
+
+	other, err := NewMigrator(database, nil)
+	require.NoError(t, err)
+	other.owner = "another-replica"
+	other.lockWait = 0
+
+	// The migration outlives the lock TTL, and another replica tries to take the lock meanwhile
+	var otherErr error
+	slow := Migration{Version: 1, Up: func(ctx context.Context, db *mongo.Database) error {
+		time.Sleep(1500 * time.Millisecond)
+		otherErr = other.Up(ctx, 0)
+		return nil
+	}}
+
+	migrator, err := NewMigrator(database, []Migration{slow})
+	require.NoError(t, err)
+	migrator.lockTTL = 600 * time.Millisecond
+
+	require.NoError(t, migrator.Up(ctx, migrator.Latest()))
+	assert.True(t, errors.Is(otherErr, ErrLocked), "got %v", otherErr)
+}
+
+func TestMigratorStopsWhenTheLockIsLost(t *testing.T) {
+	database := newTestDatabase(t)
+	ctx := context.Background()
+
+	// The lock is taken over while the migration runs
+	stolen := Migration{Version: 1, Up: func(ctx context.Context, database *mongo.Database) error {
+		_, err := database.Collection(db.SchemaMigrationsLockCollection).UpdateOne(ctx,
+			bson.M{"_id": lockID}, bson.M{"$set": bson.M{"owner": "another-replica"}})
+		if err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	migrator, err := NewMigrator(database, []Migration{stolen})
+	require.NoError(t, err)
+	migrator.lockTTL = 300 * time.Millisecond
+
+	err = migrator.Up(ctx, migrator.Latest())
+	assert.True(t, errors.Is(err, ErrLockLost), "got %v", err)
+}
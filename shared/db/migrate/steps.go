@@ -0,0 +1,91 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoDB error codes the steps tolerate, to stay idempotent
+const (
+	codeIndexNotFound   = 27
+	codeNamespaceExists = 48
+)
+
+// Indexes is a migration creating indexes on a collection, reverted by dropping them.
+// Creating an index that already exists with the same options is a no-op.
+func Indexes(version int, description, collection string, models ...mongo.IndexModel) Migration {
+	return Migration{
+		Version:     version,
+		Description: description,
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection(collection).Indexes().CreateMany(ctx, models)
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			for _, model := range models {
+				_, err := db.Collection(collection).Indexes().DropOneWithKey(ctx, model.Keys)
+				if err != nil && !hasCode(err, codeIndexNotFound) {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// Validator is a migration validating the documents of a collection against a JSON schema,
+// reverted by removing the validator. The validation is moderate: documents that were
+// already invalid can still be updated, so the legacy documents don't block the service.
+func Validator(version int, description, collection string, schema bson.M) Migration {
+	return Migration{
+		Version:     version,
+		Description: description,
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			if err := db.CreateCollection(ctx, collection); err != nil && !hasCode(err, codeNamespaceExists) {
+				return err
+			}
+			return db.RunCommand(ctx, bson.D{
+				{Key: "collMod", Value: collection},
+				{Key: "validator", Value: bson.M{"$jsonSchema": schema}},
+				{Key: "validationLevel", Value: "moderate"},
+				{Key: "validationAction", Value: "error"},
+			}).Err()
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return db.RunCommand(ctx, bson.D{
+				{Key: "collMod", Value: collection},
+				{Key: "validator", Value: bson.M{}},
+				{Key: "validationLevel", Value: "off"},
+			}).Err()
+		},
+	}
+}
+
+// Backfill is a migration updating the documents matching filter with an update
+// pipeline. It can't be reverted, the previous values are gone.
+func Backfill(version int, description, collection string, filter bson.M, pipeline mongo.Pipeline) Migration {
+	return Migration{
+		Version:     version,
+		Description: description,
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			result, err := db.Collection(collection).UpdateMany(ctx, filter, pipeline)
+			if err != nil {
+				return fmt.Errorf("failed to backfill %s: %w", collection, err)
+			}
+			if result.ModifiedCount > 0 {
+				log.Printf("Backfilled %d documents of %s", result.ModifiedCount, collection)
+			}
+			return nil
+		},
+	}
+}
+
+func hasCode(err error, code int32) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == code
+}
@@ -13,8 +13,17 @@ import (
 )
 
 const (
-	TripsCollection     = "trips"
-	RideFaresCollection = "ride_fares"
+	TripsCollection      = "trips"
+	RideFaresCollection  = "ride_fares"
+	PackagesCollection   = "packages"
+	PromotionsCollection = "promotions"
+	// PromotionRedemptionsCollection counts the redemptions of every promotion per user
+	PromotionRedemptionsCollection = "promotion_redemptions"
+	OutboxCollection               = "outbox"
+	TimelineCollection             = "trip_timeline"
+	// SchemaMigrationsCollection records the applied migrations, see the migrate package
+	SchemaMigrationsCollection     = "schema_migrations"
+	SchemaMigrationsLockCollection = "schema_migrations_lock"
 )
 
 // MongoConfig holds MongoDB connection configuration
@@ -40,7 +49,7 @@ func NewMongoClient(ctx context.Context, cfg *MongoConfig) (*mongo.Client, error
 		return nil, fmt.Errorf("mongodb database is required")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
 	defer cancel()
 	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.URI))
 	if err != nil {
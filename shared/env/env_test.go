@@ -121,4 +121,42 @@ func TestGetBool(t *testing.T) {
 			assert.Equal(t, tt.expected, result)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestGetFloat(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		fallback float64
+		envValue string
+		setEnv   bool
+		expected float64
+	}{
+		{"valid_float", "TEST_FLOAT", 1.5, "2.75", true, 2.75},
+		{"int_as_float", "TEST_FLOAT", 1.5, "3", true, 3},
+		{"invalid_float", "INVALID_FLOAT", 1.5, "not_a_number", true, 1.5},
+		{"missing_float", "MISSING_FLOAT", 1.5, "", false, 1.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			originalValue := os.Getenv(tt.key)
+			defer func() {
+				if originalValue != "" {
+					os.Setenv(tt.key, originalValue)
+				} else {
+					os.Unsetenv(tt.key)
+				}
+			}()
+
+			if tt.setEnv {
+				os.Setenv(tt.key, tt.envValue)
+			} else {
+				os.Unsetenv(tt.key)
+			}
+
+			result := GetFloat(tt.key, tt.fallback)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package messaging
 import (
 	pbd "github.com/Anurag-Mishra22/taxi/shared/proto/driver"
 	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+	"github.com/Anurag-Mishra22/taxi/shared/types"
 )
 
 const (
@@ -11,39 +12,89 @@ const (
 	DriverTripResponseQueue          = "driver_trip_response"
 	NotifyDriverNoDriversFoundQueue  = "notify_driver_no_drivers_found"
 	NotifyDriverAssignQueue          = "notify_driver_assign"
+	NotifyDriverTripCancelledQueue   = "notify_driver_trip_cancelled"
 	PaymentTripResponseQueue         = "payment_trip_response"
 	NotifyPaymentSessionCreatedQueue = "notify_payment_session_created"
 	NotifyPaymentSuccessQueue        = "payment_success"
+	TripDemandQueue                  = "trip_demand"
+	NotifyStopReachedQueue           = "notify_stop_reached"
+	NotifyTripCompletedQueue         = "notify_trip_completed"
+	TripTimelineQueue                = "trip_timeline"
+	DriverDispatchQueue              = "driver_dispatch"
+	NotifyDriverOfferRevokedQueue    = "notify_driver_offer_revoked"
 	DeadLetterQueue                  = "dead_letter_queue"
 )
 
+// Payment charge types, carried in the payment session metadata
+const (
+	PaymentChargeTypeRide            = "ride"
+	PaymentChargeTypeCancellationFee = "cancellation_fee"
+)
+
 type TripEventData struct {
 	Trip *pb.Trip `json:"trip"`
 }
 
+// TripDriverNotInterestedData is sent when a driver declined the trip, to offer it to another driver
+type TripDriverNotInterestedData struct {
+	Trip *pb.Trip `json:"trip"`
+	// DriverID is the driver who declined
+	DriverID string `json:"driverID"`
+}
+
+// Reasons of a revoked trip offer
+const (
+	OfferRevokedExpired   = "expired"
+	OfferRevokedTaken     = "taken"
+	OfferRevokedCancelled = "cancelled"
+)
+
+// TripOfferRevokedData tells a driver that their trip offer can no longer be accepted
+type TripOfferRevokedData struct {
+	TripID string `json:"tripID"`
+	Reason string `json:"reason"`
+}
+
+type TripCancelledData struct {
+	Trip            *pb.Trip    `json:"trip"`
+	Reason          string      `json:"reason"`
+	CancellationFee types.Money `json:"cancellationFee"`
+}
+
 type DriverTripResponseData struct {
 	Driver  *pbd.Driver `json:"driver"`
 	TripID  string      `json:"tripID"`
 	RiderID string      `json:"riderID"`
 }
 
+// DriverStopReachedData is sent by the driver when they reach an intermediate stop
+type DriverStopReachedData struct {
+	TripID string `json:"tripID"`
+	// Stop is the index of the reached stop
+	Stop int `json:"stop"`
+}
+
+// DriverTripCompleteData is sent by the driver when they drop the rider off
+type DriverTripCompleteData struct {
+	TripID string `json:"tripID"`
+}
+
 type PaymentEventSessionCreatedData struct {
-	TripID    string  `json:"tripID"`
-	SessionID string  `json:"sessionID"`
-	Amount    float64 `json:"amount"`
-	Currency  string  `json:"currency"`
+	TripID    string      `json:"tripID"`
+	SessionID string      `json:"sessionID"`
+	Amount    types.Money `json:"amount"`
 }
 
 type PaymentTripResponseData struct {
-	TripID   string  `json:"tripID"`
-	UserID   string  `json:"userID"`
-	DriverID string  `json:"driverID"`
-	Amount   float64 `json:"amount"`
-	Currency string  `json:"currency"`
+	TripID   string      `json:"tripID"`
+	UserID   string      `json:"userID"`
+	DriverID string      `json:"driverID"`
+	Amount   types.Money `json:"amount"`
 }
 
 type PaymentStatusUpdateData struct {
-	TripID   string `json:"tripID"`
-	UserID   string `json:"userID"`
-	DriverID string `json:"driverID"`
+	TripID     string `json:"tripID"`
+	UserID     string `json:"userID"`
+	DriverID   string `json:"driverID"`
+	ChargeType string `json:"chargeType"`
 }
@@ -44,6 +44,10 @@ func (qc *QueueConsumer) Start() error {
 			}
 
 			userID := msgBody.OwnerID
+			if userID == "" {
+				// Only meant for the services, no client is notified
+				continue
+			}
 
 			var payload any
 			if msgBody.Data != nil {
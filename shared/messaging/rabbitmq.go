@@ -9,6 +9,7 @@ import (
 	"github.com/Anurag-Mishra22/taxi/shared/retry"
 	"github.com/Anurag-Mishra22/taxi/shared/tracing"
 
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -129,6 +130,7 @@ func (r *RabbitMQ) PublishMessage(ctx context.Context, routingKey string, messag
 	}
 
 	msg := amqp.Publishing{
+		MessageId:    messageID(message),
 		DeliveryMode: amqp.Persistent,
 		ContentType:  "application/json",
 		Body:         jsonMsg,
@@ -137,6 +139,105 @@ func (r *RabbitMQ) PublishMessage(ctx context.Context, routingKey string, messag
 	return tracing.TracedPublisher(ctx, TripExchange, routingKey, msg, r.publish)
 }
 
+// ConfirmedPublisher publishes on its own channel in confirm mode,
+// PublishMessage only succeeds once the broker has taken responsibility for the message
+type ConfirmedPublisher struct {
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	// closed is notified when the broker closes the channel, ex: after a channel error
+	closed chan *amqp.Error
+}
+
+// NewConfirmedPublisher opens a confirm mode channel on the connection, it is not safe for concurrent use.
+// The channel is opened again on the next publish when the broker closes it.
+func (r *RabbitMQ) NewConfirmedPublisher() (*ConfirmedPublisher, error) {
+	p := &ConfirmedPublisher{conn: r.conn}
+	if err := p.open(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *ConfirmedPublisher) open() error {
+	ch, err := p.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to create channel: %v", err)
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to put the channel in confirm mode: %v", err)
+	}
+
+	p.channel = ch
+	p.closed = ch.NotifyClose(make(chan *amqp.Error, 1))
+	return nil
+}
+
+// openChannel returns the channel, reopening it if it was closed since the last publish
+func (p *ConfirmedPublisher) openChannel() (*amqp.Channel, error) {
+	select {
+	case reason := <-p.closed:
+		log.Printf("Confirm channel closed: %v, reopening it", reason)
+		if err := p.open(); err != nil {
+			return nil, err
+		}
+	default:
+	}
+
+	return p.channel, nil
+}
+
+func (p *ConfirmedPublisher) PublishMessage(ctx context.Context, routingKey string, message contracts.AmqpMessage) error {
+	jsonMsg, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	msg := amqp.Publishing{
+		MessageId:    messageID(message),
+		DeliveryMode: amqp.Persistent,
+		ContentType:  "application/json",
+		Body:         jsonMsg,
+	}
+
+	return tracing.TracedPublisher(ctx, TripExchange, routingKey, msg, p.publish)
+}
+
+func (p *ConfirmedPublisher) publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	ch, err := p.openChannel()
+	if err != nil {
+		return err
+	}
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, false, false, msg)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return fmt.Errorf("message %s was rejected by the broker", routingKey)
+	}
+
+	return nil
+}
+
+func (p *ConfirmedPublisher) Close() error {
+	return p.channel.Close()
+}
+
+func messageID(message contracts.AmqpMessage) string {
+	if message.MessageID != "" {
+		return message.MessageID
+	}
+	return uuid.NewString()
+}
+
 func (r *RabbitMQ) publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
 	return r.Channel.PublishWithContext(ctx,
 		exchange,   // exchange
@@ -219,6 +320,25 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 		return err
 	}
 
+	// The dispatch of a trip stops once a driver is assigned or the trip is cancelled,
+	// the driver is available again once they complete the trip or it is cancelled.
+	// An accept only reaches the trip-service once it claimed a pending offer.
+	if err := r.declareAndBindQueue(
+		DriverDispatchQueue,
+		[]string{contracts.TripEventDriverAssigned, contracts.TripEventCancelled, contracts.TripEventCompleted, contracts.DriverCmdTripAccept},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	if err := r.declareAndBindQueue(
+		NotifyDriverOfferRevokedQueue,
+		[]string{contracts.TripEventOfferRevoked},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
 	if err := r.declareAndBindQueue(
 		DriverCmdTripRequestQueue,
 		[]string{contracts.DriverCmdTripRequest},
@@ -229,7 +349,7 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 
 	if err := r.declareAndBindQueue(
 		DriverTripResponseQueue,
-		[]string{contracts.DriverCmdTripAccept, contracts.DriverCmdTripDecline},
+		[]string{contracts.TripEventOfferAccepted, contracts.DriverCmdTripDecline, contracts.DriverCmdStopReached, contracts.DriverCmdTripComplete, contracts.TripEventNoDriversFound},
 		TripExchange,
 	); err != nil {
 		return err
@@ -251,9 +371,17 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 		return err
 	}
 
+	if err := r.declareAndBindQueue(
+		NotifyDriverTripCancelledQueue,
+		[]string{contracts.TripEventCancelled},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
 	if err := r.declareAndBindQueue(
 		PaymentTripResponseQueue,
-		[]string{contracts.PaymentCmdCreateSession},
+		[]string{contracts.PaymentCmdCreateSession, contracts.PaymentCmdChargeCancellationFee},
 		TripExchange,
 	); err != nil {
 		return err
@@ -275,6 +403,40 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 		return err
 	}
 
+	if err := r.declareAndBindQueue(
+		NotifyStopReachedQueue,
+		[]string{contracts.TripEventStopReached},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	if err := r.declareAndBindQueue(
+		NotifyTripCompletedQueue,
+		[]string{contracts.TripEventCompleted},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	// Feeds the surge pricing with requested and unfulfilled trips
+	if err := r.declareAndBindQueue(
+		TripDemandQueue,
+		[]string{contracts.TripEventCreated, contracts.TripEventNoDriversFound},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	// Records the driver offers and payment sessions in the trip timelines
+	if err := r.declareAndBindQueue(
+		TripTimelineQueue,
+		[]string{contracts.DriverCmdTripRequest, contracts.PaymentEventSessionCreated},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -67,6 +67,10 @@ type Metrics struct {
 	ExternalAPICallsTotal    *prometheus.CounterVec
 	ExternalAPICallDuration  *prometheus.HistogramVec
 	ExternalAPICircuitBreaker *prometheus.GaugeVec
+
+	// Cache Metrics
+	CacheHitsTotal   *prometheus.CounterVec
+	CacheMissesTotal *prometheus.CounterVec
 }
 
 var (
@@ -466,6 +470,28 @@ func InitMetrics(serviceName string) *Metrics {
 			},
 			[]string{"api_name"},
 		),
+
+		// Cache Metrics
+		CacheHitsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   "ride_sharing",
+				Subsystem:   "cache",
+				Name:        "hits_total",
+				Help:        "Total number of cache hits",
+				ConstLabels: labels,
+			},
+			[]string{"cache"},
+		),
+		CacheMissesTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   "ride_sharing",
+				Subsystem:   "cache",
+				Name:        "misses_total",
+				Help:        "Total number of cache misses",
+				ConstLabels: labels,
+			},
+			[]string{"cache"},
+		),
 	}
 
 	// Set global metrics instance
@@ -519,6 +545,20 @@ func (m *Metrics) RecordTripCreated(packageType, status string) {
 	m.TripsCreatedTotal.WithLabelValues(packageType, status).Inc()
 }
 
+// RecordCircuitBreakerState records the state of a circuit breaker (0=closed, 1=open, 2=half-open)
+func (m *Metrics) RecordCircuitBreakerState(name string, state int) {
+	m.ExternalAPICircuitBreaker.WithLabelValues(name).Set(float64(state))
+}
+
+// RecordCacheLookup records a cache hit or miss
+func (m *Metrics) RecordCacheLookup(cache string, hit bool) {
+	if hit {
+		m.CacheHitsTotal.WithLabelValues(cache).Inc()
+	} else {
+		m.CacheMissesTotal.WithLabelValues(cache).Inc()
+	}
+}
+
 // RecordPayment records a payment transaction
 func (m *Metrics) RecordPayment(status, paymentMethod, currency string, amount float64) {
 	m.PaymentsProcessedTotal.WithLabelValues(status, paymentMethod).Inc()
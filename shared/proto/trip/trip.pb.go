@@ -9,6 +9,7 @@ package trip
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -26,6 +27,12 @@ type PreviewTripRequest struct {
 	UserID        string                 `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
 	StartLocation *Coordinate            `protobuf:"bytes,2,opt,name=startLocation,proto3" json:"startLocation,omitempty"`
 	EndLocation   *Coordinate            `protobuf:"bytes,3,opt,name=endLocation,proto3" json:"endLocation,omitempty"`
+	// Optional promotion code applied to the fares
+	PromoCode string `protobuf:"bytes,4,opt,name=promoCode,proto3" json:"promoCode,omitempty"`
+	// Optional pickup time to book the trip in advance, the fares are priced for that time
+	ScheduledFor *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=scheduledFor,proto3" json:"scheduledFor,omitempty"`
+	// Optional stops between the start and the end location, in the order they are visited
+	Stops         []*Coordinate `protobuf:"bytes,6,rep,name=stops,proto3" json:"stops,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -81,6 +88,27 @@ func (x *PreviewTripRequest) GetEndLocation() *Coordinate {
 	return nil
 }
 
+func (x *PreviewTripRequest) GetPromoCode() string {
+	if x != nil {
+		return x.PromoCode
+	}
+	return ""
+}
+
+func (x *PreviewTripRequest) GetScheduledFor() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ScheduledFor
+	}
+	return nil
+}
+
+func (x *PreviewTripRequest) GetStops() []*Coordinate {
+	if x != nil {
+		return x.Stops
+	}
+	return nil
+}
+
 type PreviewTripResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	TripID        string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
@@ -238,10 +266,12 @@ func (x *Geometry) GetCoordinates() []*Coordinate {
 }
 
 type Route struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Geometry      []*Geometry            `protobuf:"bytes,1,rep,name=geometry,proto3" json:"geometry,omitempty"`
-	Distance      float64                `protobuf:"fixed64,2,opt,name=distance,proto3" json:"distance,omitempty"`
-	Duration      float64                `protobuf:"fixed64,3,opt,name=duration,proto3" json:"duration,omitempty"`
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Geometry []*Geometry            `protobuf:"bytes,1,rep,name=geometry,proto3" json:"geometry,omitempty"`
+	Distance float64                `protobuf:"fixed64,2,opt,name=distance,proto3" json:"distance,omitempty"`
+	Duration float64                `protobuf:"fixed64,3,opt,name=duration,proto3" json:"duration,omitempty"`
+	// One leg per pair of consecutive waypoints, a trip with n stops has n+1 legs
+	Legs          []*RouteLeg `protobuf:"bytes,4,rep,name=legs,proto3" json:"legs,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -297,19 +327,90 @@ func (x *Route) GetDuration() float64 {
 	return 0
 }
 
+func (x *Route) GetLegs() []*RouteLeg {
+	if x != nil {
+		return x.Legs
+	}
+	return nil
+}
+
+type RouteLeg struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Distance      float64                `protobuf:"fixed64,1,opt,name=distance,proto3" json:"distance,omitempty"`
+	Duration      float64                `protobuf:"fixed64,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RouteLeg) Reset() {
+	*x = RouteLeg{}
+	mi := &file_trip_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RouteLeg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteLeg) ProtoMessage() {}
+
+func (x *RouteLeg) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteLeg.ProtoReflect.Descriptor instead.
+func (*RouteLeg) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RouteLeg) GetDistance() float64 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+func (x *RouteLeg) GetDuration() float64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
 type RideFare struct {
-	state             protoimpl.MessageState `protogen:"open.v1"`
-	Id                string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	UserID            string                 `protobuf:"bytes,2,opt,name=userID,proto3" json:"userID,omitempty"`
-	PackageSlug       string                 `protobuf:"bytes,3,opt,name=packageSlug,proto3" json:"packageSlug,omitempty"`
-	TotalPriceInCents float64                `protobuf:"fixed64,4,opt,name=totalPriceInCents,proto3" json:"totalPriceInCents,omitempty"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserID      string                 `protobuf:"bytes,2,opt,name=userID,proto3" json:"userID,omitempty"`
+	PackageSlug string                 `protobuf:"bytes,3,opt,name=packageSlug,proto3" json:"packageSlug,omitempty"`
+	ExpiresAt   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=expiresAt,proto3" json:"expiresAt,omitempty"`
+	// 1 when there is no surge, already applied to totalPrice
+	SurgeMultiplier float64 `protobuf:"fixed64,6,opt,name=surgeMultiplier,proto3" json:"surgeMultiplier,omitempty"`
+	// How totalPrice was built, in the order the pricing rules were applied
+	LineItems  []*FareLineItem `protobuf:"bytes,7,rep,name=lineItems,proto3" json:"lineItems,omitempty"`
+	TotalPrice *Money          `protobuf:"bytes,8,opt,name=totalPrice,proto3" json:"totalPrice,omitempty"`
+	// Promotion discount, already deducted from totalPrice
+	Discount  *Money `protobuf:"bytes,9,opt,name=discount,proto3" json:"discount,omitempty"`
+	PromoCode string `protobuf:"bytes,10,opt,name=promoCode,proto3" json:"promoCode,omitempty"`
+	// Pickup time of a booking, a trip started from this fare is scheduled
+	ScheduledFor *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=scheduledFor,proto3" json:"scheduledFor,omitempty"`
+	// Where the rider asked to be picked up
+	Pickup        *Coordinate `protobuf:"bytes,12,opt,name=pickup,proto3" json:"pickup,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *RideFare) Reset() {
 	*x = RideFare{}
-	mi := &file_trip_proto_msgTypes[5]
+	mi := &file_trip_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -321,7 +422,7 @@ func (x *RideFare) String() string {
 func (*RideFare) ProtoMessage() {}
 
 func (x *RideFare) ProtoReflect() protoreflect.Message {
-	mi := &file_trip_proto_msgTypes[5]
+	mi := &file_trip_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -334,7 +435,7 @@ func (x *RideFare) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RideFare.ProtoReflect.Descriptor instead.
 func (*RideFare) Descriptor() ([]byte, []int) {
-	return file_trip_proto_rawDescGZIP(), []int{5}
+	return file_trip_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *RideFare) GetId() string {
@@ -358,13 +459,167 @@ func (x *RideFare) GetPackageSlug() string {
 	return ""
 }
 
-func (x *RideFare) GetTotalPriceInCents() float64 {
+func (x *RideFare) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *RideFare) GetSurgeMultiplier() float64 {
+	if x != nil {
+		return x.SurgeMultiplier
+	}
+	return 0
+}
+
+func (x *RideFare) GetLineItems() []*FareLineItem {
+	if x != nil {
+		return x.LineItems
+	}
+	return nil
+}
+
+func (x *RideFare) GetTotalPrice() *Money {
+	if x != nil {
+		return x.TotalPrice
+	}
+	return nil
+}
+
+func (x *RideFare) GetDiscount() *Money {
+	if x != nil {
+		return x.Discount
+	}
+	return nil
+}
+
+func (x *RideFare) GetPromoCode() string {
+	if x != nil {
+		return x.PromoCode
+	}
+	return ""
+}
+
+func (x *RideFare) GetScheduledFor() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ScheduledFor
+	}
+	return nil
+}
+
+func (x *RideFare) GetPickup() *Coordinate {
+	if x != nil {
+		return x.Pickup
+	}
+	return nil
+}
+
+type FareLineItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Amount        *Money                 `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FareLineItem) Reset() {
+	*x = FareLineItem{}
+	mi := &file_trip_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FareLineItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FareLineItem) ProtoMessage() {}
+
+func (x *FareLineItem) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FareLineItem.ProtoReflect.Descriptor instead.
+func (*FareLineItem) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FareLineItem) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FareLineItem) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+// Amount in the minor unit of the currency (cents for USD)
+type Money struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AmountMinor   int64                  `protobuf:"varint,1,opt,name=amountMinor,proto3" json:"amountMinor,omitempty"`
+	Currency      string                 `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Money) Reset() {
+	*x = Money{}
+	mi := &file_trip_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Money) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Money) ProtoMessage() {}
+
+func (x *Money) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Money.ProtoReflect.Descriptor instead.
+func (*Money) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Money) GetAmountMinor() int64 {
 	if x != nil {
-		return x.TotalPriceInCents
+		return x.AmountMinor
 	}
 	return 0
 }
 
+func (x *Money) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
 type CreateTripRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	RideFareID    string                 `protobuf:"bytes,1,opt,name=rideFareID,proto3" json:"rideFareID,omitempty"`
@@ -375,7 +630,7 @@ type CreateTripRequest struct {
 
 func (x *CreateTripRequest) Reset() {
 	*x = CreateTripRequest{}
-	mi := &file_trip_proto_msgTypes[6]
+	mi := &file_trip_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -387,7 +642,7 @@ func (x *CreateTripRequest) String() string {
 func (*CreateTripRequest) ProtoMessage() {}
 
 func (x *CreateTripRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_trip_proto_msgTypes[6]
+	mi := &file_trip_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -400,7 +655,7 @@ func (x *CreateTripRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateTripRequest.ProtoReflect.Descriptor instead.
 func (*CreateTripRequest) Descriptor() ([]byte, []int) {
-	return file_trip_proto_rawDescGZIP(), []int{6}
+	return file_trip_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *CreateTripRequest) GetRideFareID() string {
@@ -427,7 +682,7 @@ type CreateTripResponse struct {
 
 func (x *CreateTripResponse) Reset() {
 	*x = CreateTripResponse{}
-	mi := &file_trip_proto_msgTypes[7]
+	mi := &file_trip_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -439,7 +694,7 @@ func (x *CreateTripResponse) String() string {
 func (*CreateTripResponse) ProtoMessage() {}
 
 func (x *CreateTripResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_trip_proto_msgTypes[7]
+	mi := &file_trip_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -452,7 +707,7 @@ func (x *CreateTripResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateTripResponse.ProtoReflect.Descriptor instead.
 func (*CreateTripResponse) Descriptor() ([]byte, []int) {
-	return file_trip_proto_rawDescGZIP(), []int{7}
+	return file_trip_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *CreateTripResponse) GetTripID() string {
@@ -469,33 +724,30 @@ func (x *CreateTripResponse) GetTrip() *Trip {
 	return nil
 }
 
-type Trip struct {
+type CancelTripRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	SelectedFare  *RideFare              `protobuf:"bytes,2,opt,name=selectedFare,proto3" json:"selectedFare,omitempty"`
-	Route         *Route                 `protobuf:"bytes,3,opt,name=route,proto3" json:"route,omitempty"`
-	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
-	UserID        string                 `protobuf:"bytes,5,opt,name=userID,proto3" json:"userID,omitempty"`
-	Driver        *TripDriver            `protobuf:"bytes,6,opt,name=driver,proto3" json:"driver,omitempty"`
+	TripID        string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	UserID        string                 `protobuf:"bytes,2,opt,name=userID,proto3" json:"userID,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Trip) Reset() {
-	*x = Trip{}
-	mi := &file_trip_proto_msgTypes[8]
+func (x *CancelTripRequest) Reset() {
+	*x = CancelTripRequest{}
+	mi := &file_trip_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Trip) String() string {
+func (x *CancelTripRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Trip) ProtoMessage() {}
+func (*CancelTripRequest) ProtoMessage() {}
 
-func (x *Trip) ProtoReflect() protoreflect.Message {
-	mi := &file_trip_proto_msgTypes[8]
+func (x *CancelTripRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -506,79 +758,55 @@ func (x *Trip) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Trip.ProtoReflect.Descriptor instead.
-func (*Trip) Descriptor() ([]byte, []int) {
-	return file_trip_proto_rawDescGZIP(), []int{8}
-}
-
-func (x *Trip) GetId() string {
-	if x != nil {
-		return x.Id
-	}
-	return ""
-}
-
-func (x *Trip) GetSelectedFare() *RideFare {
-	if x != nil {
-		return x.SelectedFare
-	}
-	return nil
-}
-
-func (x *Trip) GetRoute() *Route {
-	if x != nil {
-		return x.Route
-	}
-	return nil
+// Deprecated: Use CancelTripRequest.ProtoReflect.Descriptor instead.
+func (*CancelTripRequest) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *Trip) GetStatus() string {
+func (x *CancelTripRequest) GetTripID() string {
 	if x != nil {
-		return x.Status
+		return x.TripID
 	}
 	return ""
 }
 
-func (x *Trip) GetUserID() string {
+func (x *CancelTripRequest) GetUserID() string {
 	if x != nil {
 		return x.UserID
 	}
 	return ""
 }
 
-func (x *Trip) GetDriver() *TripDriver {
+func (x *CancelTripRequest) GetReason() string {
 	if x != nil {
-		return x.Driver
+		return x.Reason
 	}
-	return nil
+	return ""
 }
 
-// Static driver object that is used to store the driver information
-type TripDriver struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	ProfilePicture string                 `protobuf:"bytes,3,opt,name=profilePicture,proto3" json:"profilePicture,omitempty"`
-	CarPlate       string                 `protobuf:"bytes,4,opt,name=carPlate,proto3" json:"carPlate,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+type CancelTripResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Trip            *Trip                  `protobuf:"bytes,1,opt,name=trip,proto3" json:"trip,omitempty"`
+	CancellationFee *Money                 `protobuf:"bytes,3,opt,name=cancellationFee,proto3" json:"cancellationFee,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *TripDriver) Reset() {
-	*x = TripDriver{}
-	mi := &file_trip_proto_msgTypes[9]
+func (x *CancelTripResponse) Reset() {
+	*x = CancelTripResponse{}
+	mi := &file_trip_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TripDriver) String() string {
+func (x *CancelTripResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TripDriver) ProtoMessage() {}
+func (*CancelTripResponse) ProtoMessage() {}
 
-func (x *TripDriver) ProtoReflect() protoreflect.Message {
-	mi := &file_trip_proto_msgTypes[9]
+func (x *CancelTripResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -589,16 +817,794 @@ func (x *TripDriver) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TripDriver.ProtoReflect.Descriptor instead.
-func (*TripDriver) Descriptor() ([]byte, []int) {
-	return file_trip_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use CancelTripResponse.ProtoReflect.Descriptor instead.
+func (*CancelTripResponse) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *TripDriver) GetId() string {
+func (x *CancelTripResponse) GetTrip() *Trip {
 	if x != nil {
-		return x.Id
+		return x.Trip
 	}
-	return ""
+	return nil
+}
+
+func (x *CancelTripResponse) GetCancellationFee() *Money {
+	if x != nil {
+		return x.CancellationFee
+	}
+	return nil
+}
+
+// The trip is only returned to its rider or to its driver
+type GetTripRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TripID        string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	UserID        string                 `protobuf:"bytes,2,opt,name=userID,proto3" json:"userID,omitempty"`
+	DriverID      string                 `protobuf:"bytes,3,opt,name=driverID,proto3" json:"driverID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTripRequest) Reset() {
+	*x = GetTripRequest{}
+	mi := &file_trip_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTripRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTripRequest) ProtoMessage() {}
+
+func (x *GetTripRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTripRequest.ProtoReflect.Descriptor instead.
+func (*GetTripRequest) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetTripRequest) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+func (x *GetTripRequest) GetUserID() string {
+	if x != nil {
+		return x.UserID
+	}
+	return ""
+}
+
+func (x *GetTripRequest) GetDriverID() string {
+	if x != nil {
+		return x.DriverID
+	}
+	return ""
+}
+
+type GetTripResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Trip          *Trip                  `protobuf:"bytes,1,opt,name=trip,proto3" json:"trip,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTripResponse) Reset() {
+	*x = GetTripResponse{}
+	mi := &file_trip_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTripResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTripResponse) ProtoMessage() {}
+
+func (x *GetTripResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTripResponse.ProtoReflect.Descriptor instead.
+func (*GetTripResponse) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetTripResponse) GetTrip() *Trip {
+	if x != nil {
+		return x.Trip
+	}
+	return nil
+}
+
+// All filters are optional and combined with AND
+type ListTripsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserID        string                 `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
+	DriverID      string                 `protobuf:"bytes,2,opt,name=driverID,proto3" json:"driverID,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedFrom   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=createdFrom,proto3" json:"createdFrom,omitempty"`
+	CreatedTo     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=createdTo,proto3" json:"createdTo,omitempty"`
+	PageSize      int32                  `protobuf:"varint,6,opt,name=pageSize,proto3" json:"pageSize,omitempty"`
+	PageToken     string                 `protobuf:"bytes,7,opt,name=pageToken,proto3" json:"pageToken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTripsRequest) Reset() {
+	*x = ListTripsRequest{}
+	mi := &file_trip_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTripsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTripsRequest) ProtoMessage() {}
+
+func (x *ListTripsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTripsRequest.ProtoReflect.Descriptor instead.
+func (*ListTripsRequest) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListTripsRequest) GetUserID() string {
+	if x != nil {
+		return x.UserID
+	}
+	return ""
+}
+
+func (x *ListTripsRequest) GetDriverID() string {
+	if x != nil {
+		return x.DriverID
+	}
+	return ""
+}
+
+func (x *ListTripsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListTripsRequest) GetCreatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedFrom
+	}
+	return nil
+}
+
+func (x *ListTripsRequest) GetCreatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return nil
+}
+
+func (x *ListTripsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListTripsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListTripsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Trips         []*Trip                `protobuf:"bytes,1,rep,name=trips,proto3" json:"trips,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=nextPageToken,proto3" json:"nextPageToken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTripsResponse) Reset() {
+	*x = ListTripsResponse{}
+	mi := &file_trip_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTripsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTripsResponse) ProtoMessage() {}
+
+func (x *ListTripsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTripsResponse.ProtoReflect.Descriptor instead.
+func (*ListTripsResponse) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListTripsResponse) GetTrips() []*Trip {
+	if x != nil {
+		return x.Trips
+	}
+	return nil
+}
+
+func (x *ListTripsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type ListScheduledTripsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserID        string                 `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListScheduledTripsRequest) Reset() {
+	*x = ListScheduledTripsRequest{}
+	mi := &file_trip_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListScheduledTripsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListScheduledTripsRequest) ProtoMessage() {}
+
+func (x *ListScheduledTripsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListScheduledTripsRequest.ProtoReflect.Descriptor instead.
+func (*ListScheduledTripsRequest) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListScheduledTripsRequest) GetUserID() string {
+	if x != nil {
+		return x.UserID
+	}
+	return ""
+}
+
+type ListScheduledTripsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Trips         []*Trip                `protobuf:"bytes,1,rep,name=trips,proto3" json:"trips,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListScheduledTripsResponse) Reset() {
+	*x = ListScheduledTripsResponse{}
+	mi := &file_trip_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListScheduledTripsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListScheduledTripsResponse) ProtoMessage() {}
+
+func (x *ListScheduledTripsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListScheduledTripsResponse.ProtoReflect.Descriptor instead.
+func (*ListScheduledTripsResponse) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListScheduledTripsResponse) GetTrips() []*Trip {
+	if x != nil {
+		return x.Trips
+	}
+	return nil
+}
+
+type ListPackagesRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	IncludeDisabled bool                   `protobuf:"varint,1,opt,name=includeDisabled,proto3" json:"includeDisabled,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ListPackagesRequest) Reset() {
+	*x = ListPackagesRequest{}
+	mi := &file_trip_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPackagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPackagesRequest) ProtoMessage() {}
+
+func (x *ListPackagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPackagesRequest.ProtoReflect.Descriptor instead.
+func (*ListPackagesRequest) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListPackagesRequest) GetIncludeDisabled() bool {
+	if x != nil {
+		return x.IncludeDisabled
+	}
+	return false
+}
+
+type ListPackagesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Packages      []*VehiclePackage      `protobuf:"bytes,1,rep,name=packages,proto3" json:"packages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPackagesResponse) Reset() {
+	*x = ListPackagesResponse{}
+	mi := &file_trip_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPackagesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPackagesResponse) ProtoMessage() {}
+
+func (x *ListPackagesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPackagesResponse.ProtoReflect.Descriptor instead.
+func (*ListPackagesResponse) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListPackagesResponse) GetPackages() []*VehiclePackage {
+	if x != nil {
+		return x.Packages
+	}
+	return nil
+}
+
+type VehiclePackage struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Slug             string                 `protobuf:"bytes,1,opt,name=slug,proto3" json:"slug,omitempty"`
+	DisplayName      string                 `protobuf:"bytes,2,opt,name=displayName,proto3" json:"displayName,omitempty"`
+	SeatCapacity     int32                  `protobuf:"varint,3,opt,name=seatCapacity,proto3" json:"seatCapacity,omitempty"`
+	BaseFareMinor    float64                `protobuf:"fixed64,4,opt,name=baseFareMinor,proto3" json:"baseFareMinor,omitempty"`
+	PerKmMinor       float64                `protobuf:"fixed64,5,opt,name=perKmMinor,proto3" json:"perKmMinor,omitempty"`
+	PerMinuteMinor   float64                `protobuf:"fixed64,6,opt,name=perMinuteMinor,proto3" json:"perMinuteMinor,omitempty"`
+	MinimumFareMinor float64                `protobuf:"fixed64,7,opt,name=minimumFareMinor,proto3" json:"minimumFareMinor,omitempty"`
+	Enabled          bool                   `protobuf:"varint,8,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *VehiclePackage) Reset() {
+	*x = VehiclePackage{}
+	mi := &file_trip_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VehiclePackage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VehiclePackage) ProtoMessage() {}
+
+func (x *VehiclePackage) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VehiclePackage.ProtoReflect.Descriptor instead.
+func (*VehiclePackage) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *VehiclePackage) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *VehiclePackage) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *VehiclePackage) GetSeatCapacity() int32 {
+	if x != nil {
+		return x.SeatCapacity
+	}
+	return 0
+}
+
+func (x *VehiclePackage) GetBaseFareMinor() float64 {
+	if x != nil {
+		return x.BaseFareMinor
+	}
+	return 0
+}
+
+func (x *VehiclePackage) GetPerKmMinor() float64 {
+	if x != nil {
+		return x.PerKmMinor
+	}
+	return 0
+}
+
+func (x *VehiclePackage) GetPerMinuteMinor() float64 {
+	if x != nil {
+		return x.PerMinuteMinor
+	}
+	return 0
+}
+
+func (x *VehiclePackage) GetMinimumFareMinor() float64 {
+	if x != nil {
+		return x.MinimumFareMinor
+	}
+	return 0
+}
+
+func (x *VehiclePackage) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+type Trip struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Id           string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	SelectedFare *RideFare              `protobuf:"bytes,2,opt,name=selectedFare,proto3" json:"selectedFare,omitempty"`
+	Route        *Route                 `protobuf:"bytes,3,opt,name=route,proto3" json:"route,omitempty"`
+	Status       string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	UserID       string                 `protobuf:"bytes,5,opt,name=userID,proto3" json:"userID,omitempty"`
+	Driver       *TripDriver            `protobuf:"bytes,6,opt,name=driver,proto3" json:"driver,omitempty"`
+	CreatedAt    *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	ScheduledFor *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=scheduledFor,proto3" json:"scheduledFor,omitempty"`
+	Stops        []*TripStop            `protobuf:"bytes,9,rep,name=stops,proto3" json:"stops,omitempty"`
+	// Index of the leg the driver is driving, it moves forward each time a stop is reached
+	CurrentLeg int32 `protobuf:"varint,10,opt,name=currentLeg,proto3" json:"currentLeg,omitempty"`
+	// Service zone of the pickup, empty when the service area isn't restricted
+	ZoneID string `protobuf:"bytes,11,opt,name=zoneID,proto3" json:"zoneID,omitempty"`
+	// Where the rider asked to be picked up, drivers are matched around it
+	Pickup *Coordinate `protobuf:"bytes,12,opt,name=pickup,proto3" json:"pickup,omitempty"`
+	// When the ride was paid, unset until then
+	PaidAt        *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=paidAt,proto3" json:"paidAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Trip) Reset() {
+	*x = Trip{}
+	mi := &file_trip_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Trip) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Trip) ProtoMessage() {}
+
+func (x *Trip) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Trip.ProtoReflect.Descriptor instead.
+func (*Trip) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Trip) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Trip) GetSelectedFare() *RideFare {
+	if x != nil {
+		return x.SelectedFare
+	}
+	return nil
+}
+
+func (x *Trip) GetRoute() *Route {
+	if x != nil {
+		return x.Route
+	}
+	return nil
+}
+
+func (x *Trip) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Trip) GetUserID() string {
+	if x != nil {
+		return x.UserID
+	}
+	return ""
+}
+
+func (x *Trip) GetDriver() *TripDriver {
+	if x != nil {
+		return x.Driver
+	}
+	return nil
+}
+
+func (x *Trip) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Trip) GetScheduledFor() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ScheduledFor
+	}
+	return nil
+}
+
+func (x *Trip) GetStops() []*TripStop {
+	if x != nil {
+		return x.Stops
+	}
+	return nil
+}
+
+func (x *Trip) GetCurrentLeg() int32 {
+	if x != nil {
+		return x.CurrentLeg
+	}
+	return 0
+}
+
+func (x *Trip) GetZoneID() string {
+	if x != nil {
+		return x.ZoneID
+	}
+	return ""
+}
+
+func (x *Trip) GetPickup() *Coordinate {
+	if x != nil {
+		return x.Pickup
+	}
+	return nil
+}
+
+func (x *Trip) GetPaidAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PaidAt
+	}
+	return nil
+}
+
+// Intermediate stop of a trip
+type TripStop struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Location      *Coordinate            `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	ReachedAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=reachedAt,proto3" json:"reachedAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TripStop) Reset() {
+	*x = TripStop{}
+	mi := &file_trip_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TripStop) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TripStop) ProtoMessage() {}
+
+func (x *TripStop) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TripStop.ProtoReflect.Descriptor instead.
+func (*TripStop) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *TripStop) GetLocation() *Coordinate {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *TripStop) GetReachedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReachedAt
+	}
+	return nil
+}
+
+// Static driver object that is used to store the driver information
+type TripDriver struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	ProfilePicture string                 `protobuf:"bytes,3,opt,name=profilePicture,proto3" json:"profilePicture,omitempty"`
+	CarPlate       string                 `protobuf:"bytes,4,opt,name=carPlate,proto3" json:"carPlate,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *TripDriver) Reset() {
+	*x = TripDriver{}
+	mi := &file_trip_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TripDriver) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TripDriver) ProtoMessage() {}
+
+func (x *TripDriver) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TripDriver.ProtoReflect.Descriptor instead.
+func (*TripDriver) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *TripDriver) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
 }
 
 func (x *TripDriver) GetName() string {
@@ -622,16 +1628,229 @@ func (x *TripDriver) GetCarPlate() string {
 	return ""
 }
 
+// The timeline is shown to the rider of the trip or to its driver, set one of them
+type GetTripTimelineRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TripID        string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	UserID        string                 `protobuf:"bytes,2,opt,name=userID,proto3" json:"userID,omitempty"`
+	DriverID      string                 `protobuf:"bytes,3,opt,name=driverID,proto3" json:"driverID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTripTimelineRequest) Reset() {
+	*x = GetTripTimelineRequest{}
+	mi := &file_trip_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTripTimelineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTripTimelineRequest) ProtoMessage() {}
+
+func (x *GetTripTimelineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTripTimelineRequest.ProtoReflect.Descriptor instead.
+func (*GetTripTimelineRequest) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GetTripTimelineRequest) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+func (x *GetTripTimelineRequest) GetUserID() string {
+	if x != nil {
+		return x.UserID
+	}
+	return ""
+}
+
+func (x *GetTripTimelineRequest) GetDriverID() string {
+	if x != nil {
+		return x.DriverID
+	}
+	return ""
+}
+
+type GetTripTimelineResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Oldest first
+	Entries       []*TimelineEntry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTripTimelineResponse) Reset() {
+	*x = GetTripTimelineResponse{}
+	mi := &file_trip_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTripTimelineResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTripTimelineResponse) ProtoMessage() {}
+
+func (x *GetTripTimelineResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTripTimelineResponse.ProtoReflect.Descriptor instead.
+func (*GetTripTimelineResponse) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *GetTripTimelineResponse) GetEntries() []*TimelineEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+// Event in the history of a trip
+type TimelineEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	At    *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=at,proto3" json:"at,omitempty"`
+	// rider, driver or system
+	ActorType string `protobuf:"bytes,3,opt,name=actorType,proto3" json:"actorType,omitempty"`
+	ActorID   string `protobuf:"bytes,4,opt,name=actorID,proto3" json:"actorID,omitempty"`
+	Reason    string `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Set on status changes
+	FromStatus string `protobuf:"bytes,6,opt,name=fromStatus,proto3" json:"fromStatus,omitempty"`
+	ToStatus   string `protobuf:"bytes,7,opt,name=toStatus,proto3" json:"toStatus,omitempty"`
+	// Set on driver offers
+	DriverID      string `protobuf:"bytes,8,opt,name=driverID,proto3" json:"driverID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TimelineEntry) Reset() {
+	*x = TimelineEntry{}
+	mi := &file_trip_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimelineEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimelineEntry) ProtoMessage() {}
+
+func (x *TimelineEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimelineEntry.ProtoReflect.Descriptor instead.
+func (*TimelineEntry) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *TimelineEntry) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *TimelineEntry) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *TimelineEntry) GetActorType() string {
+	if x != nil {
+		return x.ActorType
+	}
+	return ""
+}
+
+func (x *TimelineEntry) GetActorID() string {
+	if x != nil {
+		return x.ActorID
+	}
+	return ""
+}
+
+func (x *TimelineEntry) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *TimelineEntry) GetFromStatus() string {
+	if x != nil {
+		return x.FromStatus
+	}
+	return ""
+}
+
+func (x *TimelineEntry) GetToStatus() string {
+	if x != nil {
+		return x.ToStatus
+	}
+	return ""
+}
+
+func (x *TimelineEntry) GetDriverID() string {
+	if x != nil {
+		return x.DriverID
+	}
+	return ""
+}
+
 var File_trip_proto protoreflect.FileDescriptor
 
 const file_trip_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"trip.proto\x12\x04trip\"\x98\x01\n" +
+	"trip.proto\x12\x04trip\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9e\x02\n" +
 	"\x12PreviewTripRequest\x12\x16\n" +
 	"\x06userID\x18\x01 \x01(\tR\x06userID\x126\n" +
 	"\rstartLocation\x18\x02 \x01(\v2\x10.trip.CoordinateR\rstartLocation\x122\n" +
-	"\vendLocation\x18\x03 \x01(\v2\x10.trip.CoordinateR\vendLocation\"~\n" +
+	"\vendLocation\x18\x03 \x01(\v2\x10.trip.CoordinateR\vendLocation\x12\x1c\n" +
+	"\tpromoCode\x18\x04 \x01(\tR\tpromoCode\x12>\n" +
+	"\fscheduledFor\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\fscheduledFor\x12&\n" +
+	"\x05stops\x18\x06 \x03(\v2\x10.trip.CoordinateR\x05stops\"~\n" +
 	"\x13PreviewTripResponse\x12\x16\n" +
 	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12!\n" +
 	"\x05route\x18\x02 \x01(\v2\v.trip.RouteR\x05route\x12,\n" +
@@ -641,16 +1860,36 @@ const file_trip_proto_rawDesc = "" +
 	"\blatitude\x18\x01 \x01(\x01R\blatitude\x12\x1c\n" +
 	"\tlongitude\x18\x02 \x01(\x01R\tlongitude\">\n" +
 	"\bGeometry\x122\n" +
-	"\vcoordinates\x18\x01 \x03(\v2\x10.trip.CoordinateR\vcoordinates\"k\n" +
+	"\vcoordinates\x18\x01 \x03(\v2\x10.trip.CoordinateR\vcoordinates\"\x8f\x01\n" +
 	"\x05Route\x12*\n" +
 	"\bgeometry\x18\x01 \x03(\v2\x0e.trip.GeometryR\bgeometry\x12\x1a\n" +
 	"\bdistance\x18\x02 \x01(\x01R\bdistance\x12\x1a\n" +
-	"\bduration\x18\x03 \x01(\x01R\bduration\"\x82\x01\n" +
+	"\bduration\x18\x03 \x01(\x01R\bduration\x12\"\n" +
+	"\x04legs\x18\x04 \x03(\v2\x0e.trip.RouteLegR\x04legs\"B\n" +
+	"\bRouteLeg\x12\x1a\n" +
+	"\bdistance\x18\x01 \x01(\x01R\bdistance\x12\x1a\n" +
+	"\bduration\x18\x02 \x01(\x01R\bduration\"\xe1\x03\n" +
 	"\bRideFare\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
 	"\x06userID\x18\x02 \x01(\tR\x06userID\x12 \n" +
-	"\vpackageSlug\x18\x03 \x01(\tR\vpackageSlug\x12,\n" +
-	"\x11totalPriceInCents\x18\x04 \x01(\x01R\x11totalPriceInCents\"K\n" +
+	"\vpackageSlug\x18\x03 \x01(\tR\vpackageSlug\x128\n" +
+	"\texpiresAt\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12(\n" +
+	"\x0fsurgeMultiplier\x18\x06 \x01(\x01R\x0fsurgeMultiplier\x120\n" +
+	"\tlineItems\x18\a \x03(\v2\x12.trip.FareLineItemR\tlineItems\x12+\n" +
+	"\n" +
+	"totalPrice\x18\b \x01(\v2\v.trip.MoneyR\n" +
+	"totalPrice\x12'\n" +
+	"\bdiscount\x18\t \x01(\v2\v.trip.MoneyR\bdiscount\x12\x1c\n" +
+	"\tpromoCode\x18\n" +
+	" \x01(\tR\tpromoCode\x12>\n" +
+	"\fscheduledFor\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\fscheduledFor\x12(\n" +
+	"\x06pickup\x18\f \x01(\v2\x10.trip.CoordinateR\x06pickupJ\x04\b\x04\x10\x05R\x11totalPriceInCents\"\\\n" +
+	"\fFareLineItem\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12#\n" +
+	"\x06amount\x18\x03 \x01(\v2\v.trip.MoneyR\x06amountJ\x04\b\x02\x10\x03R\ramountInCents\"E\n" +
+	"\x05Money\x12 \n" +
+	"\vamountMinor\x18\x01 \x01(\x03R\vamountMinor\x12\x1a\n" +
+	"\bcurrency\x18\x02 \x01(\tR\bcurrency\"K\n" +
 	"\x11CreateTripRequest\x12\x1e\n" +
 	"\n" +
 	"rideFareID\x18\x01 \x01(\tR\n" +
@@ -659,24 +1898,108 @@ const file_trip_proto_rawDesc = "" +
 	"\x12CreateTripResponse\x12\x16\n" +
 	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12\x1e\n" +
 	"\x04trip\x18\x02 \x01(\v2\n" +
-	".trip.TripR\x04trip\"\xc7\x01\n" +
+	".trip.TripR\x04trip\"[\n" +
+	"\x11CancelTripRequest\x12\x16\n" +
+	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12\x16\n" +
+	"\x06userID\x18\x02 \x01(\tR\x06userID\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"\x87\x01\n" +
+	"\x12CancelTripResponse\x12\x1e\n" +
+	"\x04trip\x18\x01 \x01(\v2\n" +
+	".trip.TripR\x04trip\x125\n" +
+	"\x0fcancellationFee\x18\x03 \x01(\v2\v.trip.MoneyR\x0fcancellationFeeJ\x04\b\x02\x10\x03R\x14cancellationFeeMinor\"\\\n" +
+	"\x0eGetTripRequest\x12\x16\n" +
+	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12\x16\n" +
+	"\x06userID\x18\x02 \x01(\tR\x06userID\x12\x1a\n" +
+	"\bdriverID\x18\x03 \x01(\tR\bdriverID\"1\n" +
+	"\x0fGetTripResponse\x12\x1e\n" +
+	"\x04trip\x18\x01 \x01(\v2\n" +
+	".trip.TripR\x04trip\"\x90\x02\n" +
+	"\x10ListTripsRequest\x12\x16\n" +
+	"\x06userID\x18\x01 \x01(\tR\x06userID\x12\x1a\n" +
+	"\bdriverID\x18\x02 \x01(\tR\bdriverID\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12<\n" +
+	"\vcreatedFrom\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vcreatedFrom\x128\n" +
+	"\tcreatedTo\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedTo\x12\x1a\n" +
+	"\bpageSize\x18\x06 \x01(\x05R\bpageSize\x12\x1c\n" +
+	"\tpageToken\x18\a \x01(\tR\tpageToken\"[\n" +
+	"\x11ListTripsResponse\x12 \n" +
+	"\x05trips\x18\x01 \x03(\v2\n" +
+	".trip.TripR\x05trips\x12$\n" +
+	"\rnextPageToken\x18\x02 \x01(\tR\rnextPageToken\"3\n" +
+	"\x19ListScheduledTripsRequest\x12\x16\n" +
+	"\x06userID\x18\x01 \x01(\tR\x06userID\">\n" +
+	"\x1aListScheduledTripsResponse\x12 \n" +
+	"\x05trips\x18\x01 \x03(\v2\n" +
+	".trip.TripR\x05trips\"?\n" +
+	"\x13ListPackagesRequest\x12(\n" +
+	"\x0fincludeDisabled\x18\x01 \x01(\bR\x0fincludeDisabled\"H\n" +
+	"\x14ListPackagesResponse\x120\n" +
+	"\bpackages\x18\x01 \x03(\v2\x14.trip.VehiclePackageR\bpackages\"\x9e\x02\n" +
+	"\x0eVehiclePackage\x12\x12\n" +
+	"\x04slug\x18\x01 \x01(\tR\x04slug\x12 \n" +
+	"\vdisplayName\x18\x02 \x01(\tR\vdisplayName\x12\"\n" +
+	"\fseatCapacity\x18\x03 \x01(\x05R\fseatCapacity\x12$\n" +
+	"\rbaseFareMinor\x18\x04 \x01(\x01R\rbaseFareMinor\x12\x1e\n" +
+	"\n" +
+	"perKmMinor\x18\x05 \x01(\x01R\n" +
+	"perKmMinor\x12&\n" +
+	"\x0eperMinuteMinor\x18\x06 \x01(\x01R\x0eperMinuteMinor\x12*\n" +
+	"\x10minimumFareMinor\x18\a \x01(\x01R\x10minimumFareMinor\x12\x18\n" +
+	"\aenabled\x18\b \x01(\bR\aenabled\"\xfd\x03\n" +
 	"\x04Trip\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x122\n" +
 	"\fselectedFare\x18\x02 \x01(\v2\x0e.trip.RideFareR\fselectedFare\x12!\n" +
 	"\x05route\x18\x03 \x01(\v2\v.trip.RouteR\x05route\x12\x16\n" +
 	"\x06status\x18\x04 \x01(\tR\x06status\x12\x16\n" +
 	"\x06userID\x18\x05 \x01(\tR\x06userID\x12(\n" +
-	"\x06driver\x18\x06 \x01(\v2\x10.trip.TripDriverR\x06driver\"t\n" +
+	"\x06driver\x18\x06 \x01(\v2\x10.trip.TripDriverR\x06driver\x128\n" +
+	"\tcreatedAt\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12>\n" +
+	"\fscheduledFor\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\fscheduledFor\x12$\n" +
+	"\x05stops\x18\t \x03(\v2\x0e.trip.TripStopR\x05stops\x12\x1e\n" +
+	"\n" +
+	"currentLeg\x18\n" +
+	" \x01(\x05R\n" +
+	"currentLeg\x12\x16\n" +
+	"\x06zoneID\x18\v \x01(\tR\x06zoneID\x12(\n" +
+	"\x06pickup\x18\f \x01(\v2\x10.trip.CoordinateR\x06pickup\x122\n" +
+	"\x06paidAt\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\x06paidAt\"r\n" +
+	"\bTripStop\x12,\n" +
+	"\blocation\x18\x01 \x01(\v2\x10.trip.CoordinateR\blocation\x128\n" +
+	"\treachedAt\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\treachedAt\"t\n" +
 	"\n" +
 	"TripDriver\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12&\n" +
 	"\x0eprofilePicture\x18\x03 \x01(\tR\x0eprofilePicture\x12\x1a\n" +
-	"\bcarPlate\x18\x04 \x01(\tR\bcarPlate2\x92\x01\n" +
+	"\bcarPlate\x18\x04 \x01(\tR\bcarPlate\"d\n" +
+	"\x16GetTripTimelineRequest\x12\x16\n" +
+	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12\x16\n" +
+	"\x06userID\x18\x02 \x01(\tR\x06userID\x12\x1a\n" +
+	"\bdriverID\x18\x03 \x01(\tR\bdriverID\"H\n" +
+	"\x17GetTripTimelineResponse\x12-\n" +
+	"\aentries\x18\x01 \x03(\v2\x13.trip.TimelineEntryR\aentries\"\xf7\x01\n" +
+	"\rTimelineEntry\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12*\n" +
+	"\x02at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x1c\n" +
+	"\tactorType\x18\x03 \x01(\tR\tactorType\x12\x18\n" +
+	"\aactorID\x18\x04 \x01(\tR\aactorID\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\x12\x1e\n" +
+	"\n" +
+	"fromStatus\x18\x06 \x01(\tR\n" +
+	"fromStatus\x12\x1a\n" +
+	"\btoStatus\x18\a \x01(\tR\btoStatus\x12\x1a\n" +
+	"\bdriverID\x18\b \x01(\tR\bdriverID2\xb9\x04\n" +
 	"\vTripService\x12B\n" +
 	"\vPreviewTrip\x12\x18.trip.PreviewTripRequest\x1a\x19.trip.PreviewTripResponse\x12?\n" +
 	"\n" +
-	"CreateTrip\x12\x17.trip.CreateTripRequest\x1a\x18.trip.CreateTripResponseB\x18Z\x16shared/proto/trip;tripb\x06proto3"
+	"CreateTrip\x12\x17.trip.CreateTripRequest\x1a\x18.trip.CreateTripResponse\x12?\n" +
+	"\n" +
+	"CancelTrip\x12\x17.trip.CancelTripRequest\x1a\x18.trip.CancelTripResponse\x126\n" +
+	"\aGetTrip\x12\x14.trip.GetTripRequest\x1a\x15.trip.GetTripResponse\x12<\n" +
+	"\tListTrips\x12\x16.trip.ListTripsRequest\x1a\x17.trip.ListTripsResponse\x12E\n" +
+	"\fListPackages\x12\x19.trip.ListPackagesRequest\x1a\x1a.trip.ListPackagesResponse\x12W\n" +
+	"\x12ListScheduledTrips\x12\x1f.trip.ListScheduledTripsRequest\x1a .trip.ListScheduledTripsResponse\x12N\n" +
+	"\x0fGetTripTimeline\x12\x1c.trip.GetTripTimelineRequest\x1a\x1d.trip.GetTripTimelineResponseB\x18Z\x16shared/proto/trip;tripb\x06proto3"
 
 var (
 	file_trip_proto_rawDescOnce sync.Once
@@ -690,39 +2013,97 @@ func file_trip_proto_rawDescGZIP() []byte {
 	return file_trip_proto_rawDescData
 }
 
-var file_trip_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_trip_proto_msgTypes = make([]protoimpl.MessageInfo, 28)
 var file_trip_proto_goTypes = []any{
-	(*PreviewTripRequest)(nil),  // 0: trip.PreviewTripRequest
-	(*PreviewTripResponse)(nil), // 1: trip.PreviewTripResponse
-	(*Coordinate)(nil),          // 2: trip.Coordinate
-	(*Geometry)(nil),            // 3: trip.Geometry
-	(*Route)(nil),               // 4: trip.Route
-	(*RideFare)(nil),            // 5: trip.RideFare
-	(*CreateTripRequest)(nil),   // 6: trip.CreateTripRequest
-	(*CreateTripResponse)(nil),  // 7: trip.CreateTripResponse
-	(*Trip)(nil),                // 8: trip.Trip
-	(*TripDriver)(nil),          // 9: trip.TripDriver
+	(*PreviewTripRequest)(nil),         // 0: trip.PreviewTripRequest
+	(*PreviewTripResponse)(nil),        // 1: trip.PreviewTripResponse
+	(*Coordinate)(nil),                 // 2: trip.Coordinate
+	(*Geometry)(nil),                   // 3: trip.Geometry
+	(*Route)(nil),                      // 4: trip.Route
+	(*RouteLeg)(nil),                   // 5: trip.RouteLeg
+	(*RideFare)(nil),                   // 6: trip.RideFare
+	(*FareLineItem)(nil),               // 7: trip.FareLineItem
+	(*Money)(nil),                      // 8: trip.Money
+	(*CreateTripRequest)(nil),          // 9: trip.CreateTripRequest
+	(*CreateTripResponse)(nil),         // 10: trip.CreateTripResponse
+	(*CancelTripRequest)(nil),          // 11: trip.CancelTripRequest
+	(*CancelTripResponse)(nil),         // 12: trip.CancelTripResponse
+	(*GetTripRequest)(nil),             // 13: trip.GetTripRequest
+	(*GetTripResponse)(nil),            // 14: trip.GetTripResponse
+	(*ListTripsRequest)(nil),           // 15: trip.ListTripsRequest
+	(*ListTripsResponse)(nil),          // 16: trip.ListTripsResponse
+	(*ListScheduledTripsRequest)(nil),  // 17: trip.ListScheduledTripsRequest
+	(*ListScheduledTripsResponse)(nil), // 18: trip.ListScheduledTripsResponse
+	(*ListPackagesRequest)(nil),        // 19: trip.ListPackagesRequest
+	(*ListPackagesResponse)(nil),       // 20: trip.ListPackagesResponse
+	(*VehiclePackage)(nil),             // 21: trip.VehiclePackage
+	(*Trip)(nil),                       // 22: trip.Trip
+	(*TripStop)(nil),                   // 23: trip.TripStop
+	(*TripDriver)(nil),                 // 24: trip.TripDriver
+	(*GetTripTimelineRequest)(nil),     // 25: trip.GetTripTimelineRequest
+	(*GetTripTimelineResponse)(nil),    // 26: trip.GetTripTimelineResponse
+	(*TimelineEntry)(nil),              // 27: trip.TimelineEntry
+	(*timestamppb.Timestamp)(nil),      // 28: google.protobuf.Timestamp
 }
 var file_trip_proto_depIdxs = []int32{
 	2,  // 0: trip.PreviewTripRequest.startLocation:type_name -> trip.Coordinate
 	2,  // 1: trip.PreviewTripRequest.endLocation:type_name -> trip.Coordinate
-	4,  // 2: trip.PreviewTripResponse.route:type_name -> trip.Route
-	5,  // 3: trip.PreviewTripResponse.rideFares:type_name -> trip.RideFare
-	2,  // 4: trip.Geometry.coordinates:type_name -> trip.Coordinate
-	3,  // 5: trip.Route.geometry:type_name -> trip.Geometry
-	8,  // 6: trip.CreateTripResponse.trip:type_name -> trip.Trip
-	5,  // 7: trip.Trip.selectedFare:type_name -> trip.RideFare
-	4,  // 8: trip.Trip.route:type_name -> trip.Route
-	9,  // 9: trip.Trip.driver:type_name -> trip.TripDriver
-	0,  // 10: trip.TripService.PreviewTrip:input_type -> trip.PreviewTripRequest
-	6,  // 11: trip.TripService.CreateTrip:input_type -> trip.CreateTripRequest
-	1,  // 12: trip.TripService.PreviewTrip:output_type -> trip.PreviewTripResponse
-	7,  // 13: trip.TripService.CreateTrip:output_type -> trip.CreateTripResponse
-	12, // [12:14] is the sub-list for method output_type
-	10, // [10:12] is the sub-list for method input_type
-	10, // [10:10] is the sub-list for extension type_name
-	10, // [10:10] is the sub-list for extension extendee
-	0,  // [0:10] is the sub-list for field type_name
+	28, // 2: trip.PreviewTripRequest.scheduledFor:type_name -> google.protobuf.Timestamp
+	2,  // 3: trip.PreviewTripRequest.stops:type_name -> trip.Coordinate
+	4,  // 4: trip.PreviewTripResponse.route:type_name -> trip.Route
+	6,  // 5: trip.PreviewTripResponse.rideFares:type_name -> trip.RideFare
+	2,  // 6: trip.Geometry.coordinates:type_name -> trip.Coordinate
+	3,  // 7: trip.Route.geometry:type_name -> trip.Geometry
+	5,  // 8: trip.Route.legs:type_name -> trip.RouteLeg
+	28, // 9: trip.RideFare.expiresAt:type_name -> google.protobuf.Timestamp
+	7,  // 10: trip.RideFare.lineItems:type_name -> trip.FareLineItem
+	8,  // 11: trip.RideFare.totalPrice:type_name -> trip.Money
+	8,  // 12: trip.RideFare.discount:type_name -> trip.Money
+	28, // 13: trip.RideFare.scheduledFor:type_name -> google.protobuf.Timestamp
+	2,  // 14: trip.RideFare.pickup:type_name -> trip.Coordinate
+	8,  // 15: trip.FareLineItem.amount:type_name -> trip.Money
+	22, // 16: trip.CreateTripResponse.trip:type_name -> trip.Trip
+	22, // 17: trip.CancelTripResponse.trip:type_name -> trip.Trip
+	8,  // 18: trip.CancelTripResponse.cancellationFee:type_name -> trip.Money
+	22, // 19: trip.GetTripResponse.trip:type_name -> trip.Trip
+	28, // 20: trip.ListTripsRequest.createdFrom:type_name -> google.protobuf.Timestamp
+	28, // 21: trip.ListTripsRequest.createdTo:type_name -> google.protobuf.Timestamp
+	22, // 22: trip.ListTripsResponse.trips:type_name -> trip.Trip
+	22, // 23: trip.ListScheduledTripsResponse.trips:type_name -> trip.Trip
+	21, // 24: trip.ListPackagesResponse.packages:type_name -> trip.VehiclePackage
+	6,  // 25: trip.Trip.selectedFare:type_name -> trip.RideFare
+	4,  // 26: trip.Trip.route:type_name -> trip.Route
+	24, // 27: trip.Trip.driver:type_name -> trip.TripDriver
+	28, // 28: trip.Trip.createdAt:type_name -> google.protobuf.Timestamp
+	28, // 29: trip.Trip.scheduledFor:type_name -> google.protobuf.Timestamp
+	23, // 30: trip.Trip.stops:type_name -> trip.TripStop
+	2,  // 31: trip.Trip.pickup:type_name -> trip.Coordinate
+	28, // 32: trip.Trip.paidAt:type_name -> google.protobuf.Timestamp
+	2,  // 33: trip.TripStop.location:type_name -> trip.Coordinate
+	28, // 34: trip.TripStop.reachedAt:type_name -> google.protobuf.Timestamp
+	27, // 35: trip.GetTripTimelineResponse.entries:type_name -> trip.TimelineEntry
+	28, // 36: trip.TimelineEntry.at:type_name -> google.protobuf.Timestamp
+	0,  // 37: trip.TripService.PreviewTrip:input_type -> trip.PreviewTripRequest
+	9,  // 38: trip.TripService.CreateTrip:input_type -> trip.CreateTripRequest
+	11, // 39: trip.TripService.CancelTrip:input_type -> trip.CancelTripRequest
+	13, // 40: trip.TripService.GetTrip:input_type -> trip.GetTripRequest
+	15, // 41: trip.TripService.ListTrips:input_type -> trip.ListTripsRequest
+	19, // 42: trip.TripService.ListPackages:input_type -> trip.ListPackagesRequest
+	17, // 43: trip.TripService.ListScheduledTrips:input_type -> trip.ListScheduledTripsRequest
+	25, // 44: trip.TripService.GetTripTimeline:input_type -> trip.GetTripTimelineRequest
+	1,  // 45: trip.TripService.PreviewTrip:output_type -> trip.PreviewTripResponse
+	10, // 46: trip.TripService.CreateTrip:output_type -> trip.CreateTripResponse
+	12, // 47: trip.TripService.CancelTrip:output_type -> trip.CancelTripResponse
+	14, // 48: trip.TripService.GetTrip:output_type -> trip.GetTripResponse
+	16, // 49: trip.TripService.ListTrips:output_type -> trip.ListTripsResponse
+	20, // 50: trip.TripService.ListPackages:output_type -> trip.ListPackagesResponse
+	18, // 51: trip.TripService.ListScheduledTrips:output_type -> trip.ListScheduledTripsResponse
+	26, // 52: trip.TripService.GetTripTimeline:output_type -> trip.GetTripTimelineResponse
+	45, // [45:53] is the sub-list for method output_type
+	37, // [37:45] is the sub-list for method input_type
+	37, // [37:37] is the sub-list for extension type_name
+	37, // [37:37] is the sub-list for extension extendee
+	0,  // [0:37] is the sub-list for field type_name
 }
 
 func init() { file_trip_proto_init() }
@@ -736,7 +2117,7 @@ func file_trip_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_trip_proto_rawDesc), len(file_trip_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   10,
+			NumMessages:   28,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -0,0 +1,268 @@
+/*
+Package resilience protects the calls to outbound dependencies with circuit breakers,
+concurrency bulkheads and per-call timeouts, see Policy to combine them.
+*/
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/metrics"
+)
+
+var (
+	// ErrCircuitOpen is returned without calling the dependency while its breaker is open
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+	// ErrInvalidConfig is returned when creating a breaker or a policy from unusable values
+	ErrInvalidConfig = errors.New("invalid resilience config")
+)
+
+// State is the state of a circuit breaker, the values are the ones exported in the metrics
+type State int
+
+const (
+	StateClosed   State = 0
+	StateOpen     State = 1
+	StateHalfOpen State = 2
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// windowBuckets is the number of buckets the failure rate window is split into
+const windowBuckets = 10
+
+type BreakerConfig struct {
+	// Window is the period over which the failure rate is computed
+	Window time.Duration
+	// MinRequests is the number of calls in the window before the breaker can open
+	MinRequests int
+	// FailureRate opens the breaker when reached, between 0 and 1
+	FailureRate float64
+	// OpenTimeout is how long the breaker stays open before letting trial calls through
+	OpenTimeout time.Duration
+	// HalfOpenCalls is the number of successful trial calls needed to close the breaker
+	HalfOpenCalls int
+	// IsFailure reports whether an error counts as a failure of the dependency,
+	// by default every error but the caller cancelling its context
+	IsFailure func(err error) bool
+}
+
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		Window:        30 * time.Second,
+		MinRequests:   10,
+		FailureRate:   0.5,
+		OpenTimeout:   15 * time.Second,
+		HalfOpenCalls: 3,
+	}
+}
+
+// Validate checks that the breaker can work with the config
+func (c BreakerConfig) Validate() error {
+	switch {
+	case c.Window/windowBuckets <= 0:
+		return fmt.Errorf("%w: the breaker window must be positive, got %s", ErrInvalidConfig, c.Window)
+	case c.MinRequests < 1:
+		return fmt.Errorf("%w: the breaker needs at least one request, got %d", ErrInvalidConfig, c.MinRequests)
+	case c.FailureRate <= 0 || c.FailureRate > 1:
+		return fmt.Errorf("%w: the breaker failure rate must be in (0, 1], got %g", ErrInvalidConfig, c.FailureRate)
+	case c.OpenTimeout < 0:
+		return fmt.Errorf("%w: the breaker open timeout can't be negative, got %s", ErrInvalidConfig, c.OpenTimeout)
+	case c.HalfOpenCalls < 1:
+		return fmt.Errorf("%w: the breaker needs at least one trial call, got %d", ErrInvalidConfig, c.HalfOpenCalls)
+	}
+	return nil
+}
+
+func isFailure(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+type bucket struct {
+	start     time.Time
+	successes int
+	failures  int
+}
+
+// CircuitBreaker stops calling a dependency once too many calls fail. It opens when the
+// failure rate over the window reaches the threshold, lets a few trial calls through after
+// the open timeout (half-open), then closes again once they all succeed.
+type CircuitBreaker struct {
+	name    string
+	config  BreakerConfig
+	metrics *metrics.Metrics
+
+	mu       sync.Mutex
+	state    State
+	buckets  [windowBuckets]bucket
+	openedAt time.Time
+	// trials counts the trial calls let through while half-open, and successes the ones that succeeded
+	trials    int
+	successes int
+}
+
+// NewCircuitBreaker creates a closed breaker, name labels its state in the metrics.
+// It returns an error matching ErrInvalidConfig for a config that doesn't validate.
+func NewCircuitBreaker(name string, config BreakerConfig, m *metrics.Metrics) (*CircuitBreaker, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("circuit breaker %s: %w", name, err)
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = isFailure
+	}
+
+	b := &CircuitBreaker{
+		name:    name,
+		config:  config,
+		metrics: m,
+	}
+	b.recordState()
+
+	return b, nil
+}
+
+func (b *CircuitBreaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refreshState(time.Now())
+	return b.state
+}
+
+// Execute calls fn unless the breaker is open
+func (b *CircuitBreaker) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	err := fn(ctx)
+	b.record(err)
+
+	return err
+}
+
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refreshState(time.Now())
+
+	switch b.state {
+	case StateOpen:
+		return ErrCircuitOpen
+	case StateHalfOpen:
+		if b.trials >= b.config.HalfOpenCalls {
+			return ErrCircuitOpen
+		}
+		b.trials++
+	}
+
+	return nil
+}
+
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	failed := b.config.IsFailure(err)
+
+	switch b.state {
+	case StateHalfOpen:
+		if failed {
+			b.setState(StateOpen, now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.config.HalfOpenCalls {
+			b.setState(StateClosed, now)
+		}
+	case StateClosed:
+		current := b.bucket(now)
+		if failed {
+			current.failures++
+		} else {
+			current.successes++
+		}
+
+		successes, failures := b.counts(now)
+		total := successes + failures
+		if total >= b.config.MinRequests && float64(failures)/float64(total) >= b.config.FailureRate {
+			b.setState(StateOpen, now)
+		}
+	}
+}
+
+// refreshState moves an open breaker to half-open once the open timeout is over
+func (b *CircuitBreaker) refreshState(now time.Time) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.config.OpenTimeout {
+		b.setState(StateHalfOpen, now)
+	}
+}
+
+func (b *CircuitBreaker) setState(state State, now time.Time) {
+	if b.state == state {
+		return
+	}
+
+	log.Printf("Circuit breaker %s: %s -> %s", b.name, b.state, state)
+
+	b.state = state
+	b.trials = 0
+	b.successes = 0
+
+	switch state {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		// Start over, the failures that opened the breaker are no longer relevant
+		b.buckets = [windowBuckets]bucket{}
+	}
+
+	b.recordState()
+}
+
+func (b *CircuitBreaker) recordState() {
+	if b.metrics != nil {
+		b.metrics.RecordCircuitBreakerState(b.name, int(b.state))
+	}
+}
+
+// bucket returns the bucket of the current time, reset if it held an older period
+func (b *CircuitBreaker) bucket(now time.Time) *bucket {
+	width := b.config.Window / windowBuckets
+	start := now.Truncate(width)
+	current := &b.buckets[int(start.UnixNano()/int64(width))%windowBuckets]
+
+	if !current.start.Equal(start) {
+		*current = bucket{start: start}
+	}
+
+	return current
+}
+
+// counts sums the buckets that are still in the window
+func (b *CircuitBreaker) counts(now time.Time) (successes, failures int) {
+	for _, bk := range b.buckets {
+		if now.Sub(bk.start) < b.config.Window {
+			successes += bk.successes
+			failures += bk.failures
+		}
+	}
+	return successes, failures
+}
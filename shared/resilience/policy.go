@@ -0,0 +1,98 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Anurag-Mishra22/taxi/shared/metrics"
+)
+
+type PolicyConfig struct {
+	Breaker BreakerConfig
+	// MaxConcurrent is the bulkhead size, zero disables the bulkhead
+	MaxConcurrent int
+	// MaxWait is how long a call waits for a bulkhead slot
+	MaxWait time.Duration
+	// Timeout bounds every call, zero disables it
+	Timeout time.Duration
+}
+
+func DefaultPolicyConfig() PolicyConfig {
+	return PolicyConfig{
+		Breaker:       DefaultBreakerConfig(),
+		MaxConcurrent: 20,
+		MaxWait:       100 * time.Millisecond,
+		Timeout:       5 * time.Second,
+	}
+}
+
+// Policy protects the calls to one dependency. A call first waits for a bulkhead slot,
+// is rejected while the breaker is open and is cancelled after the timeout.
+type Policy struct {
+	breaker  *CircuitBreaker
+	bulkhead *Bulkhead
+	timeout  time.Duration
+}
+
+// NewPolicy creates the policy of a dependency, name labels its breaker in the metrics.
+// It returns an error matching ErrInvalidConfig for a config that doesn't validate.
+func NewPolicy(name string, config PolicyConfig, m *metrics.Metrics) (*Policy, error) {
+	if config.MaxConcurrent < 0 || config.MaxWait < 0 || config.Timeout < 0 {
+		return nil, fmt.Errorf("policy %s: %w: negative bulkhead size, wait or timeout", name, ErrInvalidConfig)
+	}
+
+	breaker, err := NewCircuitBreaker(name, config.Breaker, m)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Policy{
+		breaker: breaker,
+		timeout: config.Timeout,
+	}
+	if config.MaxConcurrent > 0 {
+		p.bulkhead = NewBulkhead(config.MaxConcurrent, config.MaxWait)
+	}
+	return p, nil
+}
+
+func (p *Policy) Breaker() *CircuitBreaker {
+	return p.breaker
+}
+
+func (p *Policy) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	call := func(ctx context.Context) error {
+		return p.breaker.Execute(ctx, func(ctx context.Context) error {
+			return WithTimeout(ctx, p.timeout, fn)
+		})
+	}
+
+	if p.bulkhead == nil {
+		return call(ctx)
+	}
+	return p.bulkhead.Execute(ctx, call)
+}
+
+// Call is Execute for functions returning a value
+func Call[T any](ctx context.Context, p *Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := p.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+	return result, err
+}
+
+// WithTimeout calls fn with a context cancelled after timeout, zero means no timeout
+func WithTimeout(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return fn(ctx)
+}
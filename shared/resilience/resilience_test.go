@@ -0,0 +1,163 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errDependency = errors.New("dependency failed")
+
+func testBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		Window:        time.Minute,
+		MinRequests:   4,
+		FailureRate:   0.5,
+		OpenTimeout:   20 * time.Millisecond,
+		HalfOpenCalls: 2,
+	}
+}
+
+func newTestBreaker(t *testing.T, config BreakerConfig) *CircuitBreaker {
+	b, err := NewCircuitBreaker("test", config, nil)
+	require.NoError(t, err)
+	return b
+}
+
+func call(b *CircuitBreaker, err error) error {
+	return b.Execute(context.Background(), func(context.Context) error { return err })
+}
+
+func TestCircuitBreakerOpensOnFailureRate(t *testing.T) {
+	b := newTestBreaker(t, testBreakerConfig())
+
+	call(b, nil)
+	call(b, nil)
+	call(b, errDependency)
+	assert.Equal(t, StateClosed, b.State(), "below MinRequests the breaker stays closed")
+
+	call(b, errDependency)
+	assert.Equal(t, StateOpen, b.State())
+
+	called := false
+	err := b.Execute(context.Background(), func(context.Context) error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.False(t, called)
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	b := newTestBreaker(t, testBreakerConfig())
+	for range 4 {
+		call(b, errDependency)
+	}
+	assert.Equal(t, StateOpen, b.State())
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	// A failed trial call opens the breaker again
+	call(b, errDependency)
+	assert.Equal(t, StateOpen, b.State())
+
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, call(b, nil))
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.NoError(t, call(b, nil))
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestCircuitBreakerIgnoresNonFailures(t *testing.T) {
+	config := testBreakerConfig()
+	config.IsFailure = func(err error) bool { return err != nil && !errors.Is(err, errDependency) }
+	b := newTestBreaker(t, config)
+
+	for range 10 {
+		call(b, errDependency)
+	}
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBulkheadRejectsWhenFull(t *testing.T) {
+	bulkhead := NewBulkhead(1, 10*time.Millisecond)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		bulkhead.Execute(context.Background(), func(context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	err := bulkhead.Execute(context.Background(), func(context.Context) error { return nil })
+	assert.ErrorIs(t, err, ErrBulkheadFull)
+
+	close(release)
+	wg.Wait()
+	assert.NoError(t, bulkhead.Execute(context.Background(), func(context.Context) error { return nil }))
+}
+
+func TestPolicyTimeout(t *testing.T) {
+	config := DefaultPolicyConfig()
+	config.Timeout = 10 * time.Millisecond
+	policy, err := NewPolicy("test", config, nil)
+	require.NoError(t, err)
+
+	_, err = Call(context.Background(), policy, func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	result, err := Call(context.Background(), policy, func(context.Context) (string, error) {
+		return "ok", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", result)
+}
+
+func TestBreakerConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *BreakerConfig)
+	}{
+		{"zero_window", func(c *BreakerConfig) { c.Window = 0 }},
+		{"window_shorter_than_its_buckets", func(c *BreakerConfig) { c.Window = windowBuckets - 1 }},
+		{"no_min_requests", func(c *BreakerConfig) { c.MinRequests = 0 }},
+		{"zero_failure_rate", func(c *BreakerConfig) { c.FailureRate = 0 }},
+		{"failure_rate_above_one", func(c *BreakerConfig) { c.FailureRate = 1.5 }},
+		{"negative_open_timeout", func(c *BreakerConfig) { c.OpenTimeout = -time.Second }},
+		{"no_trial_calls", func(c *BreakerConfig) { c.HalfOpenCalls = 0 }},
+	}
+
+	assert.NoError(t, testBreakerConfig().Validate())
+	assert.NoError(t, DefaultBreakerConfig().Validate())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testBreakerConfig()
+			tt.change(&config)
+
+			_, err := NewCircuitBreaker("test", config, nil)
+			assert.ErrorIs(t, err, ErrInvalidConfig)
+
+			policyConfig := DefaultPolicyConfig()
+			policyConfig.Breaker = config
+			_, err = NewPolicy("test", policyConfig, nil)
+			assert.ErrorIs(t, err, ErrInvalidConfig)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	pb "github.com/Anurag-Mishra22/taxi/shared/proto/trip"
+)
+
+// Money is an amount in the minor unit of its currency (cents for USD, yen for JPY)
+type Money struct {
+	AmountMinor int64  `json:"amountMinor" bson:"amountMinor"`
+	Currency    string `json:"currency" bson:"currency"` // ISO 4217, ex: "USD"
+}
+
+// minorUnitExponents lists the currencies whose minor unit isn't 1/100 of the major unit
+var minorUnitExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// MinorUnitExponent returns the number of decimals of a currency (2 for USD, 0 for JPY)
+func MinorUnitExponent(currency string) int {
+	if exp, ok := minorUnitExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+func NewMoney(amountMinor int64, currency string) Money {
+	return Money{AmountMinor: amountMinor, Currency: strings.ToUpper(currency)}
+}
+
+// roundingIncrements lists the currencies charged in steps of more than one minor unit
+var roundingIncrements = map[string]int64{
+	"CHF": 5, // the smallest coin is 5 centimes
+}
+
+// RoundingIncrement returns the smallest step, in minor units, a currency is charged in
+func RoundingIncrement(currency string) int64 {
+	if increment, ok := roundingIncrements[strings.ToUpper(currency)]; ok {
+		return increment
+	}
+	return 1
+}
+
+// RoundMoney turns a computed amount, in minor units of currency, into Money.
+// The amount is rounded to the rounding increment of the currency, halves away
+// from zero, so it must be the only place where computed prices lose precision.
+func RoundMoney(amountMinor float64, currency string) Money {
+	increment := float64(RoundingIncrement(currency))
+	return NewMoney(int64(math.Round(amountMinor/increment)*increment), currency)
+}
+
+// SameCurrency tells if two amounts can be added up
+func (m Money) SameCurrency(other Money) bool {
+	return strings.EqualFold(m.Currency, other.Currency)
+}
+
+// Add returns the sum of two amounts, it panics when the currencies differ:
+// amounts of different currencies are never meant to meet
+func (m Money) Add(other Money) Money {
+	m.mustMatch(other)
+	return Money{AmountMinor: m.AmountMinor + other.AmountMinor, Currency: m.Currency}
+}
+
+// Sub returns the difference of two amounts, it panics when the currencies differ
+func (m Money) Sub(other Money) Money {
+	m.mustMatch(other)
+	return Money{AmountMinor: m.AmountMinor - other.AmountMinor, Currency: m.Currency}
+}
+
+func (m Money) mustMatch(other Money) {
+	if !m.SameCurrency(other) {
+		panic(fmt.Sprintf("money: currency mismatch, %s and %s", m.Currency, other.Currency))
+	}
+}
+
+func (m Money) IsZero() bool {
+	return m.AmountMinor == 0
+}
+
+// Major returns the amount in major units (dollars for USD), only meant for display and metrics
+func (m Money) Major() float64 {
+	return float64(m.AmountMinor) / math.Pow10(MinorUnitExponent(m.Currency))
+}
+
+func (m Money) String() string {
+	exp := MinorUnitExponent(m.Currency)
+	return fmt.Sprintf("%.*f %s", exp, m.Major(), m.Currency)
+}
+
+func (m Money) ToProto() *pb.Money {
+	return &pb.Money{
+		AmountMinor: m.AmountMinor,
+		Currency:    m.Currency,
+	}
+}
+
+func MoneyFromProto(m *pb.Money) Money {
+	return NewMoney(m.GetAmountMinor(), m.GetCurrency())
+}
@@ -0,0 +1,46 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   float64
+		currency string
+		expected Money
+	}{
+		{"rounds_down", 1234.4, "usd", Money{AmountMinor: 1234, Currency: "USD"}},
+		{"rounds_half_up", 1234.5, "USD", Money{AmountMinor: 1235, Currency: "USD"}},
+		{"zero_decimal_currency", 1499.5, "JPY", Money{AmountMinor: 1500, Currency: "JPY"}},
+		{"negative", -10.5, "EUR", Money{AmountMinor: -11, Currency: "EUR"}},
+		{"rounding_increment", 1232.4, "CHF", Money{AmountMinor: 1230, Currency: "CHF"}},
+		{"rounding_increment_half_up", 1232.5, "chf", Money{AmountMinor: 1235, Currency: "CHF"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RoundMoney(tt.amount, tt.currency))
+		})
+	}
+}
+
+func TestMoneyMajor(t *testing.T) {
+	assert.Equal(t, 12.34, NewMoney(1234, "USD").Major())
+	assert.Equal(t, 1234.0, NewMoney(1234, "JPY").Major())
+	assert.Equal(t, 1.234, NewMoney(1234, "KWD").Major())
+	assert.Equal(t, "12.34 USD", NewMoney(1234, "USD").String())
+	assert.Equal(t, "1234 JPY", NewMoney(1234, "JPY").String())
+}
+
+func TestMoneyAddAndSub(t *testing.T) {
+	total := NewMoney(1250, "USD").Add(NewMoney(150, "usd"))
+	assert.Equal(t, NewMoney(1400, "USD"), total)
+	assert.Equal(t, NewMoney(900, "USD"), total.Sub(NewMoney(500, "USD")))
+
+	assert.Panics(t, func() { NewMoney(1250, "USD").Add(NewMoney(150, "EUR")) })
+	assert.Panics(t, func() { NewMoney(1250, "USD").Sub(NewMoney(150, "JPY")) })
+}
@@ -1,5 +1,13 @@
 package types
 
+import (
+	"errors"
+	"math"
+)
+
+// ErrInvalidCoordinate is returned for a coordinate outside of the valid latitude and longitude ranges
+var ErrInvalidCoordinate = errors.New("invalid coordinate")
+
 type Route struct {
 	Distance float64     `json:"distance"`
 	Duration float64     `json:"duration"`
@@ -10,7 +18,37 @@ type Geometry struct {
 	Coordinates []*Coordinate `json:"coordinates"`
 }
 
+// MaxLatitude is the latitude limit of the Web Mercator projection, the Redis GEO
+// indexes reject the points closer to the poles
+const MaxLatitude = 85.05112878
+
 type Coordinate struct {
 	Latitude  float64 `json:"latitude"`
 	Longitude float64 `json:"longitude"`
 }
+
+// Validate checks that the coordinate is a point on Earth the GEO indexes can store
+func (c *Coordinate) Validate() error {
+	if c == nil || math.IsNaN(c.Latitude) || math.IsNaN(c.Longitude) {
+		return ErrInvalidCoordinate
+	}
+	if c.Latitude < -MaxLatitude || c.Latitude > MaxLatitude || c.Longitude < -180 || c.Longitude > 180 {
+		return ErrInvalidCoordinate
+	}
+	return nil
+}
+
+// earthRadiusKm is the mean radius of the Earth
+const earthRadiusKm = 6371.0
+
+// DistanceKm returns the great-circle (haversine) distance between two coordinates
+func (c *Coordinate) DistanceKm(other *Coordinate) float64 {
+	lat1 := c.Latitude * math.Pi / 180
+	lat2 := other.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (other.Longitude - c.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,31 @@
+package types
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoordinateValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		coord *Coordinate
+		valid bool
+	}{
+		{"valid", &Coordinate{Latitude: 40.7128, Longitude: -74.0060}, true},
+		{"bounds", &Coordinate{Latitude: -MaxLatitude, Longitude: 180}, true},
+		{"beyond_the_geo_index_limit", &Coordinate{Latitude: 85.1, Longitude: 0}, false},
+		{"pole", &Coordinate{Latitude: -90, Longitude: 0}, false},
+		{"latitude_out_of_range", &Coordinate{Latitude: 90.1, Longitude: 0}, false},
+		{"longitude_out_of_range", &Coordinate{Latitude: 0, Longitude: -180.5}, false},
+		{"nan", &Coordinate{Latitude: math.NaN(), Longitude: 0}, false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.valid, tt.coord.Validate() == nil)
+		})
+	}
+}